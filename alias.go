@@ -0,0 +1,44 @@
+package sqload
+
+import "strings"
+
+// expandAliases returns a copy of queries and headers with every query added under each
+// of the names written in its alias annotation, so a renamed query can still be found by
+// its old names. The variants of a query are copied under its aliases too, and the
+// headers of the aliases record the query they refer to. If an alias is not a valid query
+// name or it clashes with another query, it will return an error.
+func expandAliases(queries map[string]string, headers map[string]header) (map[string]string, map[string]header, error) {
+	expanded := copyQueries(queries)
+	expandedHeaders := make(map[string]header, len(headers))
+	for name, h := range headers {
+		expandedHeaders[name] = h
+	}
+	for _, key := range sortedKeys(queries) {
+		name, variant, isVariant := strings.Cut(key, variantSeparator)
+		for _, alias := range headers[name].annotations.List("alias") {
+			if !validQueryNamePattern.MatchString(alias) {
+				return nil, nil, errorf(CodeInvalidName, "%w: invalid alias %s of query %s", ErrCannotLoadQueries, alias, name)
+			}
+			aliasKey := alias
+			if isVariant {
+				aliasKey = variantKey(alias, variant)
+			}
+			if _, found := expanded[aliasKey]; found {
+				return nil, nil, errorf(CodeConflict, "%w: alias %s of query %s clashes with query %s", ErrCannotLoadQueries, alias, name, aliasKey)
+			}
+			expanded[aliasKey] = queries[key]
+			h := headers[key]
+			h.aliasOf = name
+			expandedHeaders[aliasKey] = h
+		}
+	}
+	return expanded, expandedHeaders, nil
+}
+
+// warnAlias calls the deprecation handler of cfg, if any, when name is an alias of
+// another query, as recorded in the headers being bound.
+func warnAlias(name string, cfg *config) {
+	if target := cfg.headers[name].aliasOf; target != "" && cfg.deprecationHandler != nil {
+		cfg.deprecationHandler(name, target)
+	}
+}
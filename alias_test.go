@@ -0,0 +1,124 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAliases(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte(`-- query: FindUserByID
+-- alias: FindUserByIdV1, GetUser
+SELECT * FROM user WHERE id = :id;
+
+-- query: FindUserByID variant=fast
+SELECT id FROM user WHERE id = :id;
+`)},
+	}
+	var warnings []string
+	handler := WithDeprecationHandler(func(alias, name string) {
+		warnings = append(warnings, alias+"->"+name)
+	})
+	qs, err := LoadQuerySet(fsys, handler)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedNames := []string{"FindUserByID", "FindUserByIdV1", "GetUser"}
+	if fmt.Sprint(qs.Names()) != fmt.Sprint(wantedNames) {
+		t.Errorf("got %v, want %v", qs.Names(), wantedNames)
+	}
+	q, _ := qs.Query("FindUserByID")
+	if fmt.Sprint(q.Aliases) != "[FindUserByIdV1 GetUser]" || q.AliasOf != "" {
+		t.Errorf("got aliases %v and alias of %q, want [FindUserByIdV1 GetUser] and \"\"", q.Aliases, q.AliasOf)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("got warnings %v, want none", warnings)
+	}
+	alias, _ := qs.Query("FindUserByIdV1")
+	if alias.AliasOf != "FindUserByID" || alias.Aliases != nil || alias.SQL != q.SQL {
+		t.Errorf("got alias of %q, aliases %v and SQL %s, want FindUserByID, none and %s", alias.AliasOf, alias.Aliases, alias.SQL, q.SQL)
+	}
+	if sql, _ := qs.Select("GetUser", Flags{"GetUser": "fast"}); sql != "SELECT id FROM user WHERE id = :id;" {
+		t.Errorf("got %s, want the fast variant", sql)
+	}
+	if fmt.Sprint(warnings) != "[FindUserByIdV1->FindUserByID]" {
+		t.Errorf("got %v, want [FindUserByIdV1->FindUserByID]", warnings)
+	}
+
+	type Queries struct {
+		FindUserByIdV1 string `query:"FindUserByIdV1"`
+	}
+	warnings = nil
+	v, err := LoadFromFS[Queries](fsys, handler)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if v.FindUserByIdV1 != q.SQL {
+		t.Errorf("got %s, want %s", v.FindUserByIdV1, q.SQL)
+	}
+	if fmt.Sprint(warnings) != "[FindUserByIdV1->FindUserByID]" {
+		t.Errorf("got %v, want [FindUserByIdV1->FindUserByID]", warnings)
+	}
+}
+
+func TestAliasErrors(t *testing.T) {
+	testCases := []struct {
+		sql     string
+		wantErr string
+	}{
+		{
+			sql:     "-- query: A\n-- alias: B\nSELECT 1;\n-- query: B\nSELECT 2;\n",
+			wantErr: "cannot load queries: alias B of query A clashes with query B",
+		},
+		{
+			sql:     "-- query: A\n-- alias: B-1\nSELECT 1;\n",
+			wantErr: "cannot load queries: invalid alias B-1 of query A",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte(tc.sql)}})
+			if fmt.Sprint(err) != tc.wantErr {
+				t.Errorf("got %s, want %s", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestAliasesUpdate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("-- query: FindUserByID\n-- alias: GetUser\nSELECT * FROM user WHERE id = :id;\n")},
+	}
+	var warnings []string
+	qs, err := LoadQuerySet(fsys, WithDeprecationHandler(func(alias, name string) {
+		warnings = append(warnings, alias+"->"+name)
+	}))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	fsys["users.sql"] = &fstest.MapFile{Data: []byte("-- query: FindUserByID\nSELECT * FROM user WHERE id = :id;\n\n-- query: GetUser\nSELECT * FROM user LIMIT 1;\n")}
+	updated, err := qs.Update(fsys, []string{"users.sql"})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q, _ := updated.Query("GetUser"); q.AliasOf != "" || q.SQL != "SELECT * FROM user LIMIT 1;" {
+		t.Errorf("got alias of %q and SQL %s, want \"\" and SELECT * FROM user LIMIT 1;", q.AliasOf, q.SQL)
+	}
+	if q, _ := qs.Query("GetUser"); q.AliasOf != "FindUserByID" {
+		t.Errorf("got alias of %q, want FindUserByID", q.AliasOf)
+	}
+	warnings = nil
+	if _, found := updated.Select("GetUser", nil); !found || len(warnings) != 0 {
+		t.Errorf("got found %t and warnings %v, want true and no warnings", found, warnings)
+	}
+
+	queries := map[string]string{"FindUserByID": "SELECT 1;"}
+	headers := map[string]header{"FindUserByID": parseHeader("-- alias: GetUser\nSELECT 1;")}
+	if _, _, err := expandAliases(queries, headers); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if len(queries) != 1 || len(headers) != 1 {
+		t.Errorf("got %v and %d headers, want the queries and the headers unchanged", queries, len(headers))
+	}
+}
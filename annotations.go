@@ -0,0 +1,165 @@
+package sqload
+
+import (
+	"strings"
+	"time"
+)
+
+// Annotations are the key: value comments written in the header of a query, right after
+// its query comment and before its SQL code. Keys are case-insensitive and are stored in
+// lower case; a key may appear more than once.
+//
+//	-- query: FindUsers
+//	-- orderable: created_at, name
+//	SELECT * FROM user;
+//
+// Some annotations are understood by the package itself: orderable (see
+// QuerySet.OrderBy), upsert (see ExpandUpsert), kind (see Query.Kind), isolation (see
+// Query.TxOptions), no-prepare (see Query.Preparable), budget (see ComplexityBudget),
+// param and assert. If a query has param annotations, the parameters they declare, one
+// per annotation, must be the named parameters of its SQL code. Assertions are checked
+// when the queries are loaded, and the load fails if any of them does not hold:
+//
+//	-- query: DeleteExpiredSessions
+//	-- assert: contains "WHERE"
+//	-- assert: max-lines 20
+//	-- assert: kind dml
+//	DELETE FROM session WHERE expires_at < now();
+//
+// The assertions are contains "text", not-contains "text", max-lines n and kind k, where
+// k is one of the kinds returned by Classify (dml, ddl, procedure or other).
+type Annotations map[string][]string
+
+// flagAnnotations are the annotations written without a value, like -- no-prepare.
+var flagAnnotations = map[string]bool{"no-prepare": true}
+
+// header is the header of a query: the comment lines that follow its query comment,
+// before its SQL code.
+type header struct {
+	annotations Annotations
+	doc         string
+	file        string // empty if the query was not read from a file
+	line        int    // line of the query comment
+	order       int    // position of the query among the queries of the source
+	aliasOf     string // name of the query the query is an alias of, if it is one
+	attrs       map[string]string
+	modTime     time.Time // modification time of file
+	size        int64     // size of file in bytes
+}
+
+// parseHeader returns the header of a query, made of the lines of its SQL code sql that
+// follow its query comment.
+func parseHeader(sql string) header {
+	h := header{annotations: Annotations{}}
+	// A single doc line, the usual case, is sliced out of sql instead of copied
+	var doc strings.Builder
+	docLines := 0
+	for start := 0; start < len(sql); start = lineEnd(sql, start) + 1 {
+		line := sql[start:lineEnd(sql, start)]
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if !strings.HasPrefix(trimmed, "--") {
+			break
+		}
+		if flag, isFlag := cutFlagAnnotation(trimmed); isFlag {
+			h.annotations[flag] = append(h.annotations[flag], "")
+			continue
+		}
+		if key, value, isAnnotation := cutAnnotation(line); isAnnotation {
+			key = strings.ToLower(key)
+			h.annotations[key] = append(h.annotations[key], value)
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(trimmed, "--"))
+		switch docLines++; docLines {
+		case 1:
+			h.doc = line
+		case 2:
+			doc.WriteString(h.doc)
+			fallthrough
+		default:
+			doc.WriteByte('\n')
+			doc.WriteString(line)
+		}
+	}
+	if docLines > 1 {
+		h.doc = doc.String()
+	}
+	return h
+}
+
+// cutFlagAnnotation returns the key of the annotation written without a value in the
+// trimmed comment line line, like -- no-prepare, and whether line is one. The key is
+// matched ignoring case.
+func cutFlagAnnotation(line string) (string, bool) {
+	text := strings.TrimSpace(strings.TrimPrefix(line, "--"))
+	for flag := range flagAnnotations {
+		if strings.EqualFold(text, flag) {
+			return flag, true
+		}
+	}
+	return "", false
+}
+
+// cutAnnotation returns the key and the value of the annotation written in the comment
+// line line, like -- orderable: created_at, and whether line is an annotation. The key
+// starts with a letter and is made of letters, digits, underscores and dashes.
+func cutAnnotation(line string) (string, string, bool) {
+	rest := strings.TrimLeft(line, " \t")
+	if !strings.HasPrefix(rest, "--") {
+		return "", "", false
+	}
+	rest = strings.TrimLeft(rest[2:], " \t")
+	colon := strings.IndexByte(rest, ':')
+	if colon <= 0 || !isLetter(rest[0]) {
+		return "", "", false
+	}
+	for i := 1; i < colon; i++ {
+		if c := rest[i]; !isLetter(c) && !('0' <= c && c <= '9') && c != '_' && c != '-' {
+			return "", "", false
+		}
+	}
+	return rest[:colon], strings.TrimRight(strings.TrimLeft(rest[colon+1:], " \t"), " \t\r"), true
+}
+
+// isLetter reports whether c is an ASCII letter.
+func isLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// Get returns the first value of the annotation key, or an empty string if there is none.
+func (a Annotations) Get(key string) string {
+	values := a[strings.ToLower(key)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// Has reports whether the annotation key is present.
+func (a Annotations) Has(key string) bool {
+	_, found := a[strings.ToLower(key)]
+	return found
+}
+
+// List returns the comma-separated items of all the values of the annotation key, with
+// the surrounding spaces removed and the empty items skipped.
+//
+//	-- orderable: created_at, name
+//	-- orderable: email
+//
+//	q.Annotations.List("orderable")
+//	// [created_at name email]
+func (a Annotations) List(key string) []string {
+	items := []string{}
+	for _, value := range a[strings.ToLower(key)] {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
@@ -0,0 +1,60 @@
+package sqload
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseHeader(t *testing.T) {
+	testCases := []struct {
+		lines           []string
+		wantAnnotations Annotations
+		wantDoc         string
+	}{
+		{[]string{"SELECT 1;"}, Annotations{}, ""},
+		{
+			[]string{"-- Finds the users.", "-- Orderable: created_at, name", "", "--timeout:5s  ", "--   Deleted users are skipped.", "SELECT 1;", "-- tag: ignored"},
+			Annotations{"orderable": {"created_at, name"}, "timeout": {"5s"}},
+			"Finds the users.\nDeleted users are skipped.",
+		},
+		{
+			[]string{"-- orderable: name", "-- orderable: email", "SELECT 1;"},
+			Annotations{"orderable": {"name", "email"}},
+			"",
+		},
+		{[]string{"-- note:", "SELECT '-- owner: me';"}, Annotations{"note": {""}}, ""},
+		{[]string{"-- Copies the cats.", "-- No-Prepare ", "-- no-prepare is set", "COPY cat FROM STDIN;"}, Annotations{"no-prepare": {""}}, "Copies the cats.\nno-prepare is set"},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got := parseHeader(strings.Join(testCase.lines, "\n"))
+			if fmt.Sprint(got.annotations) != fmt.Sprint(testCase.wantAnnotations) {
+				t.Errorf("got %v, want %v", got.annotations, testCase.wantAnnotations)
+			}
+			if got.doc != testCase.wantDoc {
+				t.Errorf("got %q, want %q", got.doc, testCase.wantDoc)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	a := Annotations{"orderable": {"created_at, name", " , email"}, "note": {""}}
+	if got := a.Get("Orderable"); got != "created_at, name" {
+		t.Errorf("got %s, want %s", got, "created_at, name")
+	}
+	if got := a.Get("timeout"); got != "" {
+		t.Errorf("got %s, want an empty string", got)
+	}
+	if !a.Has("note") || a.Has("timeout") {
+		t.Errorf("got %t and %t, want true and false", a.Has("note"), a.Has("timeout"))
+	}
+	want := []string{"created_at", "name", "email"}
+	if got := a.List("orderable"); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := a.List("timeout"); len(got) != 0 {
+		t.Errorf("got %v, want []", got)
+	}
+}
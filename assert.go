@@ -0,0 +1,65 @@
+package sqload
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// checkAssertion checks the assertion assertion, the value of an assert annotation (see
+// Annotations), against the SQL code sql written in dialect d. The text of contains and
+// not-contains can also be written without quotes if it has no surrounding spaces.
+func checkAssertion(assertion, sql string, d Dialect) error {
+	check, arg, _ := strings.Cut(strings.TrimSpace(assertion), " ")
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		return fmt.Errorf("invalid assertion %s", assertion)
+	}
+	switch check {
+	case "contains", "not-contains":
+		text := arg
+		if strings.HasPrefix(arg, `"`) {
+			var err error
+			if text, err = strconv.Unquote(arg); err != nil {
+				return fmt.Errorf("invalid assertion %s", assertion)
+			}
+		}
+		if strings.Contains(sql, text) != (check == "contains") {
+			return fmt.Errorf("assertion %s failed", assertion)
+		}
+	case "max-lines":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid assertion %s", assertion)
+		}
+		if lines := strings.Count(strings.TrimSpace(sql), "\n") + 1; lines > n {
+			return fmt.Errorf("assertion %s failed: the query has %d lines", assertion, lines)
+		}
+	case "kind":
+		if kind := Classify(sql, d); kind.String() != strings.ToLower(arg) {
+			return fmt.Errorf("assertion %s failed: the query is %s", assertion, kind)
+		}
+	default:
+		return fmt.Errorf("invalid assertion %s", assertion)
+	}
+	return nil
+}
+
+// checkAssertions checks the assertions written in the assert annotations of the
+// queries, see checkAssertion. The queries are checked in order of name, so the first
+// error is always the same.
+func checkAssertions(queries map[string]string, headers map[string]header, cfg *config) error {
+	names := sortedKeys(headers)
+	for _, name := range names {
+		sql, found := queries[name]
+		if !found {
+			continue
+		}
+		for _, assertion := range headers[name].annotations["assert"] {
+			if err := checkAssertion(assertion, sql, cfg.dialect); err != nil {
+				return errorf(CodeCheckFailed, "%w: query %s: %s", ErrCannotLoadQueries, name, err)
+			}
+		}
+	}
+	return nil
+}
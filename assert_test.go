@@ -0,0 +1,65 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCheckAssertion(t *testing.T) {
+	sql := "DELETE FROM session\n WHERE expires_at < now();"
+	testCases := []struct {
+		assertion string
+		want      error
+	}{
+		{`contains "WHERE"`, nil},
+		{`contains WHERE`, nil},
+		{`contains " WHERE expires_at"`, nil},
+		{`contains "LIMIT"`, fmt.Errorf(`assertion contains "LIMIT" failed`)},
+		{`not-contains "SELECT *"`, nil},
+		{`not-contains session`, fmt.Errorf("assertion not-contains session failed")},
+		{"max-lines 2", nil},
+		{"max-lines 1", fmt.Errorf("assertion max-lines 1 failed: the query has 2 lines")},
+		{"kind dml", nil},
+		{"kind DDL", fmt.Errorf("assertion kind DDL failed: the query is dml")},
+		{"max-lines many", fmt.Errorf("invalid assertion max-lines many")},
+		{`contains "WHERE`, fmt.Errorf(`invalid assertion contains "WHERE`)},
+		{"contains", fmt.Errorf("invalid assertion contains")},
+		{"is-fast yes", fmt.Errorf("invalid assertion is-fast yes")},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			err := checkAssertion(testCase.assertion, sql, DialectGeneric)
+			if fmt.Sprint(err) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %v", err, testCase.want)
+			}
+		})
+	}
+}
+
+func TestLoadWithAssertions(t *testing.T) {
+	sql := `
+-- query: DeleteExpiredSessions
+-- assert: contains "WHERE"
+-- assert: kind dml
+DELETE FROM session WHERE expires_at < now();
+`
+	q, err := LoadFromString[struct {
+		DeleteExpiredSessions string `query:"DeleteExpiredSessions"`
+	}](sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.DeleteExpiredSessions != "DELETE FROM session WHERE expires_at < now();" {
+		t.Errorf("got %s, want %s", q.DeleteExpiredSessions, "DELETE FROM session WHERE expires_at < now();")
+	}
+	sql += `
+-- query: DeleteAllSessions
+-- assert: contains "WHERE"
+DELETE FROM session;
+`
+	_, err = LoadFromString[struct{}](sql)
+	want := fmt.Errorf(`%w: query DeleteAllSessions: assertion contains "WHERE" failed`, ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
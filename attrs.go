@@ -0,0 +1,139 @@
+package sqload
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Attr returns the value of the attribute key of the query (see Query.Attributes), or
+// an empty string if the query has no such attribute.
+func (q Query) Attr(key string) string {
+	return q.Attributes[key]
+}
+
+// Attrs returns a copy of the attributes of the query, so it can be modified without
+// changing the query. It never returns nil.
+func (q Query) Attrs() map[string]string {
+	attrs := make(map[string]string, len(q.Attributes))
+	for key, value := range q.Attributes {
+		attrs[key] = value
+	}
+	return attrs
+}
+
+// AttrDuration returns the value of the attribute key of the query parsed by
+// time.ParseDuration, or fallback if the query has no such attribute. If the value is
+// not a valid duration, it will return an error.
+//
+//	-- query: FindUser timeout=2s
+//
+//	timeout, err := q.AttrDuration("timeout", 5*time.Second)
+//	if err != nil {
+//		return err
+//	}
+//	ctx, cancel := context.WithTimeout(ctx, timeout)
+func (q Query) AttrDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value, found := q.Attributes[key]
+	if !found {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, q.attrError("duration", key, value)
+	}
+	return d, nil
+}
+
+// AttrBool returns the value of the attribute key of the query parsed by
+// strconv.ParseBool, or fallback if the query has no such attribute. If the value is not
+// a valid boolean, it will return an error.
+func (q Query) AttrBool(key string, fallback bool) (bool, error) {
+	value, found := q.Attributes[key]
+	if !found {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, q.attrError("boolean", key, value)
+	}
+	return b, nil
+}
+
+// AttrInt returns the value of the attribute key of the query parsed as a base 10
+// integer, or fallback if the query has no such attribute. If the value is not a valid
+// integer, it will return an error.
+func (q Query) AttrInt(key string, fallback int) (int, error) {
+	value, found := q.Attributes[key]
+	if !found {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, q.attrError("integer", key, value)
+	}
+	return n, nil
+}
+
+// attrError returns the error for the value value of the attribute key of the query,
+// which is not a valid what.
+func (q Query) attrError(what, key, value string) error {
+	return fmt.Errorf("invalid %s %s of attribute %s of query %s", what, value, key, q.Name)
+}
+
+// durationType is the type of the time.Duration fields.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// bindAttrField sets the field i of the struct elem to the value of the attribute named
+// by the queryattr tag attrTag, written as the query name followed by a dot and the
+// attribute key, like queryattr:"FindUser.timeout". The value is parsed according to the
+// type of the field, which can be a string, a bool, an integer or a time.Duration. If the
+// query has no such attribute, the field is left unchanged. The name of the query is
+// relative to the namespace namespace.
+func bindAttrField(queries map[string]string, elem reflect.Value, i int, attrTag string, namespace string, cfg *config) error {
+	fieldName := elem.Type().Field(i).Name
+	dot := strings.LastIndex(attrTag, ".")
+	if dot == -1 {
+		return errorf(CodeBadField, "%w: field %s: invalid queryattr tag %s", ErrCannotLoadQueries, fieldName, attrTag)
+	}
+	queryName, key := attrTag[:dot], attrTag[dot+1:]
+	if _, found := queries[queryName]; !found {
+		return &missingQueryError{name: namespace + queryName}
+	}
+	value, found := cfg.headers[cfg.prefix+namespace+queryName].attrs[key]
+	if !found {
+		return nil
+	}
+	field := elem.Field(i)
+	if !field.CanSet() {
+		return errorf(CodeBadField, "%w: field %s cannot be changed", ErrCannotLoadQueries, fieldName)
+	}
+	var err error
+	switch {
+	case field.Type() == durationType:
+		var d time.Duration
+		if d, err = time.ParseDuration(value); err == nil {
+			field.SetInt(int64(d))
+		}
+	case field.Kind() == reflect.String:
+		field.SetString(value)
+	case field.Kind() == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(value); err == nil {
+			field.SetBool(b)
+		}
+	case field.CanInt():
+		var n int64
+		if n, err = strconv.ParseInt(value, 10, field.Type().Bits()); err == nil {
+			field.SetInt(n)
+		}
+	default:
+		return errorf(CodeBadField, "%w: field %s has the unsupported type %s", ErrCannotLoadQueries, fieldName, field.Type())
+	}
+	if err != nil {
+		return errorf(CodeBadField, "%w: field %s: invalid value %s of attribute %s of query %s%s", ErrCannotLoadQueries, fieldName, value, key, namespace, queryName)
+	}
+	return nil
+}
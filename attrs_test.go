@@ -0,0 +1,130 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestQueryAttr(t *testing.T) {
+	q := Query{Name: "FindUser", Attributes: map[string]string{"owner": "payments", "timeout": "2s", "cache": "false", "retries": "3", "bad": "x"}}
+	if got := q.Attr("owner"); got != "payments" {
+		t.Errorf("got %s, want payments", got)
+	}
+	if got := q.Attr("missing"); got != "" {
+		t.Errorf("got %s, want an empty string", got)
+	}
+	attrs := q.Attrs()
+	attrs["owner"] = "billing"
+	if q.Attr("owner") != "payments" {
+		t.Error("Attrs must return a copy")
+	}
+	if attrs := (Query{}).Attrs(); attrs == nil {
+		t.Error("Attrs must not return nil")
+	}
+	testCases := []struct {
+		get     func() (any, error)
+		want    any
+		wantErr error
+	}{
+		{func() (any, error) { return q.AttrDuration("timeout", time.Second) }, 2 * time.Second, nil},
+		{func() (any, error) { return q.AttrDuration("missing", time.Second) }, time.Second, nil},
+		{func() (any, error) { return q.AttrDuration("bad", time.Second) }, time.Duration(0), fmt.Errorf("invalid duration x of attribute bad of query FindUser")},
+		{func() (any, error) { return q.AttrBool("cache", true) }, false, nil},
+		{func() (any, error) { return q.AttrBool("missing", true) }, true, nil},
+		{func() (any, error) { return q.AttrBool("bad", true) }, false, fmt.Errorf("invalid boolean x of attribute bad of query FindUser")},
+		{func() (any, error) { return q.AttrInt("retries", 1) }, 3, nil},
+		{func() (any, error) { return q.AttrInt("missing", 1) }, 1, nil},
+		{func() (any, error) { return q.AttrInt("bad", 1) }, 0, fmt.Errorf("invalid integer x of attribute bad of query FindUser")},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got, err := tc.get()
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %v, want %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadQueryAttrs(t *testing.T) {
+	sql := `-- query: FindUser timeout=2s cache=false retries=3 owner=payments
+SELECT * FROM user WHERE id = :id;
+
+-- query: billing.FindInvoice timeout=1m
+SELECT * FROM invoice WHERE id = :id;`
+	type Meta struct {
+		Timeout time.Duration `queryattr:"FindUser.timeout"`
+		Cache   bool          `queryattr:"FindUser.cache"`
+		Retries int           `queryattr:"FindUser.retries"`
+		Owner   string        `queryattr:"FindUser.owner"`
+		Missing string        `queryattr:"FindUser.missing"`
+		Billing struct {
+			Timeout time.Duration `queryattr:"FindInvoice.timeout"`
+		}
+	}
+	attributes := WithAttributes("timeout", "cache", "retries", "owner")
+	meta, err := LoadFromString[Meta](sql, attributes)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if meta.Timeout != 2*time.Second || meta.Cache || meta.Retries != 3 || meta.Owner != "payments" || meta.Missing != "" {
+		t.Errorf("got %+v, want the attributes of FindUser", meta)
+	}
+	if meta.Billing.Timeout != time.Minute {
+		t.Errorf("got %s, want %s", meta.Billing.Timeout, time.Minute)
+	}
+	qs, err := LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte(sql)}}, attributes)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	var fromSet Meta
+	if err := qs.Into(&fromSet); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(fromSet) != fmt.Sprint(*meta) {
+		t.Errorf("got %+v, want %+v", fromSet, *meta)
+	}
+
+	testCases := []struct {
+		v       Struct
+		wantErr error
+	}{
+		{
+			&struct {
+				Timeout time.Duration `queryattr:"FindUser.owner"`
+			}{},
+			fmt.Errorf("%w: field Timeout: invalid value payments of attribute owner of query FindUser", ErrCannotLoadQueries),
+		},
+		{
+			&struct {
+				Timeout time.Duration `queryattr:"FindUser"`
+			}{},
+			fmt.Errorf("%w: field Timeout: invalid queryattr tag FindUser", ErrCannotLoadQueries),
+		},
+		{
+			&struct {
+				Timeout time.Duration `queryattr:"FindCat.timeout"`
+			}{},
+			fmt.Errorf("%w: could not find query FindCat", ErrCannotLoadQueries),
+		},
+		{
+			&struct {
+				Timeout float64 `queryattr:"FindUser.timeout"`
+			}{},
+			fmt.Errorf("%w: field Timeout has the unsupported type float64", ErrCannotLoadQueries),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			err := qs.Into(tc.v)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Errorf("got %v, want %s", err, tc.wantErr)
+			}
+		})
+	}
+}
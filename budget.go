@@ -0,0 +1,96 @@
+package sqload
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Budget is the complexity a query is allowed to have, see ComplexityBudget. The zero
+// value of a field means no limit.
+type Budget struct {
+	// MaxJoins is the maximum number of JOIN clauses.
+	MaxJoins int
+	// MaxSubqueries is the maximum number of subqueries, the SELECT statements written
+	// between parentheses.
+	MaxSubqueries int
+	// MaxLength is the maximum length of the SQL code, in bytes.
+	MaxLength int
+}
+
+// ComplexityBudget returns a rule, named complexity-budget, that forbids the queries
+// more complex than the budget b, so a runaway query can not slip into a program
+// unnoticed. It is enforced at load time with WithPolicy, or by the lint command.
+//
+//	policy := sqload.Policy{sqload.ComplexityBudget(sqload.Budget{MaxJoins: 4, MaxSubqueries: 2})}
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithPolicy(policy))
+//
+// A query can override the limits of the budget in its budget annotation, where the
+// limits are written as max-joins, max-subqueries and max-length:
+//
+//	-- query: MonthlyRevenueReport
+//	-- budget: max-joins=8, max-length=4000
+//	SELECT ...
+func ComplexityBudget(b Budget) Rule {
+	return Rule{
+		Name: "complexity-budget",
+		Check: func(q Query, d Dialect) []string {
+			budget, err := b.override(q.Annotations.List("budget"))
+			if err != nil {
+				return []string{err.Error()}
+			}
+			messages := []string{}
+			joins, subqueries := countComplexity(q.SQL, d)
+			if budget.MaxJoins > 0 && joins > budget.MaxJoins {
+				messages = append(messages, fmt.Sprintf("the query has %d joins, more than the budget of %d", joins, budget.MaxJoins))
+			}
+			if budget.MaxSubqueries > 0 && subqueries > budget.MaxSubqueries {
+				messages = append(messages, fmt.Sprintf("the query has %d subqueries, more than the budget of %d", subqueries, budget.MaxSubqueries))
+			}
+			if budget.MaxLength > 0 && len(q.SQL) > budget.MaxLength {
+				messages = append(messages, fmt.Sprintf("the query has %d bytes, more than the budget of %d", len(q.SQL), budget.MaxLength))
+			}
+			return messages
+		},
+	}
+}
+
+// override returns the budget b with the limits written in the values of a budget
+// annotation, like max-joins=8, replaced.
+func (b Budget) override(values []string) (Budget, error) {
+	for _, value := range values {
+		key, limit, _ := strings.Cut(value, "=")
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 0 {
+			return b, fmt.Errorf("invalid budget %s", value)
+		}
+		switch strings.TrimSpace(key) {
+		case "max-joins":
+			b.MaxJoins = n
+		case "max-subqueries":
+			b.MaxSubqueries = n
+		case "max-length":
+			b.MaxLength = n
+		default:
+			return b, fmt.Errorf("invalid budget %s", value)
+		}
+	}
+	return b, nil
+}
+
+// countComplexity returns the number of JOIN clauses and subqueries of the SQL code sql,
+// written in dialect d.
+func countComplexity(sql string, d Dialect) (joins, subqueries int) {
+	tokens := significantTokens(sql, d)
+	for i, tok := range tokens {
+		switch strings.ToUpper(tok.text(sql)) {
+		case "JOIN":
+			joins++
+		case "SELECT", "WITH":
+			if i > 0 && tokens[i-1].text(sql) == "(" {
+				subqueries++
+			}
+		}
+	}
+	return joins, subqueries
+}
@@ -0,0 +1,46 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestComplexityBudget(t *testing.T) {
+	sql := `SELECT u.id, (SELECT count(*) FROM orders o WHERE o.user_id = u.id)
+FROM user u
+JOIN team t ON t.id = u.team_id
+LEFT JOIN org g ON g.id = t.org_id
+WHERE u.id IN (SELECT user_id FROM admin) AND u.name <> '(select join)';`
+	testCases := []struct {
+		budget     Budget
+		annotation string
+		want       []string
+	}{
+		{Budget{}, "", []string{}},
+		{Budget{MaxJoins: 2, MaxSubqueries: 2, MaxLength: 500}, "", []string{}},
+		{
+			Budget{MaxJoins: 1, MaxSubqueries: 1, MaxLength: 100},
+			"",
+			[]string{
+				"the query has 2 joins, more than the budget of 1",
+				"the query has 2 subqueries, more than the budget of 1",
+				"the query has 219 bytes, more than the budget of 100",
+			},
+		},
+		{Budget{MaxJoins: 1, MaxSubqueries: 1}, "max-joins=2, max-subqueries=0", []string{}},
+		{Budget{MaxJoins: 1}, "max-joins=many", []string{"invalid budget max-joins=many"}},
+		{Budget{MaxJoins: 1}, "joins=3", []string{"invalid budget joins=3"}},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			q := Query{Name: "Q", SQL: sql}
+			if tc.annotation != "" {
+				q.Annotations = Annotations{"budget": {tc.annotation}}
+			}
+			got := ComplexityBudget(tc.budget).Check(q, DialectGeneric)
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package sqload
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the kind of statement a query holds.
+type Kind int
+
+const (
+	// KindOther is used for the statements that are not DML, DDL or procedure
+	// definitions, like SET, PRAGMA or GRANT, and for empty queries.
+	KindOther Kind = iota
+	// KindDML is used for the statements that read or modify data: SELECT, INSERT,
+	// UPDATE, DELETE, MERGE, WITH, CALL...
+	KindDML
+	// KindDDL is used for the statements that define the schema: CREATE, ALTER, DROP,
+	// TRUNCATE...
+	KindDDL
+	// KindProcedure is used for the statements that define functions, procedures and
+	// triggers.
+	KindProcedure
+)
+
+var kindNames = map[Kind]string{
+	KindOther:     "other",
+	KindDML:       "dml",
+	KindDDL:       "ddl",
+	KindProcedure: "procedure",
+}
+
+// String returns the lowercase name of the kind: other, dml, ddl or procedure.
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseKind returns the kind named name without regard to case: other, dml, ddl or
+// procedure.
+func ParseKind(name string) (Kind, error) {
+	for kind, kindName := range kindNames {
+		if strings.EqualFold(name, kindName) {
+			return kind, nil
+		}
+	}
+	return KindOther, fmt.Errorf("invalid kind %s", name)
+}
+
+// queryKind returns the kind of a query with the SQL code sql and the annotations
+// annotations: the kind of its kind annotation, if any, or else the kind Classify
+// returns. If the kind annotation is invalid, it returns the kind Classify returns and
+// an error.
+func queryKind(sql string, annotations Annotations, d Dialect) (Kind, error) {
+	if name := annotations.Get("kind"); name != "" {
+		kind, err := ParseKind(name)
+		if err != nil {
+			return Classify(sql, d), err
+		}
+		return kind, nil
+	}
+	return Classify(sql, d), nil
+}
+
+var dmlKeywords = map[string]bool{
+	"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "MERGE": true,
+	"WITH": true, "VALUES": true, "TABLE": true, "REPLACE": true, "UPSERT": true,
+	"CALL": true, "EXEC": true, "EXECUTE": true, "COPY": true,
+}
+
+var ddlKeywords = map[string]bool{
+	"CREATE": true, "ALTER": true, "DROP": true, "TRUNCATE": true, "COMMENT": true,
+	"RENAME": true,
+}
+
+var procedureKeywords = map[string]bool{
+	"FUNCTION": true, "PROCEDURE": true, "PROC": true, "TRIGGER": true, "PACKAGE": true,
+}
+
+// objectKeywords are the schema objects that can follow CREATE and ALTER, after them
+// there are only names and definitions.
+var objectKeywords = map[string]bool{
+	"TABLE": true, "INDEX": true, "VIEW": true, "SCHEMA": true, "SEQUENCE": true,
+	"TYPE": true, "DATABASE": true, "EXTENSION": true, "DOMAIN": true, "ROLE": true,
+	"USER": true,
+}
+
+// Classify inspects the first statement of the SQL code sql, written in dialect d, and
+// returns its kind. Only the leading keywords are looked at, so the result is a hint
+// rather than a guarantee; it is good enough to, for example, run all the DDL queries
+// before the others.
+func Classify(sql string, d Dialect) Kind {
+	words := leadingWords(sql, d, 8)
+	if len(words) == 0 {
+		return KindOther
+	}
+	switch {
+	case dmlKeywords[words[0]]:
+		return KindDML
+	case words[0] == "CREATE" || words[0] == "ALTER":
+		for _, word := range words[1:] {
+			if procedureKeywords[word] {
+				return KindProcedure
+			}
+			if objectKeywords[word] {
+				return KindDDL
+			}
+		}
+		return KindDDL
+	case ddlKeywords[words[0]]:
+		return KindDDL
+	}
+	return KindOther
+}
+
+// leadingWords returns, in upper case, up to n words at the beginning of the first
+// statement of sql.
+func leadingWords(sql string, d Dialect, n int) []string {
+	words := []string{}
+	for i := 0; i < len(sql) && len(words) < n; {
+		tok := nextToken(sql, i, d)
+		i = tok.end
+		switch tok.kind {
+		case tokenWord:
+			words = append(words, strings.ToUpper(tok.text(sql)))
+		case tokenSemicolon:
+			return words
+		}
+	}
+	return words
+}
@@ -0,0 +1,81 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	testCases := []struct {
+		sql     string
+		dialect Dialect
+		want    Kind
+	}{
+		{"SELECT * FROM cat;", DialectGeneric, KindDML},
+		{"  -- Finds the cats\n/* all of them */ select * from cat;", DialectGeneric, KindDML},
+		{"WITH c AS (SELECT 1) SELECT * FROM c;", DialectGeneric, KindDML},
+		{"INSERT INTO cat (name) VALUES ('Puca');", DialectGeneric, KindDML},
+		{"CREATE TABLE cat (id INT, procedure TEXT);", DialectGeneric, KindDDL},
+		{"CREATE UNIQUE INDEX cat_name ON cat (name);", DialectGeneric, KindDDL},
+		{"ALTER TABLE cat ADD COLUMN color TEXT;", DialectGeneric, KindDDL},
+		{"DROP FUNCTION inc;", DialectGeneric, KindDDL},
+		{"TRUNCATE cat;", DialectGeneric, KindDDL},
+		{"CREATE OR REPLACE FUNCTION inc(i int) RETURNS int AS $$ SELECT i + 1; $$ LANGUAGE sql;", DialectPostgres, KindProcedure},
+		{"CREATE DEFINER=`admin`@`%` PROCEDURE add_cat() BEGIN SELECT 1; END", DialectMySQL, KindProcedure},
+		{"CREATE OR ALTER PROC add_cat AS SELECT 1", DialectTSQL, KindProcedure},
+		{"CREATE TRIGGER t AFTER INSERT ON cat BEGIN SELECT 1; END;", DialectSQLite, KindProcedure},
+		{"# setup\nSET NAMES utf8mb4;", DialectMySQL, KindOther},
+		{"PRAGMA foreign_keys = ON;", DialectSQLite, KindOther},
+		{"", DialectGeneric, KindOther},
+		{"-- nothing but comments", DialectGeneric, KindOther},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			kind := Classify(testCase.sql, testCase.dialect)
+			if kind != testCase.want {
+				t.Errorf("got %s, want %s", kind, testCase.want)
+			}
+		})
+	}
+}
+
+func TestKindString(t *testing.T) {
+	testCases := []struct {
+		kind Kind
+		want string
+	}{
+		{KindOther, "other"},
+		{KindDML, "dml"},
+		{KindDDL, "ddl"},
+		{KindProcedure, "procedure"},
+		{Kind(100), "unknown"},
+	}
+	for _, testCase := range testCases {
+		if testCase.kind.String() != testCase.want {
+			t.Errorf("got %s, want %s", testCase.kind, testCase.want)
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	testCases := []struct {
+		name    string
+		want    Kind
+		wantErr error
+	}{
+		{"dml", KindDML, nil},
+		{"DDL", KindDDL, nil},
+		{"Procedure", KindProcedure, nil},
+		{"other", KindOther, nil},
+		{"query", KindOther, errors.New("invalid kind query")},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			kind, err := ParseKind(testCase.name)
+			if kind != testCase.want || fmt.Sprint(err) != fmt.Sprint(testCase.wantErr) {
+				t.Errorf("got %s, %v, want %s, %v", kind, err, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
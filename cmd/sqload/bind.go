@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/midir99/sqload"
+)
+
+// runBind writes a Go file that embeds the .sql files of a directory and binds their
+// queries to a generated struct, so a project is set up with a single go:generate line:
+//
+//	//go:generate sqload bind -dir sql -out queries_gen.go -pkg db
+//
+// With -interfaces, it also writes an interface for each group of queries, so the code
+// using them can depend on the interface and be tested with the generated mock.
+func runBind(args []string, stdout io.Writer) error {
+	fs := newFlagSet("bind", "[-dir dir] [-out file] [-pkg name] [-type name] [-var name] [-interfaces] [-config file]")
+	dir := fs.String("dir", "sql", "embed the .sql files of `dir`, which must be inside the directory of the output file")
+	output := fs.String("out", "", "write the Go code to `file` instead of the standard output; the file is overwritten")
+	pkg := fs.String("pkg", "", "write the Go code in the package `name` instead of the package of the directory of the output file")
+	typeName := fs.String("type", "Queries", "`name` of the generated struct")
+	varName := fs.String("var", "Q", "`name` of the variable holding the queries")
+	interfaces := fs.Bool("interfaces", false, "also write, for each namespace, an interface with a method returning each query, a method of the struct returning it and a mock implementing it")
+	configFile := fs.String("config", ".sqload.yaml", "read the attributes of the queries from `file`, if it exists")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	outDir := "."
+	if *output != "" {
+		outDir = filepath.Dir(*output)
+	}
+	embed, err := embedPattern(outDir, *dir)
+	if err != nil {
+		return err
+	}
+	if *pkg == "" {
+		if *pkg, err = packageName(outDir); err != nil {
+			return err
+		}
+	}
+	qs, err := sqload.LoadQuerySet(os.DirFS(*dir), cfg.options()...)
+	if err != nil {
+		return err
+	}
+	root := &bindStruct{byName: map[string]*bindField{}}
+	for _, q := range qs.InOrder() {
+		if q.AliasOf != "" {
+			continue
+		}
+		if err := root.add(q.Name, q.Name); err != nil {
+			return err
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by sqload bind; DO NOT EDIT.\n\npackage %s\n\n", *pkg)
+	fmt.Fprintf(&b, "import (\n\t\"embed\"\n\n\t\"github.com/midir99/sqload\"\n)\n\n")
+	fmt.Fprintf(&b, "//go:embed %s\nvar sqlFiles embed.FS\n\n", embed)
+	fmt.Fprintf(&b, "// %s holds the queries of the .sql files of %s.\ntype %s ", *typeName, *dir, *typeName)
+	root.write(&b)
+	fmt.Fprintf(&b, "\n\n// %s holds the queries of the .sql files of %s, loaded when the package is initialized.\n", *varName, *dir)
+	fmt.Fprintf(&b, "var %s = sqload.MustLoadFromFS[%s](sqlFiles)\n", *varName, *typeName)
+	if *interfaces {
+		if err := root.writeInterfaces(&b, *typeName); err != nil {
+			return err
+		}
+	}
+	code, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err := stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(*output, code, 0o644)
+}
+
+// embedPattern returns the go:embed pattern of the directory dir, relative to the
+// directory outDir of the Go file embedding it.
+func embedPattern(outDir, dir string) (string, error) {
+	rel, err := filepath.Rel(outDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("directory %s is not inside the directory of the output file, %s", dir, outDir)
+	}
+	if rel == "." {
+		return "*.sql", nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// packageName returns the name of the package of the Go files of the directory dir.
+func packageName(dir string) (string, error) {
+	pkg, err := parsePackage(dir)
+	if err != nil {
+		return "", err
+	}
+	if len(pkg.files) == 0 {
+		return "", fmt.Errorf("could not find the package of %s, use -pkg", dir)
+	}
+	return pkg.files[0].Name.Name, nil
+}
+
+// bindStruct is a struct generated by runBind: a field for each query and a nested
+// struct for each namespace.
+type bindStruct struct {
+	fields []*bindField
+	byName map[string]*bindField // fields by Go name
+}
+
+// bindField is a field of a bindStruct, bound to a query or to a namespace.
+type bindField struct {
+	name      string      // Go name of the field
+	query     string      // name of the query, relative to the namespace of the struct
+	namespace string      // name of the namespace, if the field is a nested struct
+	fields    *bindStruct // fields of the nested struct
+}
+
+// add adds the fields that bind the query name, relative to the namespace of s, to s.
+// The full name of the query is fullName.
+func (s *bindStruct) add(name, fullName string) error {
+	namespace, rest, nested := strings.Cut(name, ".")
+	if !nested {
+		field := goFieldName(name)
+		if _, found := s.byName[field]; found {
+			return fmt.Errorf("query %s clashes with another query or namespace bound to field %s", fullName, field)
+		}
+		f := &bindField{name: field, query: name}
+		s.fields = append(s.fields, f)
+		s.byName[field] = f
+		return nil
+	}
+	field := goFieldName(namespace)
+	f, found := s.byName[field]
+	if found && f.namespace != namespace {
+		return fmt.Errorf("query %s clashes with another query or namespace bound to field %s", fullName, field)
+	}
+	if !found {
+		f = &bindField{name: field, namespace: namespace, fields: &bindStruct{byName: map[string]*bindField{}}}
+		s.fields = append(s.fields, f)
+		s.byName[field] = f
+	}
+	return f.fields.add(rest, fullName)
+}
+
+// write writes the struct type of s to b.
+func (s *bindStruct) write(b *strings.Builder) {
+	b.WriteString("struct {\n")
+	for _, f := range s.fields {
+		if f.fields == nil {
+			fmt.Fprintf(b, "%s string `query:%s`\n", f.name, strconv.Quote(f.query))
+			continue
+		}
+		fmt.Fprintf(b, "%s ", f.name)
+		f.fields.write(b)
+		fmt.Fprintf(b, " `namespace:%s`\n", strconv.Quote(f.namespace))
+	}
+	b.WriteString("}")
+}
+
+// bindGroup is a group of queries of a bindStruct for which runBind writes an interface:
+// the queries outside any namespace or the queries of a namespace.
+type bindGroup struct {
+	name      string       // name of the interface
+	namespace string       // full name of the namespace, empty for the queries outside any
+	path      string       // selector of the struct of the queries from the root struct
+	queries   []*bindField // fields bound to the queries of the group
+}
+
+// groups returns the groups of the queries of s and of its nested structs. The name of
+// s is typeName, its namespace is namespace and the selector of s from the root struct
+// is path.
+func (s *bindStruct) groups(typeName, namespace, path string) []bindGroup {
+	g := bindGroup{name: "Root" + typeName, namespace: namespace, path: path}
+	if namespace != "" {
+		g.name = goFieldName(namespace) + typeName
+	}
+	var groups []bindGroup
+	for _, f := range s.fields {
+		if f.fields == nil {
+			g.queries = append(g.queries, f)
+		}
+	}
+	if len(g.queries) > 0 {
+		groups = append(groups, g)
+	}
+	for _, f := range s.fields {
+		if f.fields != nil {
+			nested := f.namespace
+			if namespace != "" {
+				nested = namespace + "." + f.namespace
+			}
+			groups = append(groups, f.fields.groups(typeName, nested, path+"."+f.name)...)
+		}
+	}
+	return groups
+}
+
+// writeInterfaces writes to b, for each group of queries of the root struct s named
+// typeName, an interface with a method returning each query of the group, a method of
+// the struct returning the implementation of the interface and a mock implementing it.
+func (s *bindStruct) writeInterfaces(b *strings.Builder, typeName string) error {
+	declared := map[string]bool{typeName: true}
+	for _, g := range s.groups(typeName, "", "") {
+		impl := string(unicode.ToLower(rune(g.name[0]))) + g.name[1:]
+		for _, name := range []string{g.name, impl, "Mock" + g.name} {
+			if declared[name] {
+				return fmt.Errorf("interface %s of the queries of namespace %q clashes with another declaration", g.name, g.namespace)
+			}
+			declared[name] = true
+		}
+		if _, found := s.byName[g.name]; found {
+			return fmt.Errorf("interface %s of the queries of namespace %q clashes with the field %s", g.name, g.namespace, g.name)
+		}
+		description := "the queries outside any namespace"
+		if g.namespace != "" {
+			description = "the queries of the namespace " + g.namespace
+		}
+		fmt.Fprintf(b, "\n// %s is implemented by %s.\ntype %s interface {\n", g.name, description, g.name)
+		for _, f := range g.queries {
+			fmt.Fprintf(b, "%s() string\n", f.name)
+		}
+		fmt.Fprintf(b, "}\n\n// %s returns %s of q.\n", g.name, description)
+		fmt.Fprintf(b, "func (q *%s) %s() %s { return %s{q} }\n\n", typeName, g.name, g.name, impl)
+		fmt.Fprintf(b, "type %s struct{ q *%s }\n\n", impl, typeName)
+		for _, f := range g.queries {
+			fmt.Fprintf(b, "func (g %s) %s() string { return g.q%s.%s }\n", impl, f.name, g.path, f.name)
+		}
+		fmt.Fprintf(b, "\n// Mock%s implements %s with the SQL of its fields.\ntype Mock%s struct {\n", g.name, g.name, g.name)
+		for _, f := range g.queries {
+			fmt.Fprintf(b, "%sSQL string\n", f.name)
+		}
+		b.WriteString("}\n\n")
+		for _, f := range g.queries {
+			fmt.Fprintf(b, "func (m Mock%s) %s() string { return m.%sSQL }\n", g.name, f.name, f.name)
+		}
+	}
+	return nil
+}
+
+// goFieldName returns the name of the exported Go field bound to the query or namespace
+// name: name in Pascal case, prefixed with Q if it does not start with a letter.
+func goFieldName(name string) string {
+	field := pascalCase(name)
+	if r := []rune(field); len(r) == 0 || !unicode.IsLetter(r[0]) {
+		field = "Q" + field
+	}
+	return field
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunBind(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"-dir", "testdata/bind", "-pkg", "db"},
+			wantOutput: "// Code generated by sqload bind; DO NOT EDIT.\n" + `
+package db
+
+import (
+	"embed"
+
+	"github.com/midir99/sqload"
+)
+
+//go:embed testdata/bind
+var sqlFiles embed.FS
+
+// Queries holds the queries of the .sql files of testdata/bind.
+type Queries struct {
+	Ping  string ` + "`query:\"Ping\"`" + `
+	Users struct {
+		FindUser   string ` + "`query:\"FindUser\"`" + `
+		DeleteUser string ` + "`query:\"DeleteUser\"`" + `
+	} ` + "`namespace:\"users\"`" + `
+	FindUserByEmail string ` + "`query:\"find_user_by_email\"`" + `
+	Billing         struct {
+		Invoices struct {
+			CreateInvoice string ` + "`query:\"CreateInvoice\"`" + `
+		} ` + "`namespace:\"invoices\"`" + `
+	} ` + "`namespace:\"billing\"`" + `
+}
+
+// Q holds the queries of the .sql files of testdata/bind, loaded when the package is initialized.
+var Q = sqload.MustLoadFromFS[Queries](sqlFiles)
+`,
+		},
+		{
+			args: []string{"-dir", "testdata/bindiface", "-pkg", "db", "-interfaces"},
+			wantOutput: "// Code generated by sqload bind; DO NOT EDIT.\n" + `
+package db
+
+import (
+	"embed"
+
+	"github.com/midir99/sqload"
+)
+
+//go:embed testdata/bindiface
+var sqlFiles embed.FS
+
+// Queries holds the queries of the .sql files of testdata/bindiface.
+type Queries struct {
+	Ping  string ` + "`query:\"Ping\"`" + `
+	Users struct {
+		FindUser string ` + "`query:\"FindUser\"`" + `
+	} ` + "`namespace:\"users\"`" + `
+}
+
+// Q holds the queries of the .sql files of testdata/bindiface, loaded when the package is initialized.
+var Q = sqload.MustLoadFromFS[Queries](sqlFiles)
+
+// RootQueries is implemented by the queries outside any namespace.
+type RootQueries interface {
+	Ping() string
+}
+
+// RootQueries returns the queries outside any namespace of q.
+func (q *Queries) RootQueries() RootQueries { return rootQueries{q} }
+
+type rootQueries struct{ q *Queries }
+
+func (g rootQueries) Ping() string { return g.q.Ping }
+
+// MockRootQueries implements RootQueries with the SQL of its fields.
+type MockRootQueries struct {
+	PingSQL string
+}
+
+func (m MockRootQueries) Ping() string { return m.PingSQL }
+
+// UsersQueries is implemented by the queries of the namespace users.
+type UsersQueries interface {
+	FindUser() string
+}
+
+// UsersQueries returns the queries of the namespace users of q.
+func (q *Queries) UsersQueries() UsersQueries { return usersQueries{q} }
+
+type usersQueries struct{ q *Queries }
+
+func (g usersQueries) FindUser() string { return g.q.Users.FindUser }
+
+// MockUsersQueries implements UsersQueries with the SQL of its fields.
+type MockUsersQueries struct {
+	FindUserSQL string
+}
+
+func (m MockUsersQueries) FindUser() string { return m.FindUserSQL }
+`,
+		},
+		{
+			args:    []string{"-dir", "testdata/bindifaceclash", "-pkg", "db", "-interfaces"},
+			wantErr: fmt.Errorf("interface RootQueries of the queries of namespace \"root\" clashes with another declaration"),
+		},
+		{
+			args:    []string{"-dir", "testdata/bindclash", "-pkg", "db"},
+			wantErr: fmt.Errorf("query users.FindUser clashes with another query or namespace bound to field Users"),
+		},
+		{
+			args:    []string{"-dir", "testdata/bind", "-out", "testdata/queries/queries_gen.go"},
+			wantErr: fmt.Errorf("directory testdata/bind is not inside the directory of the output file, testdata/queries"),
+		},
+		{
+			args:    []string{"-dir", "testdata/i-dont-exist", "-pkg", "db"},
+			wantErr: fmt.Errorf("cannot load queries: stat .: no such file or directory"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runBind(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunBindOutput(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sql"), 0o755); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sql", "q.sql"), []byte("-- query: Ping\nSELECT 1;\n"), 0o644); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "db.go"), []byte("package db\n"), 0o644); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	output := filepath.Join(dir, "queries_gen.go")
+	err := runBind([]string{"-dir", filepath.Join(dir, "sql"), "-out", output, "-type", "Q", "-var", "Queries"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	code, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	for _, want := range []string{"package db\n", "//go:embed sql\n", "type Q struct {\n\tPing string `query:\"Ping\"`\n}", "var Queries = sqload.MustLoadFromFS[Q](sqlFiles)"} {
+		if !strings.Contains(string(code), want) {
+			t.Errorf("got %s, want it to contain %s", code, want)
+		}
+	}
+}
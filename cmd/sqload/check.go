@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/midir99/sqload"
+)
+
+// diagnostic is a problem found by a command, at a position of a Go or .sql file. Kind
+// is the kind of problem, like missing or the rule violated, and Severity is set by the
+// commands whose diagnostics do not all fail, like lint.
+type diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Kind     string `json:"kind"`
+	Severity string `json:"severity,omitempty"`
+	Query    string `json:"query,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (d diagnostic) String() string {
+	message := d.Message
+	if d.Severity != "" {
+		message = d.Severity + ": " + message
+	}
+	if d.File == "" {
+		return message
+	}
+	return fmt.Sprintf("%s:%d: %s", d.File, d.Line, message)
+}
+
+// runCheck compares the queries bound by the structs of some Go packages with the
+// queries defined in a tree of .sql files, and reports the queries that are missing,
+// orphaned or defined more than once, and the struct tags that look misspelled. It also
+// reports the code that concatenates strings to the fields holding the queries, like
+// q.FindUser + " AND name = '" + name + "'", which defeats the parameters of the query.
+func runCheck(args []string, stdout io.Writer) error {
+	fs := newFlagSet("check", "[-sql dir] [-dialect name] [-dir-namespaces] [-prefix prefix] [-config file] [-json] [packages]")
+	sqlDir := fs.String("sql", "sql", "read the .sql files from `dir`")
+	dialect := fs.String("dialect", "", "parse the queries as written in the `dialect`: postgres, mysql, sqlite or tsql")
+	dirNamespaces := fs.Bool("dir-namespaces", false, "name the queries after their directories, see sqload.WithDirNamespaces")
+	prefix := fs.String("prefix", "", "bind the structs to the queries whose names start with `prefix`, see sqload.WithPrefix")
+	configFile := fs.String("config", ".sqload.yaml", "read the attributes of the queries from `file`, if it exists")
+	asJSON := fs.Bool("json", false, "print the diagnostics as a JSON array")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	d, err := parseDialect(*dialect)
+	if err != nil {
+		return err
+	}
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	opts := append(cfg.options(), sqload.WithDialect(d))
+	if *dirNamespaces {
+		opts = append(opts, sqload.WithDirNamespaces())
+	}
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	diags, err := check(patterns, *sqlDir, *prefix, *dirNamespaces, opts...)
+	if err != nil {
+		return err
+	}
+	if err := writeDiagnostics(stdout, diags, *asJSON); err != nil {
+		return err
+	}
+	if len(diags) > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+// check returns the diagnostics of the Go packages matched by patterns against the .sql
+// files in the directory sqlDir, parsed with the options opts, sorted by position. The
+// structs are bound to the queries whose names start with prefix, and the queries are
+// named after their directories if dirNamespaces is true, as opts do for the library.
+func check(patterns []string, sqlDir, prefix string, dirNamespaces bool, opts ...sqload.Option) ([]diagnostic, error) {
+	fsys := os.DirFS(sqlDir)
+	if _, err := sqload.ExtractQueryMapFromFS(fsys, opts...); err != nil {
+		return nil, err
+	}
+	defs, err := scanDefinitions(fsys, dirNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []*goPackage
+	for _, pattern := range patterns {
+		matched, err := scanPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, matched...)
+	}
+	var bound []structQuery
+	var diags []diagnostic
+	for _, pkg := range pkgs {
+		bound = append(bound, pkg.rootQueries(definedNames(defs), prefix)...)
+		for _, typo := range pkg.tagTypos() {
+			diags = append(diags, diagnostic{
+				File:    typo.Pos.Filename,
+				Line:    typo.Pos.Line,
+				Kind:    "tag-typo",
+				Message: fmt.Sprintf("struct tag key %s looks like a misspelling of %s", typo.Key, typo.Want),
+			})
+		}
+	}
+	// The fields can be used by other packages than the ones declaring them
+	for _, pkg := range pkgs {
+		for _, c := range pkg.concatenations(bound) {
+			diags = append(diags, diagnostic{
+				File:    c.Pos.Filename,
+				Line:    c.Pos.Line,
+				Kind:    "concatenation",
+				Query:   c.Query.Name,
+				Message: fmt.Sprintf("query %s bound to field %s is %s with a string that is not constant, pass the values as parameters instead", c.Query.Name, c.Query.Field, c.How),
+			})
+		}
+	}
+	diags = append(diags, compareQueries(bound, defs, sqlDir, prefix)...)
+	sortDiagnostics(diags)
+	return diags, nil
+}
+
+// compareQueries returns the diagnostics of the queries bound by structs, bound, against
+// the definitions of the queries in the .sql files of the directory sqlDir, defs. Only
+// the queries whose names start with prefix can be orphaned.
+func compareQueries(bound []structQuery, defs []definition, sqlDir, prefix string) []diagnostic {
+	var diags []diagnostic
+	defined := map[string]bool{}
+	for _, def := range defs {
+		defined[def.Name] = true
+	}
+	used := map[string]bool{}
+	for _, q := range bound {
+		used[q.Name] = true
+		if defined[q.Name] {
+			continue
+		}
+		message := fmt.Sprintf("query %s bound to field %s is not defined", q.Name, q.Field)
+		if suggestion := closestName(q.Name, defs); suggestion != "" {
+			message += fmt.Sprintf(", did you mean %s?", suggestion)
+		}
+		diags = append(diags, diagnostic{File: q.Pos.Filename, Line: q.Pos.Line, Kind: "missing", Query: q.Name, Message: message})
+	}
+	for _, def := range defs {
+		name, _, _ := strings.Cut(def.Name, ":")
+		if !used[name] && strings.HasPrefix(name, prefix) {
+			diags = append(diags, diagnostic{
+				File:    filepath.Join(sqlDir, def.File),
+				Line:    def.Line,
+				Kind:    "orphaned",
+				Query:   def.Name,
+				Message: fmt.Sprintf("query %s is not bound to any field", def.Name),
+			})
+		}
+	}
+	return append(diags, duplicateDiagnostics(defs, sqlDir)...)
+}
+
+// duplicateDiagnostics returns a diagnostic for every definition in defs, read from the
+// directory sqlDir, of a query that was already defined.
+func duplicateDiagnostics(defs []definition, sqlDir string) []diagnostic {
+	var diags []diagnostic
+	for _, group := range duplicates(defs) {
+		first := group[0]
+		for _, def := range group[1:] {
+			diags = append(diags, diagnostic{
+				File:    filepath.Join(sqlDir, def.File),
+				Line:    def.Line,
+				Kind:    "duplicate",
+				Query:   def.Name,
+				Message: fmt.Sprintf("query %s is already defined at %s:%d", def.Name, filepath.Join(sqlDir, first.File), first.Line),
+			})
+		}
+	}
+	return diags
+}
+
+// sortDiagnostics sorts diags by position.
+func sortDiagnostics(diags []diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+		return diags[i].Line < diags[j].Line
+	})
+}
+
+// closestName returns the name of the query defined in defs that is the closest to name,
+// if it is close enough to be a typo, or an empty string otherwise.
+func closestName(name string, defs []definition) string {
+	closest, best := "", len(name)/4+1
+	for _, def := range defs {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(def.Name)); d < best {
+			closest, best = def.Name, d
+		}
+	}
+	return closest
+}
+
+// writeDiagnostics writes diags to w, one per line or as a JSON array if asJSON is true.
+func writeDiagnostics(w io.Writer, diags []diagnostic, asJSON bool) error {
+	if asJSON {
+		if diags == nil {
+			diags = []diagnostic{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diags)
+	}
+	for _, d := range diags {
+		if _, err := fmt.Fprintln(w, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
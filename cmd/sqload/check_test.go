@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRunCheck(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"-sql", "testdata/check/sql", "./testdata/check/..."},
+			wantOutput: `testdata/check/app/app.go:11: query FindCatz bound to field FindCats is not defined, did you mean FindCats?
+testdata/check/app/app.go:12: struct tag key Query looks like a misspelling of query
+testdata/check/app/handlers.go:6: query Users.FindUser bound to field Users.FindUser is concatenated with a string that is not constant, pass the values as parameters instead
+testdata/check/app/handlers.go:14: query FindCatz bound to field FindCats is formatted with a string that is not constant, pass the values as parameters instead
+testdata/check/app/handlers.go:26: query Ping bound to field Ping is concatenated with a string that is not constant, pass the values as parameters instead
+testdata/check/sql/ping.sql:4: query FindCats is not bound to any field
+testdata/check/sql/ping.sql:7: query Ping is already defined at testdata/check/sql/ping.sql:1
+`,
+			wantErr: errFailed,
+		},
+		{
+			args: []string{"-sql", "testdata/check/sql", "-json", "./testdata/check/app"},
+			wantOutput: `[
+  {
+    "file": "testdata/check/app/app.go",
+    "line": 11,
+    "kind": "missing",
+    "query": "FindCatz",
+    "message": "query FindCatz bound to field FindCats is not defined, did you mean FindCats?"
+  },
+  {
+    "file": "testdata/check/app/app.go",
+    "line": 12,
+    "kind": "tag-typo",
+    "message": "struct tag key Query looks like a misspelling of query"
+  },
+  {
+    "file": "testdata/check/app/handlers.go",
+    "line": 6,
+    "kind": "concatenation",
+    "query": "Users.FindUser",
+    "message": "query Users.FindUser bound to field Users.FindUser is concatenated with a string that is not constant, pass the values as parameters instead"
+  },
+  {
+    "file": "testdata/check/app/handlers.go",
+    "line": 14,
+    "kind": "concatenation",
+    "query": "FindCatz",
+    "message": "query FindCatz bound to field FindCats is formatted with a string that is not constant, pass the values as parameters instead"
+  },
+  {
+    "file": "testdata/check/app/handlers.go",
+    "line": 26,
+    "kind": "concatenation",
+    "query": "Ping",
+    "message": "query Ping bound to field Ping is concatenated with a string that is not constant, pass the values as parameters instead"
+  },
+  {
+    "file": "testdata/check/sql/ping.sql",
+    "line": 4,
+    "kind": "orphaned",
+    "query": "FindCats",
+    "message": "query FindCats is not bound to any field"
+  },
+  {
+    "file": "testdata/check/sql/ping.sql",
+    "line": 7,
+    "kind": "duplicate",
+    "query": "Ping",
+    "message": "query Ping is already defined at testdata/check/sql/ping.sql:1"
+  }
+]
+`,
+			wantErr: errFailed,
+		},
+		{
+			args:       []string{"-sql", "testdata/queries/sql", "-json", "./testdata/queries"},
+			wantOutput: "[]\n",
+		},
+		{
+			args:       []string{"-sql", "testdata/checkns/sql", "-dir-namespaces", "./testdata/checkns/app"},
+			wantOutput: "",
+		},
+		{
+			args:       []string{"-sql", "testdata/checkns/sql", "-dir-namespaces", "-prefix", "users.", "./testdata/checkns/users"},
+			wantOutput: "",
+		},
+		{
+			args: []string{"-sql", "testdata/checkns/sql", "./testdata/checkns/app"},
+			wantOutput: `testdata/checkns/app/app.go:4: query Billing.FindInvoice bound to field Billing.FindInvoice is not defined
+testdata/checkns/app/app.go:9: query Users.FindUser bound to field Users.FindUser is not defined
+testdata/checkns/sql/billing/invoices.sql:1: query FindInvoice is not bound to any field
+testdata/checkns/sql/users/users.sql:1: query FindUser is not bound to any field
+`,
+			wantErr: errFailed,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runCheck(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
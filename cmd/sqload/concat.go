@@ -0,0 +1,134 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/token"
+	"go/types"
+	"path/filepath"
+)
+
+// concatenation is an expression that builds SQL code by concatenating or formatting the
+// query of a field with strings that are not constant.
+type concatenation struct {
+	Query structQuery    // query bound to the field
+	How   string         // concatenated or formatted
+	Pos   token.Position // position of the expression
+}
+
+// formatFuncs are the functions of the fmt package that format their arguments into a
+// string.
+var formatFuncs = map[string]bool{"Sprintf": true, "Sprint": true, "Sprintln": true}
+
+// concatenations returns the expressions of the package that concatenate (with + or +=)
+// or format (with fmt.Sprintf and the like) the fields that hold the queries of bound
+// with strings that are not constant. The fields are resolved with the types of the
+// package, so only the fields declared at the positions of bound match; the expressions
+// whose types can not be resolved are left out.
+func (pkg *goPackage) concatenations(bound []structQuery) []concatenation {
+	_, info := pkg.typeCheck()
+	var found []concatenation
+	add := func(node ast.Node, how string, operands []ast.Expr) {
+		var query *structQuery
+		constant := true
+		for _, operand := range operands {
+			if q := pkg.boundField(operand, bound, info); q != nil {
+				if query == nil {
+					query = q
+				}
+				continue
+			}
+			if lit, ok := operand.(*ast.BasicLit); !ok || lit.Kind != token.STRING {
+				constant = false
+			}
+		}
+		if query != nil && !constant {
+			found = append(found, concatenation{Query: *query, How: how, Pos: pkg.fset.Position(node.Pos())})
+		}
+	}
+	for _, f := range pkg.files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BinaryExpr:
+				if n.Op != token.ADD {
+					return true
+				}
+				// Only the outermost + of a chain is reported
+				add(n, "concatenated", addOperands(n, nil))
+				return false
+			case *ast.AssignStmt:
+				if n.Tok == token.ADD_ASSIGN && len(n.Lhs) == 1 && len(n.Rhs) == 1 {
+					add(n, "concatenated", addOperands(n.Rhs[0], []ast.Expr{n.Lhs[0]}))
+				}
+			case *ast.CallExpr:
+				if sel, ok := n.Fun.(*ast.SelectorExpr); ok && formatFuncs[sel.Sel.Name] {
+					if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == "fmt" {
+						add(n, "formatted", n.Args)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return found
+}
+
+// addOperands appends the operands of the chain of + expr to operands, looking through
+// the parentheses.
+func addOperands(expr ast.Expr, operands []ast.Expr) []ast.Expr {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return addOperands(e.X, operands)
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD {
+			return addOperands(e.Y, addOperands(e.X, operands))
+		}
+	}
+	return append(operands, expr)
+}
+
+// typeCheck returns the types of the package and of its expressions, with its
+// dependencies imported from their source code. The type errors are ignored, so the
+// types that depend on packages that can not be imported are left unresolved. The
+// package is only checked on the first call.
+func (pkg *goPackage) typeCheck() (*types.Package, *types.Info) {
+	if pkg.info == nil {
+		pkg.info = &types.Info{
+			Types:      map[ast.Expr]types.TypeAndValue{},
+			Defs:       map[*ast.Ident]types.Object{},
+			Selections: map[*ast.SelectorExpr]*types.Selection{},
+		}
+		conf := types.Config{
+			Importer: importer.ForCompiler(pkg.fset, "source", nil),
+			Error:    func(err error) {},
+		}
+		pkg.types, _ = conf.Check(pkg.dir, pkg.fset, pkg.files, pkg.info)
+	}
+	return pkg.types, pkg.info
+}
+
+// boundField returns the query of bound held by the field selected by expr, like
+// q.Users.FindUser, or nil if expr does not select a field of bound. The field is found
+// by the position of its declaration, resolved with the types info.
+func (pkg *goPackage) boundField(expr ast.Expr, bound []structQuery, info *types.Info) *structQuery {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	selection, ok := info.Selections[sel]
+	if !ok || selection.Kind() != types.FieldVal {
+		return nil
+	}
+	pos := pkg.fset.Position(selection.Obj().Pos())
+	file, err := filepath.Abs(pos.Filename)
+	if err != nil {
+		return nil
+	}
+	for i := range bound {
+		boundFile, err := filepath.Abs(bound[i].Pos.Filename)
+		if err == nil && boundFile == file && bound[i].Pos.Line == pos.Line && bound[i].Pos.Column == pos.Column {
+			return &bound[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/midir99/sqload"
+)
+
+// fileConfig is the configuration of the commands, read from a .sqload.yaml file:
+//
+//	dialect: postgres
+//	rules:
+//	  deny-select-star: error
+//	  deny-secrets: error
+//	  deny-cross-schema: warning
+//	  require-limit: off
+//	allowed-schemas: [public, billing]
+//	attributes: [timeout, owner]
+//	limit-tag: list
+//	budget:
+//	  max-joins: 4
+//	  max-subqueries: 2
+//	  max-length: 2000
+//	format:
+//	  keywords: upper
+//	  indent: 2
+type fileConfig struct {
+	Dialect        string
+	Rules          map[string]string // severity by rule name, for lint
+	AllowedSchemas []string
+	Attributes     []string // keys of the attributes of the query comments, for every command
+	LimitTag       string
+	Budget         sqload.Budget     // budget of the complexity-budget rule, for lint
+	Format         map[string]string // style settings by name, for fmt
+}
+
+// options returns the options the queries are loaded with according to cfg.
+func (cfg *fileConfig) options() []sqload.Option {
+	return []sqload.Option{sqload.WithAttributes(cfg.Attributes...)}
+}
+
+// parseConfig parses the configuration in data. Only the small subset of YAML used by
+// the configuration is understood: scalars, flow and block lists, and one level of
+// nested mappings.
+func parseConfig(data []byte) (*fileConfig, error) {
+	cfg := &fileConfig{Rules: map[string]string{}, Format: map[string]string{}}
+	entries, err := parseYAML(string(data))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		switch {
+		case e.key == "dialect" && e.value != "":
+			cfg.Dialect = e.value
+		case e.key == "limit-tag" && e.value != "":
+			cfg.LimitTag = e.value
+		case e.key == "allowed-schemas" && e.list != nil:
+			cfg.AllowedSchemas = e.list
+		case e.key == "attributes" && e.list != nil:
+			cfg.Attributes = e.list
+		case e.key == "rules" && e.mapping != nil:
+			for _, rule := range e.mapping {
+				cfg.Rules[rule.key] = rule.value
+			}
+		case e.key == "budget" && e.mapping != nil:
+			for _, limit := range e.mapping {
+				n, err := strconv.Atoi(limit.value)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("line %d: invalid budget %s: %s", limit.line, limit.key, limit.value)
+				}
+				switch limit.key {
+				case "max-joins":
+					cfg.Budget.MaxJoins = n
+				case "max-subqueries":
+					cfg.Budget.MaxSubqueries = n
+				case "max-length":
+					cfg.Budget.MaxLength = n
+				default:
+					return nil, fmt.Errorf("line %d: invalid budget %s: %s", limit.line, limit.key, limit.value)
+				}
+			}
+		case e.key == "format" && e.mapping != nil:
+			for _, setting := range e.mapping {
+				cfg.Format[setting.key] = setting.value
+			}
+		default:
+			return nil, fmt.Errorf("line %d: invalid entry %s", e.line, e.key)
+		}
+	}
+	return cfg, nil
+}
+
+// readConfig reads the configuration from the file filename. If the file does not
+// exist, an empty configuration is returned.
+func readConfig(filename string) (*fileConfig, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return &fileConfig{Rules: map[string]string{}, Format: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%w", filename, err)
+	}
+	return cfg, nil
+}
+
+// yamlEntry is a top-level entry of a YAML document: a scalar, a list or a mapping of
+// scalars.
+type yamlEntry struct {
+	line    int
+	key     string
+	value   string
+	list    []string
+	mapping []yamlEntry
+}
+
+// parseYAML parses the top-level entries of the YAML document doc.
+func parseYAML(doc string) ([]yamlEntry, error) {
+	var entries []yamlEntry
+	for i, raw := range strings.Split(doc, "\n") {
+		line := i + 1
+		text := stripYAMLComment(strings.TrimRight(raw, " \t\r"))
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		indented := text[0] == ' ' || text[0] == '\t'
+		text = strings.TrimSpace(text)
+		if !indented {
+			key, value, found := strings.Cut(text, ":")
+			if !found {
+				return nil, fmt.Errorf("line %d: missing colon", line)
+			}
+			e := yamlEntry{line: line, key: strings.TrimSpace(key)}
+			value = strings.TrimSpace(value)
+			if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+				e.list = []string{}
+				for _, item := range strings.Split(value[1:len(value)-1], ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						e.list = append(e.list, unquoteYAML(item))
+					}
+				}
+			} else {
+				e.value = unquoteYAML(value)
+			}
+			entries = append(entries, e)
+			continue
+		}
+		if len(entries) == 0 || entries[len(entries)-1].value != "" {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line)
+		}
+		parent := &entries[len(entries)-1]
+		if item := strings.TrimPrefix(text, "- "); item != text || text == "-" {
+			if parent.mapping != nil {
+				return nil, fmt.Errorf("line %d: unexpected list item", line)
+			}
+			parent.list = append(parent.list, unquoteYAML(strings.TrimSpace(strings.TrimPrefix(item, "-"))))
+			continue
+		}
+		key, value, found := strings.Cut(text, ":")
+		if !found || parent.list != nil {
+			return nil, fmt.Errorf("line %d: invalid entry %s", line, text)
+		}
+		parent.mapping = append(parent.mapping, yamlEntry{line: line, key: strings.TrimSpace(key), value: unquoteYAML(strings.TrimSpace(value))})
+	}
+	return entries, nil
+}
+
+// stripYAMLComment removes the # comment at the end of line, if any. A # only starts a
+// comment at the beginning of the line or after a space, outside the quoted values, which
+// start after a space, a [ or a comma.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '\'' || c == '"') && (i == 0 || strings.IndexByte(" \t[,", line[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquoteYAML removes the quotes around the scalar s, if any.
+func unquoteYAML(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/midir99/sqload"
+)
+
+// exporters write a set of queries in the conventions of another tool, by format name.
+var exporters = map[string]func(w io.Writer, queries []sqload.Query, d sqload.Dialect) error{
+	"yesql":  exportKebab,
+	"dotsql": exportKebab,
+	"sqlc":   exportSqlc,
+	"json":   exportJSON,
+	"ddl":    exportDDL,
+}
+
+// runExport loads the .sql files of a directory and writes their queries in the format
+// of another tool, so other projects can consume the same queries.
+func runExport(args []string, stdout io.Writer) error {
+	fs := newFlagSet("export", "[-format yesql|dotsql|sqlc|json|ddl] [-dialect name] [-config file] [dir]")
+	format := fs.String("format", "json", "write the queries in `format`: yesql, dotsql, sqlc, json or ddl")
+	dialect := fs.String("dialect", "", "parse the queries as written in the `dialect`: postgres, mysql, sqlite or tsql")
+	configFile := fs.String("config", ".sqload.yaml", "read the attributes of the queries from `file`, if it exists")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	export, found := exporters[*format]
+	if !found {
+		return fmt.Errorf("unknown format %s", *format)
+	}
+	d, err := parseDialect(*dialect)
+	if err != nil {
+		return err
+	}
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	qs, err := sqload.LoadQuerySet(os.DirFS(dirArg(fs.Args())), append(cfg.options(), sqload.WithDialect(d))...)
+	if err != nil {
+		return err
+	}
+	return export(stdout, qs.InOrder(), d)
+}
+
+// exportKebab writes queries as yesql and dotsql expect them: named in kebab case, with
+// their doc comments and their named parameters as they are.
+func exportKebab(w io.Writer, queries []sqload.Query, d sqload.Dialect) error {
+	return exportComments(w, queries, kebabCase, nil)
+}
+
+// exportSqlc writes queries as sqlc expects them: named in Pascal case, followed by the
+// kind of result, with their named parameters rewritten into the positional
+// placeholders of the dialect d.
+func exportSqlc(w io.Writer, queries []sqload.Query, d sqload.Dialect) error {
+	return exportComments(w, queries, func(q sqload.Query) string {
+		return pascalCase(q.Name) + " " + sqlcCommand(q)
+	}, func(q sqload.Query) (string, error) {
+		args := make(map[string]any, len(q.Params))
+		for _, p := range q.Params {
+			args[p] = nil
+		}
+		sql, _, err := sqload.BindParams(q.SQL, d, args)
+		return sql, err
+	})
+}
+
+// exportComments writes each query of queries to w after a -- name: comment with the
+// name returned by name and its doc comment. The SQL code is the one returned by sql,
+// or the SQL code of the query if sql is nil.
+func exportComments(w io.Writer, queries []sqload.Query, name func(sqload.Query) string, sql func(sqload.Query) (string, error)) error {
+	for i, q := range queries {
+		code := q.SQL
+		if sql != nil {
+			var err error
+			if code, err = sql(q); err != nil {
+				return fmt.Errorf("query %s: %w", q.Name, err)
+			}
+		}
+		var b strings.Builder
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "-- name: %s\n", name(q))
+		if q.Doc != "" {
+			for _, line := range strings.Split(q.Doc, "\n") {
+				fmt.Fprintf(&b, "-- %s\n", line)
+			}
+		}
+		fmt.Fprintf(&b, "%s\n", strings.TrimSpace(code))
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportJSON writes queries as a JSON array of objects with their names, SQL code, doc
+// comments, tags and parameters.
+func exportJSON(w io.Writer, queries []sqload.Query, d sqload.Dialect) error {
+	type jsonQuery struct {
+		Name   string   `json:"name"`
+		SQL    string   `json:"sql"`
+		Doc    string   `json:"doc,omitempty"`
+		Kind   string   `json:"kind"`
+		Tags   []string `json:"tags,omitempty"`
+		Params []string `json:"params,omitempty"`
+	}
+	out := make([]jsonQuery, 0, len(queries))
+	for _, q := range queries {
+		out = append(out, jsonQuery{Name: q.Name, SQL: q.SQL, Doc: q.Doc, Kind: q.Kind.String(), Tags: q.Tags, Params: q.Params})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// exportDDL writes the statements of queries that define the schema, the DDL and the
+// procedure ones, as a plain .sql file in the order they were defined, each one after a
+// comment with its name and its doc comment, so the schema can be diffed and applied by
+// declarative tools like Atlas:
+//
+//	sqload export -format ddl sql > schema.sql
+//	atlas schema apply --url "postgres://..." --to file://schema.sql --dev-url "docker://postgres"
+func exportDDL(w io.Writer, queries []sqload.Query, d sqload.Dialect) error {
+	first := true
+	for _, q := range queries {
+		if q.AliasOf != "" || q.Kind != sqload.KindDDL && q.Kind != sqload.KindProcedure {
+			continue
+		}
+		var b strings.Builder
+		if !first {
+			b.WriteString("\n")
+		}
+		first = false
+		fmt.Fprintf(&b, "-- %s\n", q.Name)
+		if q.Doc != "" {
+			for _, line := range strings.Split(q.Doc, "\n") {
+				fmt.Fprintf(&b, "-- %s\n", line)
+			}
+		}
+		sql := strings.TrimSpace(q.SQL)
+		if !strings.HasSuffix(sql, ";") {
+			sql += ";"
+		}
+		fmt.Fprintf(&b, "%s\n", sql)
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kebabCase returns the name of q in kebab case, so FindUserById becomes
+// find-user-by-id and users.FindUser becomes users-find-user.
+func kebabCase(q sqload.Query) string {
+	var b strings.Builder
+	runes := []rune(q.Name)
+	for i, r := range runes {
+		switch {
+		case r == '.' || r == '_':
+			b.WriteRune('-')
+			continue
+		case unicode.IsUpper(r) && i > 0 && runes[i-1] != '.' && runes[i-1] != '_':
+			previousLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || nextLower && unicode.IsUpper(runes[i-1]) {
+				b.WriteRune('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// pascalCase returns name in Pascal case, so users.FindUser becomes UsersFindUser.
+func pascalCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '.' || r == '_' }) {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// sqlcCommand returns the sqlc command of q: :many for the queries that return rows and
+// :exec for the others.
+func sqlcCommand(q sqload.Query) string {
+	fields := strings.Fields(strings.ToUpper(q.SQL))
+	if len(fields) > 0 && (fields[0] == "SELECT" || fields[0] == "WITH" || fields[0] == "VALUES") {
+		return ":many"
+	}
+	for _, field := range fields {
+		if field == "RETURNING" {
+			return ":many"
+		}
+	}
+	return ":exec"
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRunExport(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"-format", "yesql", "testdata/export"},
+			wantOutput: `-- name: find-user-by-id
+-- Returns the user with the id.
+SELECT * FROM user WHERE id = :id AND org = :org;
+
+-- name: billing-create-invoice
+INSERT INTO invoice (user_id) VALUES (:user_id) RETURNING id;
+
+-- name: delete-html-cache
+DELETE FROM html_cache;
+`,
+		},
+		{
+			args: []string{"-format", "sqlc", "-dialect", "postgres", "testdata/export"},
+			wantOutput: `-- name: FindUserById :many
+-- Returns the user with the id.
+SELECT * FROM user WHERE id = $1 AND org = $2;
+
+-- name: BillingCreateInvoice :many
+INSERT INTO invoice (user_id) VALUES ($1) RETURNING id;
+
+-- name: DeleteHTMLCache :exec
+DELETE FROM html_cache;
+`,
+		},
+		{
+			args: []string{"-format", "ddl", "testdata/export"},
+		},
+		{
+			args: []string{"-format", "ddl", "-dialect", "postgres", "testdata/schema"},
+			wantOutput: `-- CreateUserTable
+-- Holds the users.
+CREATE TABLE user (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL
+);
+
+-- CreateUserEmailIndex
+CREATE UNIQUE INDEX user_email ON user (email);
+
+-- CreateTouchFunction
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+`,
+		},
+		{
+			args:    []string{"-format", "json", "testdata/i-dont-exist"},
+			wantErr: fmt.Errorf("cannot load queries: stat .: no such file or directory"),
+		},
+		{
+			args:    []string{"-format", "xml", "testdata/export"},
+			wantErr: fmt.Errorf("unknown format xml"),
+		},
+		{
+			args:    []string{"-dialect", "oracle", "testdata/export"},
+			wantErr: fmt.Errorf("unknown dialect oracle"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runExport(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
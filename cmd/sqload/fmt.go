@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/midir99/sqload"
+)
+
+// headerCommentPattern matches the annotations and the doc comments in the header of a
+// query.
+var headerCommentPattern = regexp.MustCompile(`^[ \t]*--[ \t]*(?:([a-zA-Z][a-zA-Z0-9_-]*):[ \t]*)?(.*?)[ \t]*$`)
+
+// runFmt rewrites the .sql files of a tree in a canonical style: query comments and
+// annotations written the same way, one blank line between queries, and the keywords and
+// indentation of the SQL code as configured.
+func runFmt(args []string, stdout io.Writer) error {
+	fs := newFlagSet("fmt", "[-config file] [-keywords upper|lower|keep] [-indent tab|n] [-w | -check] [dir]")
+	configFile := fs.String("config", ".sqload.yaml", "read the style from the format section of `file`, if it exists")
+	keywords := fs.String("keywords", "", "write the keywords in upper or lower case, or keep them as written")
+	indent := fs.String("indent", "", "indent with tabs or `n` spaces")
+	write := fs.Bool("w", false, "rewrite the files instead of printing the result to the standard output")
+	check := fs.Bool("check", false, "list the files that are not formatted and fail if there are any")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	if *keywords != "" {
+		cfg.Format["keywords"] = *keywords
+	}
+	if *indent != "" {
+		cfg.Format["indent"] = *indent
+	}
+	style, err := formatStyle(cfg.Format)
+	if err != nil {
+		return err
+	}
+	d, err := parseDialect(cfg.Dialect)
+	if err != nil {
+		return err
+	}
+	dir := dirArg(fs.Args())
+	files, err := findSQLFiles(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	unformatted := false
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formatted := formatFile(data, d, style)
+		switch {
+		case *check:
+			if !bytes.Equal(data, formatted) {
+				unformatted = true
+				fmt.Fprintln(stdout, path)
+			}
+		case *write:
+			if !bytes.Equal(data, formatted) {
+				if err := os.WriteFile(path, formatted, 0o644); err != nil {
+					return err
+				}
+			}
+		default:
+			if _, err := stdout.Write(formatted); err != nil {
+				return err
+			}
+		}
+	}
+	if unformatted {
+		return errFailed
+	}
+	return nil
+}
+
+// formatStyle returns the style described by the settings of the format section of the
+// configuration.
+func formatStyle(settings map[string]string) (sqload.FormatStyle, error) {
+	var style sqload.FormatStyle
+	for name, value := range settings {
+		switch name {
+		case "keywords":
+			switch value {
+			case "upper":
+				style.Keywords = sqload.KeywordsUpper
+			case "lower":
+				style.Keywords = sqload.KeywordsLower
+			case "keep":
+				style.Keywords = sqload.KeywordsAsWritten
+			default:
+				return style, fmt.Errorf("invalid keywords setting %s", value)
+			}
+		case "indent":
+			n, err := strconv.Atoi(value)
+			switch {
+			case value == "tab":
+				style.Indent = "\t"
+			case err == nil && n > 0:
+				style.Indent = strings.Repeat(" ", n)
+			default:
+				return style, fmt.Errorf("invalid indent setting %s", value)
+			}
+		case "indent-width":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return style, fmt.Errorf("invalid indent-width setting %s", value)
+			}
+			style.IndentWidth = n
+		default:
+			return style, fmt.Errorf("unknown format setting %s", name)
+		}
+	}
+	return style, nil
+}
+
+// formatFile returns the contents data of a .sql file, written in dialect d, formatted
+// following style. The fixture and transaction blocks, found as the library finds them
+// with sqload.BlockComments, are kept as written.
+func formatFile(data []byte, d sqload.Dialect, style sqload.FormatStyle) []byte {
+	text := string(data)
+	crlf := strings.Contains(text, "\r\n")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	blockLines := map[int]bool{}
+	for _, offset := range sqload.BlockComments(text, d) {
+		blockLines[strings.Count(text[:offset], "\n")] = true
+	}
+	var chunks []string
+	var body []string
+	verbatim := false
+	flush := func() {
+		if verbatim {
+			if code := strings.TrimRight(strings.Join(body, "\n"), " \t\n"); code != "" {
+				chunks[len(chunks)-1] += code + "\n"
+			}
+			body, verbatim = nil, false
+			return
+		}
+		for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+			body = body[1:]
+		}
+		if code := strings.TrimRight(strings.Join(body, "\n"), " \t\n"); code != "" {
+			chunks[len(chunks)-1] += sqload.Format(code, d, style) + "\n"
+		}
+		body = nil
+	}
+	inHeader := false
+	for i, line := range strings.Split(text, "\n") {
+		rest, isQueryComment := cutQueryComment(line)
+		if isQueryComment || blockLines[i] {
+			if len(chunks) == 0 && len(body) > 0 {
+				chunks = append(chunks, "")
+			}
+			if len(chunks) > 0 {
+				flush()
+			}
+			if blockLines[i] {
+				chunks = append(chunks, "")
+				body, verbatim, inHeader = []string{line}, true, false
+				continue
+			}
+			chunks = append(chunks, "-- query: "+strings.Join(strings.Fields(rest), " ")+"\n")
+			inHeader = true
+			continue
+		}
+		if inHeader {
+			if m := headerCommentPattern.FindStringSubmatch(line); m != nil {
+				switch {
+				case m[1] != "":
+					chunks[len(chunks)-1] += "-- " + m[1] + ": " + m[2] + "\n"
+				case m[2] != "":
+					chunks[len(chunks)-1] += "-- " + m[2] + "\n"
+				default:
+					chunks[len(chunks)-1] += "--\n"
+				}
+				continue
+			}
+			inHeader = false
+		}
+		body = append(body, line)
+	}
+	if len(chunks) == 0 {
+		chunks = append(chunks, "")
+	}
+	flush()
+	formatted := strings.Join(nonEmpty(chunks), "\n")
+	if crlf {
+		formatted = strings.ReplaceAll(formatted, "\n", "\r\n")
+	}
+	return []byte(formatted)
+}
+
+// nonEmpty returns the strings of list that are not empty.
+func nonEmpty(list []string) []string {
+	var result []string
+	for _, s := range list {
+		if s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
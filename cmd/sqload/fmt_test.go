@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/midir99/sqload"
+)
+
+func TestRunFmt(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"-config", "testdata/lint/.sqload.yaml", "-keywords", "upper", "-indent", "2", "testdata/fmt"},
+			wantOutput: `-- Queries of the users.
+
+-- query: FindUser
+-- tags: a, b
+-- Finds a user.
+--
+SELECT *
+  FROM user
+  WHERE id = :id;
+
+-- query: DeleteUser variant=soft
+UPDATE user SET deleted = TRUE;
+`,
+		},
+		{
+			args:       []string{"-check", "testdata/fmt"},
+			wantOutput: "testdata/fmt/users.sql\n",
+			wantErr:    errFailed,
+		},
+		{
+			args:    []string{"-indent", "none", "testdata/fmt"},
+			wantErr: fmt.Errorf("invalid indent setting none"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runFmt(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunFmtWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.sql")
+	if err := os.WriteFile(path, []byte("-- query:  Ping\r\nselect 1;   \r\n\r\n\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	if err := runFmt([]string{"-keywords", "upper", "-w", dir}, &stdout); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "-- query: Ping\r\nSELECT 1;\r\n" {
+		t.Errorf("got %q, want %q", data, "-- query: Ping\r\nSELECT 1;\r\n")
+	}
+	if err := runFmt([]string{"-keywords", "upper", "-check", dir}, &stdout); err != nil {
+		t.Errorf("err must be nil, got %s", err)
+	}
+}
+
+func TestFormatFileBlocks(t *testing.T) {
+	data := "-- query: Label\nselect '-- fixture: a', 2;\n\n-- fixture: SeedCats\ninsert into cat values (1);\n\n  -- tx: Move\nupdate cat set id = 2;\n-- query: Ping\nselect 1;\n"
+	want := "-- query: Label\nSELECT '-- fixture: a', 2;\n\n-- fixture: SeedCats\ninsert into cat values (1);\n\n  -- tx: Move\nupdate cat set id = 2;\n\n-- query: Ping\nSELECT 1;\n"
+	got := string(formatFile([]byte(data), sqload.DialectGeneric, sqload.FormatStyle{Keywords: sqload.KeywordsUpper}))
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
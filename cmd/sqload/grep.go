@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// runGrep searches the names and the SQL code of the queries of a tree of .sql files
+// for a regular expression, and prints the matches along with the query they belong to.
+func runGrep(args []string, stdout io.Writer) error {
+	fs := newFlagSet("grep", "[-i] [-names | -sql] pattern [dir]")
+	ignoreCase := fs.Bool("i", false, "match without regard to case")
+	namesOnly := fs.Bool("names", false, "search only the names of the queries")
+	sqlOnly := fs.Bool("sql", false, "search only the SQL code of the queries")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 || *namesOnly && *sqlOnly {
+		fs.Usage()
+		return errFailed
+	}
+	expr := fs.Arg(0)
+	if *ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+	dir := dirArg(fs.Args()[1:])
+	found, err := grep(stdout, os.DirFS(dir), dir, re, !*sqlOnly, !*namesOnly)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errFailed
+	}
+	return nil
+}
+
+// grep writes to w a line for each match of re in the .sql files of the fsys file
+// system, read from the directory dir, and returns whether there was any. The names of
+// the queries are searched if names is true, and their SQL code if code is true.
+func grep(w io.Writer, fsys fs.FS, dir string, re *regexp.Regexp, names, code bool) (bool, error) {
+	files, err := findSQLFiles(fsys)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return false, err
+		}
+		query := ""
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, len(data)+1)
+		for line := 1; scanner.Scan(); line++ {
+			text := scanner.Text()
+			if rest, isQueryComment := cutQueryComment(text); isQueryComment {
+				queryNames, _ := splitQueryComment(rest)
+				if len(queryNames) == 0 {
+					continue
+				}
+				query = strings.Join(queryNames, ", ")
+				if !names || !re.MatchString(query) {
+					continue
+				}
+			} else if query == "" || !code || !re.MatchString(text) {
+				continue
+			}
+			found = true
+			if _, err := fmt.Fprintf(w, "%s:%d: %s: %s\n", filepath.Join(dir, file), line, query, snippet(text)); err != nil {
+				return false, err
+			}
+		}
+	}
+	return found, nil
+}
+
+// snippet returns line without its surrounding spaces, shortened to 80 characters.
+func snippet(line string) string {
+	line = strings.TrimSpace(line)
+	if runes := []rune(line); len(runes) > 80 {
+		return string(runes[:77]) + "..."
+	}
+	return line
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRunGrep(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"user", "testdata/export"},
+			wantOutput: `testdata/export/users.sql:2: FindUserById: -- Returns the user with the id.
+testdata/export/users.sql:3: FindUserById: SELECT * FROM user WHERE id = :id AND org = :org;
+testdata/export/users.sql:7: billing.CreateInvoice: INSERT INTO invoice (user_id) VALUES (:user_id) RETURNING id;
+`,
+		},
+		{
+			args: []string{"-i", "-names", "user", "testdata/export"},
+			wantOutput: `testdata/export/users.sql:1: FindUserById: -- query: FindUserById
+`,
+		},
+		{
+			args:    []string{"-sql", "FindUser", "testdata/export"},
+			wantErr: errFailed,
+		},
+		{
+			args:    []string{"(", "testdata/export"},
+			wantErr: fmt.Errorf("error parsing regexp: missing closing ): `(`"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runGrep(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// nameCommentPattern matches the -- name: comments of the formats that can be imported,
+// with the sqlc command, like :one, if any.
+var nameCommentPattern = regexp.MustCompile(`^([ \t]*)--[ \t]*name:[ \t]*(\S+)(?:[ \t]+:([a-zA-Z]+))?[ \t\r]*$`)
+
+// sqlcArgPattern matches the sqlc.arg and sqlc.narg calls of sqlc queries.
+var sqlcArgPattern = regexp.MustCompile(`sqlc\.n?arg\([ \t]*'?([a-zA-Z_][a-zA-Z0-9_]*)'?[ \t]*\)`)
+
+// importedNamePattern matches the names that are valid query names once imported.
+var importedNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+(\.[a-zA-Z0-9_]+)*$`)
+
+// importFormats are the formats that can be imported. The value tells whether the names
+// are written in kebab case.
+var importFormats = map[string]bool{
+	"sqlc":    false,
+	"goyesql": true,
+	"yesql":   true,
+	"dotsql":  true,
+}
+
+// runImport rewrites the .sql files written for another tool into the format of the
+// sqload package, keeping their doc comments and annotations.
+func runImport(args []string, stdout io.Writer) error {
+	fs := newFlagSet("import", "-from sqlc|goyesql|yesql|dotsql [-w] [dir]")
+	from := fs.String("from", "", "read the files in `format`: sqlc, goyesql, yesql or dotsql")
+	write := fs.Bool("w", false, "rewrite the files instead of printing the result to the standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	kebab, found := importFormats[*from]
+	if !found {
+		return fmt.Errorf("unknown format %s", *from)
+	}
+	dir := dirArg(fs.Args())
+	files, err := findSQLFiles(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		converted, err := importSQL(data, *from == "sqlc", kebab)
+		if err != nil {
+			return fmt.Errorf("%s:%w", path, err)
+		}
+		if *write {
+			if err := os.WriteFile(path, converted, 0o644); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(stdout, "-- %s\n%s", path, converted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importSQL converts the -- name: comments of data into query comments. If sqlc is
+// true, the sqlc commands are kept as sqlc-command annotations and the sqlc.arg calls
+// are rewritten into named parameters. If kebab is true, the names are converted from
+// kebab case to Pascal case.
+func importSQL(data []byte, sqlc, kebab bool) ([]byte, error) {
+	var b bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		m := nameCommentPattern.FindStringSubmatch(text)
+		if m == nil {
+			if sqlc {
+				text = sqlcArgPattern.ReplaceAllString(text, ":$1")
+			}
+			b.WriteString(text + "\n")
+			continue
+		}
+		name := m[2]
+		if kebab {
+			name = fromKebabCase(name)
+		}
+		if !importedNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("%d: %s cannot be converted into a valid query name", line, m[2])
+		}
+		fmt.Fprintf(&b, "%s-- query: %s\n", m[1], name)
+		if m[3] != "" {
+			fmt.Fprintf(&b, "%s-- sqlc-command: %s\n", m[1], m[3])
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// fromKebabCase converts name from kebab case to Pascal case, so find-user-by-id
+// becomes FindUserById.
+func fromKebabCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "-") {
+		runes := []rune(part)
+		if len(runes) == 0 {
+			continue
+		}
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
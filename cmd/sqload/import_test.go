@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunImport(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"-from", "sqlc", "testdata/import/sqlc"},
+			wantOutput: `-- testdata/import/sqlc/users.sql
+-- query: GetAuthor
+-- sqlc-command: one
+-- Returns the author with the id.
+SELECT * FROM authors
+WHERE id = :id LIMIT 1;
+
+-- query: DeleteAuthor
+-- sqlc-command: exec
+DELETE FROM authors WHERE id = $1;
+`,
+		},
+		{
+			args: []string{"-from", "goyesql", "testdata/import/goyesql"},
+			wantOutput: `-- testdata/import/goyesql/users.sql
+-- query: FindUserById
+-- tags: users
+SELECT * FROM user WHERE id = :id;
+`,
+		},
+		{
+			args:    []string{"-from", "yesql", "testdata/import/bad"},
+			wantErr: fmt.Errorf("testdata/import/bad/users.sql:4: find/users cannot be converted into a valid query name"),
+		},
+		{
+			args:    []string{"-from", "hibernate", "testdata/import/sqlc"},
+			wantErr: fmt.Errorf("unknown format hibernate"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runImport(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunImportWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "users.sql")
+	if err := os.WriteFile(path, []byte("-- name: find-user\nSELECT 1;\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	if err := runImport([]string{"-from", "dotsql", "-w", dir}, &stdout); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "-- query: FindUser\nSELECT 1;\n" {
+		t.Errorf("got %s, want %s", data, "-- query: FindUser\nSELECT 1;\n")
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("got %s, want no output", stdout.String())
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/midir99/sqload"
+)
+
+// The severities of the lint rules.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityOff     = "off"
+)
+
+// lintRules build the rules of the lint command, by name, from its configuration.
+var lintRules = map[string]func(cfg *fileConfig) sqload.Rule{
+	"deny-select-star":  func(cfg *fileConfig) sqload.Rule { return sqload.DenySelectStar() },
+	"deny-secrets":      func(cfg *fileConfig) sqload.Rule { return sqload.DenySecrets() },
+	"complexity-budget": func(cfg *fileConfig) sqload.Rule { return sqload.ComplexityBudget(cfg.Budget) },
+	"deny-cross-schema": func(cfg *fileConfig) sqload.Rule { return sqload.DenyCrossSchema(cfg.AllowedSchemas...) },
+	"require-limit": func(cfg *fileConfig) sqload.Rule {
+		if cfg.LimitTag == "" {
+			return sqload.RequireLimit("list")
+		}
+		return sqload.RequireLimit(cfg.LimitTag)
+	},
+}
+
+// lineRules are the rules whose violations are reported at the line of the SQL code that
+// violates them, see violationLine, instead of the line of the query comment.
+var lineRules = map[string]bool{"deny-secrets": true}
+
+// defaultSeverities are the severities of the rules not listed in the configuration.
+var defaultSeverities = map[string]string{
+	"deny-select-star":  severityError,
+	"deny-secrets":      severityWarning,
+	"complexity-budget": severityError,
+	"deny-cross-schema": severityOff,
+	"require-limit":     severityOff,
+}
+
+// runLint checks the queries of a tree of .sql files against the rules enabled in a
+// configuration file. A query can suppress a rule with a lint-ignore annotation:
+//
+//	-- query: DumpUsers
+//	-- lint-ignore: deny-select-star
+//	SELECT * FROM user;
+func runLint(args []string, stdout io.Writer) error {
+	fs := newFlagSet("lint", "[-config file] [-json] [dir]")
+	configFile := fs.String("config", ".sqload.yaml", "read the configuration from `file`, if it exists")
+	asJSON := fs.Bool("json", false, "print the diagnostics as a JSON array")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	dir := dirArg(fs.Args())
+	diags, err := lint(os.DirFS(dir), dir, cfg)
+	if err != nil {
+		return err
+	}
+	if err := writeDiagnostics(stdout, diags, *asJSON); err != nil {
+		return err
+	}
+	for _, d := range diags {
+		if d.Severity == severityError {
+			return errFailed
+		}
+	}
+	return nil
+}
+
+// lint returns the violations of the rules enabled by cfg by the queries of the .sql
+// files of the fsys file system, read from the directory dir, sorted by position.
+func lint(fsys fs.FS, dir string, cfg *fileConfig) ([]diagnostic, error) {
+	d, err := parseDialect(cfg.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	severities := map[string]string{}
+	for name, severity := range defaultSeverities {
+		severities[name] = severity
+	}
+	for name, severity := range cfg.Rules {
+		if lintRules[name] == nil {
+			return nil, fmt.Errorf("unknown rule %s", name)
+		}
+		if severity != severityError && severity != severityWarning && severity != severityOff {
+			return nil, fmt.Errorf("invalid severity %s of rule %s", severity, name)
+		}
+		severities[name] = severity
+	}
+	names := make([]string, 0, len(lintRules))
+	for name := range lintRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var policy sqload.Policy
+	for _, name := range names {
+		if severities[name] != severityOff {
+			policy = append(policy, lintRules[name](cfg))
+		}
+	}
+	qs, err := sqload.LoadQuerySet(fsys, append(cfg.options(), sqload.WithDialect(d))...)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := scanDefinitions(fsys, false)
+	if err != nil {
+		return nil, err
+	}
+	defOf := map[string]definition{}
+	for _, def := range defs {
+		defOf[def.Name] = def
+	}
+	var diags []diagnostic
+	for _, v := range policy.Check(qs) {
+		q, _ := qs.SelectQuery(v.Query, sqload.Flags{v.Query: v.Variant})
+		if containsString(q.Annotations.List("lint-ignore"), v.Rule) {
+			continue
+		}
+		key := v.Query
+		if v.Variant != "" {
+			key += ":" + v.Variant
+		}
+		def := defOf[key]
+		line := def.Line
+		if lineRules[v.Rule] {
+			line = violationLine(fsys, def, lintRules[v.Rule](cfg), d, v.Message)
+		}
+		diags = append(diags, diagnostic{
+			File:     filepath.Join(dir, def.File),
+			Line:     line,
+			Kind:     v.Rule,
+			Severity: severities[v.Rule],
+			Query:    key,
+			Message:  v.String(),
+		})
+	}
+	sortDiagnostics(diags)
+	return diags, nil
+}
+
+// violationLine returns the line of the file of the query defined at def where the rule
+// first reports message, found by checking the code of the query as written in the file,
+// one more line at a time. If it is not found, it returns the line of the query comment.
+func violationLine(fsys fs.FS, def definition, rule sqload.Rule, d sqload.Dialect, message string) int {
+	data, err := fs.ReadFile(fsys, def.File)
+	if err != nil {
+		return def.Line
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for end := def.Line + 1; end <= len(lines); end++ {
+		if _, isQueryComment := cutQueryComment(lines[end-1]); isQueryComment {
+			break
+		}
+		q := sqload.Query{Name: def.Name, SQL: strings.Join(lines[def.Line:end], "\n")}
+		if containsString(rule.Check(q, d), message) {
+			return end
+		}
+	}
+	return def.Line
+}
+
+// containsString reports whether s is one of the strings in list.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	testCases := []struct {
+		data    string
+		want    string
+		wantErr error
+	}{
+		{
+			data: "dialect: mysql\nrules:\n  deny-select-star: off\nallowed-schemas:\n  - public\n  - 'billing'\n",
+			want: "&{mysql map[deny-select-star:off] [public billing] []  {0 0 0} map[]}",
+		},
+		{
+			data: "budget:\n  max-joins: 3\n  max-length: 900\n",
+			want: "&{ map[] [] []  {3 0 900} map[]}",
+		},
+		{
+			data: "attributes: [timeout, owner]\n",
+			want: "&{ map[] [] [timeout owner]  {0 0 0} map[]}",
+		},
+		{
+			data: "# lint settings\nformat:\n  banner: 'issue # 12' # quoted\n  footer: \"a \\\" # b\"\n  case: upper # comment\n  note: it's # a comment\n",
+			want: `&{ map[] [] []  {0 0 0} map[banner:issue # 12 case:upper footer:a \" # b note:it's]}`,
+		},
+		{
+			data:    "budget:\n  max-joins: lots\n",
+			wantErr: fmt.Errorf("line 2: invalid budget max-joins: lots"),
+		},
+		{
+			data:    "budget:\n  max-unions: 2\n",
+			wantErr: fmt.Errorf("line 2: invalid budget max-unions: 2"),
+		},
+		{
+			data:    "rules: error\n",
+			wantErr: fmt.Errorf("line 1: invalid entry rules"),
+		},
+		{
+			data:    "  deny-select-star: off\n",
+			wantErr: fmt.Errorf("line 1: unexpected indentation"),
+		},
+		{
+			data:    "dialect postgres\n",
+			wantErr: fmt.Errorf("line 1: missing colon"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			cfg, err := parseConfig([]byte(tc.data))
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if err == nil && fmt.Sprint(cfg) != tc.want {
+				t.Errorf("got %v, want %s", cfg, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunLint(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"-config", "testdata/lint/.sqload.yaml", "testdata/lint"},
+			wantOutput: `testdata/lint/reports.sql:1: error: query TeamReport: complexity-budget: the query has 2 joins, more than the budget of 1
+testdata/lint/seed.sql:4: warning: query SeedUsers: deny-secrets: password is set to a string literal, pass it as a parameter instead
+testdata/lint/users.sql:1: warning: query FindUsers: deny-select-star: SELECT * is not allowed, list the columns instead
+testdata/lint/users.sql:1: error: query FindUsers: require-limit: queries tagged paged must have a LIMIT
+testdata/lint/users.sql:5: error: query DumpUsers: deny-cross-schema: references to the schema auth are not allowed
+`,
+			wantErr: errFailed,
+		},
+		{
+			args:       []string{"-config", "testdata/lint/i-dont-exist.yaml", "testdata/lint"},
+			wantOutput: "testdata/lint/seed.sql:4: warning: query SeedUsers: deny-secrets: password is set to a string literal, pass it as a parameter instead\ntestdata/lint/users.sql:1: error: query FindUsers: deny-select-star: SELECT * is not allowed, list the columns instead\n",
+			wantErr:    errFailed,
+		},
+		{
+			args: []string{"-config", "testdata/lint/i-dont-exist.yaml", "-json", "testdata/lint"},
+			wantOutput: `[
+  {
+    "file": "testdata/lint/seed.sql",
+    "line": 4,
+    "kind": "deny-secrets",
+    "severity": "warning",
+    "query": "SeedUsers",
+    "message": "query SeedUsers: deny-secrets: password is set to a string literal, pass it as a parameter instead"
+  },
+  {
+    "file": "testdata/lint/users.sql",
+    "line": 1,
+    "kind": "deny-select-star",
+    "severity": "error",
+    "query": "FindUsers",
+    "message": "query FindUsers: deny-select-star: SELECT * is not allowed, list the columns instead"
+  }
+]
+`,
+			wantErr: errFailed,
+		},
+		{
+			args:    []string{"-config", "testdata/lint/bad.yaml", "testdata/lint"},
+			wantErr: fmt.Errorf("unknown rule deny-everything"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runLint(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
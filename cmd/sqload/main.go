@@ -0,0 +1,138 @@
+// Command sqload helps to work with the SQL files loaded by the sqload package.
+//
+// Usage:
+//
+//	sqload <command> [arguments]
+//
+// The commands are:
+//
+//	bind       write a Go file that embeds the .sql files and binds their queries
+//	check      report the queries missing from or orphaned in the .sql files
+//	export     write the queries in the format of another tool
+//	fmt        rewrite the .sql files in a canonical style
+//	grep       search the names and SQL code of the queries
+//	import     rewrite the .sql files of another tool as sqload files
+//	lint       check the queries against a configurable set of rules
+//	scaffold   print a skeleton .sql file for the queries of a struct
+//	show       print the SQL code of a query as a program would receive it
+//	stats      print figures about the queries of the .sql files
+//	watch      check the .sql files every time they change
+//
+// Run sqload <command> -h to see the arguments of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/midir99/sqload"
+)
+
+// command is a subcommand of the sqload command. It runs with the arguments that follow
+// its name and writes its output to stdout.
+type command struct {
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"bind":     {"write a Go file that embeds the .sql files and binds their queries", runBind},
+	"check":    {"report the queries missing from or orphaned in the .sql files", runCheck},
+	"export":   {"write the queries in the format of another tool", runExport},
+	"fmt":      {"rewrite the .sql files in a canonical style", runFmt},
+	"grep":     {"search the names and SQL code of the queries", runGrep},
+	"import":   {"rewrite the .sql files of another tool as sqload files", runImport},
+	"lint":     {"check the queries against a configurable set of rules", runLint},
+	"scaffold": {"print a skeleton .sql file for the queries of a struct", runScaffold},
+	"show":     {"print the SQL code of a query as a program would receive it", runShow},
+	"stats":    {"print figures about the queries of the .sql files", runStats},
+	"watch":    {"check the .sql files every time they change", runWatch},
+}
+
+// errFailed is returned by the commands that already reported why they failed, so main
+// only has to set the exit status.
+var errFailed = errors.New("failed")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command named by args[0] and returns the exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		return 2
+	}
+	cmd, found := commands[args[0]]
+	if !found {
+		fmt.Fprintf(stderr, "sqload: unknown command %s\n", args[0])
+		usage(stderr)
+		return 2
+	}
+	err := cmd.run(args[1:], stdout)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 2
+	case errors.Is(err, errFailed):
+		return 1
+	}
+	fmt.Fprintf(stderr, "sqload %s: %s\n", args[0], err)
+	return 1
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: sqload <command> [arguments]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+}
+
+// newFlagSet returns a flag set for the command name whose usage line is usage.
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: sqload %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// dialects are the dialects accepted by the -dialect flags, by name.
+var dialects = map[string]sqload.Dialect{
+	"":         sqload.DialectGeneric,
+	"generic":  sqload.DialectGeneric,
+	"postgres": sqload.DialectPostgres,
+	"mysql":    sqload.DialectMySQL,
+	"sqlite":   sqload.DialectSQLite,
+	"tsql":     sqload.DialectTSQL,
+}
+
+// parseDialect returns the dialect named name.
+func parseDialect(name string) (sqload.Dialect, error) {
+	d, found := dialects[strings.ToLower(name)]
+	if !found {
+		return "", fmt.Errorf("unknown dialect %s", name)
+	}
+	return d, nil
+}
+
+// dirArg returns the directory named by the arguments args of a command, or the current
+// directory if there are none.
+func dirArg(args []string) string {
+	if len(args) == 0 {
+		return "."
+	}
+	return args[0]
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRunAttributes(t *testing.T) {
+	config := []string{"-config", "testdata/attrs/.sqload.yaml"}
+	testCases := [][]string{
+		{"bind", "-dir", "testdata/attrs/sql", "-pkg", "app"},
+		{"check", "-sql", "testdata/attrs/sql", "./testdata/attrs/app"},
+		{"export", "testdata/attrs/sql"},
+		{"lint", "testdata/attrs/sql"},
+		{"show", "FindUser", "testdata/attrs/sql"},
+		{"stats", "testdata/attrs/sql"},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			args := append([]string{tc[0]}, append(config, tc[1:]...)...)
+			var stderr bytes.Buffer
+			if status := run(args, &bytes.Buffer{}, &stderr); status != 0 {
+				t.Errorf("got status %d, want 0: %s", status, stderr.String())
+			}
+			// Without the configuration, the attributes are unknown
+			stderr.Reset()
+			want := fmt.Sprintf("sqload %s: cannot load queries: unknown query attribute timeout=2s of query FindUser, see WithAttributes (users.sql:1)\n", tc[0])
+			if status := run(tc, &bytes.Buffer{}, &stderr); status != 1 || stderr.String() != want {
+				t.Errorf("got status %d and %s, want 1 and %s", status, stderr.String(), want)
+			}
+		})
+	}
+	w := &watcher{dir: "testdata/attrs/sql", structRef: "./testdata/attrs/app.Queries"}
+	if diags := w.validate(); len(diags) != 1 {
+		t.Errorf("got %v, want the unknown attribute", diags)
+	}
+	cfg, err := readConfig("testdata/attrs/.sqload.yaml")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	w.opts = cfg.options()
+	if diags := w.validate(); len(diags) != 0 {
+		t.Errorf("got %v, want no diagnostics", diags)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// runScaffold prints a skeleton .sql file with a query comment for each query bound by
+// a struct, so a new set of queries can start from the struct that will hold them.
+func runScaffold(args []string, stdout io.Writer) error {
+	fs := newFlagSet("scaffold", "[-o file] ./pkg/queries.Q")
+	output := fs.String("o", "", "write the skeleton to `file` instead of the standard output; the file must not exist")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errFailed
+	}
+	queries, err := loadStructQueries(fs.Arg(0), nil)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return writeScaffold(stdout, queries)
+	}
+	f, err := os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := writeScaffold(f, queries); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeScaffold writes a query comment for each query in queries to w, skipping the
+// queries bound by several fields after the first one.
+func writeScaffold(w io.Writer, queries []structQuery) error {
+	seen := map[string]bool{}
+	for _, q := range queries {
+		if seen[q.Name] {
+			continue
+		}
+		seen[q.Name] = true
+		if len(seen) > 1 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "-- query: %s\n-- TODO: write the SQL code bound to %s.\n", q.Name, q.Field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
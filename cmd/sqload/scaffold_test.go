@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRunScaffold(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"./testdata/queries.Q"},
+			wantOutput: `-- query: Ping
+-- TODO: write the SQL code bound to Ping.
+
+-- query: Users.FindUser
+-- TODO: write the SQL code bound to Users.FindUser.
+
+-- query: Users.DeleteUser
+-- TODO: write the SQL code bound to Users.DeleteUser.
+
+-- query: cat.FindCat
+-- TODO: write the SQL code bound to Cats.FindCat.
+`,
+		},
+		{
+			args:    []string{"./testdata/queries.R"},
+			wantErr: fmt.Errorf("could not find struct R in ./testdata/queries"),
+		},
+		{
+			args:    []string{"./testdata/queries"},
+			wantErr: fmt.Errorf("invalid struct ./testdata/queries, want a reference like ./pkg/queries.Q"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runScaffold(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+
+	"github.com/midir99/sqload"
+)
+
+// runShow prints the SQL code of a query as a program loading the .sql files with the
+// same options would receive it.
+func runShow(args []string, stdout io.Writer) error {
+	fs := newFlagSet("show", "[-dialect name] [-variant name] [-templates] [-dir-namespaces] [-keep-comments] [-config file] query [dir]")
+	dialect := fs.String("dialect", "", "load the queries as written in the `dialect`: postgres, mysql, sqlite or tsql")
+	variant := fs.String("variant", "", "show the `variant` of the query instead of its default version")
+	templates := fs.Bool("templates", false, "expand the queries as templates, see sqload.WithTemplateFuncs")
+	dirNamespaces := fs.Bool("dir-namespaces", false, "name the queries after their directories, see sqload.WithDirNamespaces")
+	keepComments := fs.Bool("keep-comments", false, "keep the comments of the queries, see sqload.WithKeepComments")
+	configFile := fs.String("config", ".sqload.yaml", "read the attributes of the queries from `file`, if it exists")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return errFailed
+	}
+	d, err := parseDialect(*dialect)
+	if err != nil {
+		return err
+	}
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	opts := append(cfg.options(), sqload.WithDialect(d))
+	if *templates {
+		opts = append(opts, sqload.WithTemplateFuncs(template.FuncMap{}))
+	}
+	if *dirNamespaces {
+		opts = append(opts, sqload.WithDirNamespaces())
+	}
+	if *keepComments {
+		opts = append(opts, sqload.WithKeepComments())
+	}
+	qs, err := sqload.LoadQuerySet(os.DirFS(dirArg(fs.Args()[1:])), opts...)
+	if err != nil {
+		return err
+	}
+	name := fs.Arg(0)
+	q, found := qs.SelectQuery(name, sqload.Flags{name: *variant})
+	switch {
+	case !found:
+		return fmt.Errorf("could not find query %s", name)
+	case q.Variant != *variant:
+		return fmt.Errorf("could not find variant %s of query %s", *variant, name)
+	}
+	_, err = fmt.Fprintln(stdout, q.SQL)
+	return err
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRunShow(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args:       []string{"FindUser", "testdata/show"},
+			wantOutput: "SELECT * FROM user WHERE {{if true}}id = :id{{end}};\n",
+		},
+		{
+			args:       []string{"-templates", "-keep-comments", "FindUser", "testdata/show"},
+			wantOutput: "-- Finds a user.\nSELECT * FROM user WHERE id = :id;\n",
+		},
+		{
+			args:       []string{"-variant", "fast", "FindUser", "testdata/show"},
+			wantOutput: "SELECT * FROM user_by_id WHERE id = :id;\n",
+		},
+		{
+			args:    []string{"-variant", "slow", "FindUser", "testdata/show"},
+			wantErr: fmt.Errorf("could not find variant slow of query FindUser"),
+		},
+		{
+			args:    []string{"FindCat", "testdata/show"},
+			wantErr: fmt.Errorf("could not find query FindCat"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runShow(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %q, want %q", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
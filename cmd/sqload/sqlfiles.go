@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// definition is the definition of a query in a .sql file.
+type definition struct {
+	Name string // name of the query, with its variant as in Name:variant
+	File string // path of the file, relative to the file system it was read from
+	Line int    // line of the query comment
+}
+
+// findSQLFiles returns the paths of the .sql files in the fsys file system, in lexical
+// order.
+func findSQLFiles(fsys fs.FS) ([]string, error) {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.ToLower(path.Ext(p)) == ".sql" {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// scanDefinitions returns the definitions of the queries of the .sql files in the fsys
+// file system, in the order they are written. Unlike the sqload package, it keeps
+// every definition of a query, so the duplicates can be reported. If dirNamespaces is
+// true, the names start with the top-level directory of their file, see
+// sqload.WithDirNamespaces.
+func scanDefinitions(fsys fs.FS, dirNamespaces bool) ([]definition, error) {
+	files, err := findSQLFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+	var defs []definition
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		namespace := ""
+		if dir, _, found := strings.Cut(file, "/"); dirNamespaces && found {
+			namespace = dir + "."
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, len(data)+1)
+		for line := 1; scanner.Scan(); line++ {
+			rest, found := cutQueryComment(scanner.Text())
+			if !found {
+				continue
+			}
+			names, attrs := splitQueryComment(rest)
+			for _, name := range names {
+				name = namespace + name
+				for _, attr := range attrs {
+					if strings.HasPrefix(attr, "variant=") {
+						name += ":" + strings.TrimPrefix(attr, "variant=")
+					}
+				}
+				defs = append(defs, definition{Name: name, File: file, Line: line})
+			}
+		}
+	}
+	return defs, nil
+}
+
+// definedNames returns the names of the queries defined in defs.
+func definedNames(defs []definition) []string {
+	names := make([]string, len(defs))
+	for i, def := range defs {
+		names[i] = def.Name
+	}
+	return names
+}
+
+// cutQueryComment returns the text after the -- query: prefix of line, and whether
+// line is a query comment.
+func cutQueryComment(line string) (string, bool) {
+	line = strings.TrimLeft(line, " \t\r\f\v")
+	if !strings.HasPrefix(line, "-- query:") {
+		return "", false
+	}
+	return strings.TrimPrefix(line, "-- query:"), true
+}
+
+// splitQueryComment splits rest, the text after the -- query: prefix of a query comment,
+// into the names of the query, which are separated by commas, and its key=value
+// attributes. The names are split as the library splits them, so a name with a space,
+// like Delete User, is kept whole instead of becoming DeleteUser.
+func splitQueryComment(rest string) ([]string, []string) {
+	fields := strings.Fields(rest)
+	i := 0
+	for i < len(fields) && !strings.Contains(fields[i], "=") {
+		i++
+	}
+	var names []string
+	for _, name := range strings.Split(strings.Join(fields[:i], " "), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, fields[i:]
+}
+
+// duplicates returns the definitions of the queries defined more than once in defs,
+// grouped by name and sorted by name.
+func duplicates(defs []definition) [][]definition {
+	byName := map[string][]definition{}
+	for _, def := range defs {
+		byName[def.Name] = append(byName[def.Name], def)
+	}
+	var dups [][]definition
+	for _, group := range byName {
+		if len(group) > 1 {
+			dups = append(dups, group)
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool { return dups[i][0].Name < dups[j][0].Name })
+	return dups
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSplitQueryComment(t *testing.T) {
+	testCases := []struct {
+		rest      string
+		wantNames []string
+		wantAttrs []string
+	}{
+		{" FindUser", []string{"FindUser"}, nil},
+		{" DeleteUser, RemoveUser variant=soft", []string{"DeleteUser", "RemoveUser"}, []string{"variant=soft"}},
+		{" DeleteUser ,RemoveUser", []string{"DeleteUser", "RemoveUser"}, nil},
+		{" Delete User, RemoveUser", []string{"Delete User", "RemoveUser"}, nil},
+		{"", nil, nil},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			names, attrs := splitQueryComment(tc.rest)
+			if fmt.Sprint(names) != fmt.Sprint(tc.wantNames) {
+				t.Errorf("got %v, want %v", names, tc.wantNames)
+			}
+			if fmt.Sprint(attrs) != fmt.Sprint(tc.wantAttrs) {
+				t.Errorf("got %v, want %v", attrs, tc.wantAttrs)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/midir99/sqload"
+)
+
+// stats are the figures of a tree of .sql files printed by the stats command.
+type stats struct {
+	Queries       int          `json:"queries"`
+	Files         int          `json:"files"`
+	Bytes         int          `json:"bytes"`
+	Largest       []queryStats `json:"largest"`
+	WithParams    int          `json:"queries_with_params"`
+	WithoutParams int          `json:"queries_without_params"`
+	Params        []paramStats `json:"params"`
+	Dirs          []dirStats   `json:"dirs"`
+}
+
+type queryStats struct {
+	Name  string `json:"name"`
+	File  string `json:"file"`
+	Bytes int    `json:"bytes"`
+}
+
+type paramStats struct {
+	Name    string `json:"name"`
+	Queries int    `json:"queries"`
+}
+
+type dirStats struct {
+	Dir     string `json:"dir"`
+	Files   int    `json:"files"`
+	Queries int    `json:"queries"`
+	Bytes   int    `json:"bytes"`
+}
+
+// runStats prints figures about the queries of a tree of .sql files: how many there are,
+// how large they are, which parameters they use and how they spread across directories.
+func runStats(args []string, stdout io.Writer) error {
+	fs := newFlagSet("stats", "[-top n] [-json] [-config file] [dir]")
+	top := fs.Int("top", 5, "list the `n` largest queries")
+	asJSON := fs.Bool("json", false, "print the figures as a JSON object")
+	configFile := fs.String("config", ".sqload.yaml", "read the attributes of the queries from `file`, if it exists")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	s, err := collectStats(dirArg(fs.Args()), *top, cfg.options()...)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	return writeStats(stdout, s)
+}
+
+// collectStats returns the figures of the .sql files of the directory dir, loaded with
+// the options opts, listing its top largest queries.
+func collectStats(dir string, top int, opts ...sqload.Option) (*stats, error) {
+	fsys := os.DirFS(dir)
+	qs, err := sqload.LoadQuerySet(fsys, opts...)
+	if err != nil {
+		return nil, err
+	}
+	files, err := findSQLFiles(fsys)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := scanDefinitions(fsys, false)
+	if err != nil {
+		return nil, err
+	}
+	fileOf := map[string]string{}
+	for _, def := range defs {
+		fileOf[def.Name] = def.File // the last definition wins, like when loading
+	}
+	s := &stats{Files: len(files), Largest: []queryStats{}, Params: []paramStats{}, Dirs: []dirStats{}}
+	dirs := map[string]*dirStats{}
+	dirOf := func(file string) *dirStats {
+		d := path.Dir(file)
+		if dirs[d] == nil {
+			dirs[d] = &dirStats{Dir: d}
+		}
+		return dirs[d]
+	}
+	for _, file := range files {
+		dirOf(file).Files++
+	}
+	params := map[string]int{}
+	var sizes []queryStats
+	for _, q := range qs.Queries() {
+		file := fileOf[q.Name]
+		size := len(q.SQL)
+		s.Queries++
+		s.Bytes += size
+		sizes = append(sizes, queryStats{Name: q.Name, File: file, Bytes: size})
+		d := dirOf(file)
+		d.Queries++
+		d.Bytes += size
+		if len(q.Params) == 0 {
+			s.WithoutParams++
+		} else {
+			s.WithParams++
+		}
+		for _, p := range q.Params {
+			params[p]++
+		}
+	}
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].Bytes > sizes[j].Bytes })
+	if len(sizes) > top {
+		sizes = sizes[:top]
+	}
+	s.Largest = append(s.Largest, sizes...)
+	for name, n := range params {
+		s.Params = append(s.Params, paramStats{Name: name, Queries: n})
+	}
+	sort.Slice(s.Params, func(i, j int) bool {
+		if s.Params[i].Queries != s.Params[j].Queries {
+			return s.Params[i].Queries > s.Params[j].Queries
+		}
+		return s.Params[i].Name < s.Params[j].Name
+	})
+	for _, d := range dirs {
+		s.Dirs = append(s.Dirs, *d)
+	}
+	sort.Slice(s.Dirs, func(i, j int) bool { return s.Dirs[i].Dir < s.Dirs[j].Dir })
+	return s, nil
+}
+
+// writeStats writes s to w as aligned text.
+func writeStats(w io.Writer, s *stats) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "queries\t%d\n", s.Queries)
+	fmt.Fprintf(tw, "files\t%d\n", s.Files)
+	fmt.Fprintf(tw, "bytes\t%d\n", s.Bytes)
+	fmt.Fprintf(tw, "with params\t%d\n", s.WithParams)
+	fmt.Fprintf(tw, "without params\t%d\n", s.WithoutParams)
+	fmt.Fprintf(tw, "\nlargest queries\n")
+	for _, q := range s.Largest {
+		fmt.Fprintf(tw, "  %s\t%s\t%d\n", q.Name, q.File, q.Bytes)
+	}
+	fmt.Fprintf(tw, "\nparams\n")
+	for _, p := range s.Params {
+		fmt.Fprintf(tw, "  %s\t%d\n", p.Name, p.Queries)
+	}
+	fmt.Fprintf(tw, "\ndirectories\n")
+	for _, d := range s.Dirs {
+		fmt.Fprintf(tw, "  %s\t%d files\t%d queries\t%d bytes\n", d.Dir, d.Files, d.Queries, d.Bytes)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRunStats(t *testing.T) {
+	testCases := []struct {
+		args       []string
+		wantOutput string
+		wantErr    error
+	}{
+		{
+			args: []string{"-top", "2", "testdata/check/sql"},
+			wantOutput: `queries         3
+files           2
+bytes           61
+with params     1
+without params  2
+
+largest queries
+  Users.FindUser  users/users.sql  34
+  FindCats        ping.sql         18
+
+params
+  id  1
+
+directories
+  .      1 files  2 queries  27 bytes
+  users  1 files  1 queries  34 bytes
+`,
+		},
+		{
+			args: []string{"-top", "0", "-json", "testdata/check/sql/users"},
+			wantOutput: `{
+  "queries": 1,
+  "files": 1,
+  "bytes": 34,
+  "largest": [],
+  "queries_with_params": 1,
+  "queries_without_params": 0,
+  "params": [
+    {
+      "name": "id",
+      "queries": 1
+    }
+  ],
+  "dirs": [
+    {
+      "dir": ".",
+      "files": 1,
+      "queries": 1,
+      "bytes": 34
+    }
+  ]
+}
+`,
+		},
+		{
+			args:    []string{"testdata/i-dont-exist"},
+			wantErr: fmt.Errorf("cannot load queries: stat .: no such file or directory"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := runStats(tc.args, &stdout)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if stdout.String() != tc.wantOutput {
+				t.Errorf("got %s, want %s", stdout.String(), tc.wantOutput)
+			}
+		})
+	}
+}
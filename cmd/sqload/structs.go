@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// structQuery is a query bound by a struct: a field with a query tag.
+type structQuery struct {
+	Name  string         // name of the query, with the namespaces of the nested structs
+	Field string         // path of the field, like Users.FindUser
+	Pos   token.Position // position of the field
+}
+
+// goPackage holds the parsed Go files of a package directory, and their types once they
+// are checked, see typeCheck.
+type goPackage struct {
+	dir   string
+	fset  *token.FileSet
+	files []*ast.File
+	types *types.Package
+	info  *types.Info
+}
+
+// parsePackage parses the Go files of the directory dir, skipping the test files.
+func parsePackage(dir string) (*goPackage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	pkg := &goPackage{dir: dir, fset: token.NewFileSet()}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(pkg.fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		pkg.files = append(pkg.files, f)
+	}
+	return pkg, nil
+}
+
+// splitStructRef splits a struct reference like ./pkg/queries.Q into the directory of
+// the package and the name of the type.
+func splitStructRef(ref string) (dir, typeName string, err error) {
+	i := strings.LastIndex(ref, ".")
+	if i <= strings.LastIndex(ref, "/") || i == len(ref)-1 {
+		return "", "", fmt.Errorf("invalid struct %s, want a reference like ./pkg/queries.Q", ref)
+	}
+	dir, typeName = ref[:i], ref[i+1:]
+	if dir == "" {
+		dir = "."
+	}
+	return dir, typeName, nil
+}
+
+// loadStructQueries returns the queries bound by the struct referenced by ref, like
+// ./pkg/queries.Q, in the order of the fields, against the names of the queries defined,
+// see structQueries. The struct is resolved with go/types, so its fields can have the
+// struct types of other packages.
+func loadStructQueries(ref string, defined []string) ([]structQuery, error) {
+	dir, typeName, err := splitStructRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := parsePackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	tpkg, _ := pkg.typeCheck()
+	if tn, ok := tpkg.Scope().Lookup(typeName).(*types.TypeName); ok {
+		if st, ok := tn.Type().Underlying().(*types.Struct); ok {
+			return pkg.structQueries(st, "", "", defined, map[*types.Struct]bool{}), nil
+		}
+	}
+	return nil, fmt.Errorf("could not find struct %s in %s", typeName, dir)
+}
+
+// structQueries returns the queries bound by the struct type st, following the same
+// rules as the Load functions: tagged fields bind queries and untagged exported struct
+// fields bind the queries of a namespace. The namespace and field path of st are
+// namespace and path. As in the Load functions, the namespace of an untagged field is
+// the first namespace of the queries defined that matches its name without regard to
+// case, or its name if there is none.
+func (pkg *goPackage) structQueries(st *types.Struct, namespace, path string, defined []string, seen map[*types.Struct]bool) []structQuery {
+	if seen[st] {
+		return nil
+	}
+	seen[st] = true
+	defer delete(seen, st)
+	var queries []structQuery
+	for i := 0; i < st.NumFields(); i++ {
+		field, tag := st.Field(i), reflect.StructTag(st.Tag(i))
+		if query := tag.Get("query"); query != "" {
+			query, _, _ = strings.Cut(query, ",")
+			queries = append(queries, structQuery{
+				Name:  namespace + query,
+				Field: path + field.Name(),
+				Pos:   pkg.fset.Position(field.Pos()),
+			})
+			continue
+		}
+		nested, ok := field.Type().Underlying().(*types.Struct)
+		if !ok || !field.Exported() || field.Anonymous() {
+			continue
+		}
+		ns, tagged := tag.Lookup("namespace")
+		if !tagged {
+			ns = definedNamespace(defined, namespace, field.Name())
+		}
+		queries = append(queries, pkg.structQueries(nested, namespace+ns+".", path+field.Name()+".", defined, seen)...)
+	}
+	return queries
+}
+
+// definedNamespace returns the namespace bound by an untagged field named name of a
+// struct bound to the namespace namespace: the first namespace after namespace in the
+// sorted names of the queries defined that is equal to name without regard to case, or
+// name if there is none.
+func definedNamespace(defined []string, namespace, name string) string {
+	sorted := append([]string(nil), defined...)
+	sort.Strings(sorted)
+	for _, queryName := range sorted {
+		if !strings.HasPrefix(queryName, namespace) {
+			continue
+		}
+		ns, _, found := strings.Cut(strings.TrimPrefix(queryName, namespace), ".")
+		if found && strings.EqualFold(ns, name) {
+			return ns
+		}
+	}
+	return name
+}
+
+// tagKeyPattern matches the keys of a struct tag.
+var tagKeyPattern = regexp.MustCompile(`(?:^|\s)([^\s:"]+):"`)
+
+// tagTypo is a struct tag key that looks like a misspelled query or namespace key.
+type tagTypo struct {
+	Key  string
+	Want string
+	Pos  token.Position
+}
+
+// scanPattern parses the Go packages matched by pattern, a directory optionally followed
+// by /... to include its subdirectories. Directories named testdata or vendor, or
+// starting with . or _, are skipped.
+func scanPattern(pattern string) ([]*goPackage, error) {
+	root, recursive := pattern, false
+	if pattern == "..." || strings.HasSuffix(pattern, "/...") {
+		root, recursive = strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/"), true
+	}
+	if root == "" {
+		root = "."
+	}
+	var dirs []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if p != root && (!recursive || name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			return filepath.SkipDir
+		}
+		dirs = append(dirs, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pkgs []*goPackage
+	for _, dir := range dirs {
+		pkg, err := parsePackage(dir)
+		if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// rootQueries returns the queries bound by all the structs of the package that are not
+// nested in another struct, against the names of the queries defined, see
+// structQueries. The names of the queries start with prefix, see sqload.WithPrefix.
+func (pkg *goPackage) rootQueries(defined []string, prefix string) []structQuery {
+	_, info := pkg.typeCheck()
+	structOf := func(expr ast.Expr) *types.Struct {
+		st, _ := info.Types[expr].Type.(*types.Struct)
+		return st
+	}
+	// The named structs bound to a namespace by an untagged field are not roots
+	nested := map[*types.TypeName]bool{}
+	for _, f := range pkg.files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			expr, ok := n.(*ast.StructType)
+			if !ok || structOf(expr) == nil {
+				return true
+			}
+			st := structOf(expr)
+			for i := 0; i < st.NumFields(); i++ {
+				if named, ok := st.Field(i).Type().(*types.Named); ok && st.Tag(i) == "" {
+					nested[named.Obj()] = true
+				}
+			}
+			return true
+		})
+	}
+	var queries []structQuery
+	for _, f := range pkg.files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.TypeSpec:
+				if expr, ok := n.Type.(*ast.StructType); ok {
+					if tn, ok := info.Defs[n.Name].(*types.TypeName); ok && !nested[tn] && structOf(expr) != nil {
+						queries = append(queries, pkg.structQueries(structOf(expr), prefix, "", defined, map[*types.Struct]bool{})...)
+					}
+					return false
+				}
+			case *ast.StructType:
+				if st := structOf(n); st != nil {
+					found := pkg.structQueries(st, prefix, "", defined, map[*types.Struct]bool{})
+					queries = append(queries, found...)
+					return len(found) == 0
+				}
+			}
+			return true
+		})
+	}
+	return queries
+}
+
+// tagTypos returns the keys of the struct tags of the package that are not query or
+// namespace but look like a misspelling of them.
+func (pkg *goPackage) tagTypos() []tagTypo {
+	var typos []tagTypo
+	for _, f := range pkg.files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			field, ok := n.(*ast.Field)
+			if !ok || field.Tag == nil {
+				return true
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return true
+			}
+			for _, m := range tagKeyPattern.FindAllStringSubmatch(tag, -1) {
+				for _, want := range []string{"query", "queryattr", "querydoc", "namespace"} {
+					if m[1] != want && (strings.EqualFold(m[1], want) || editDistance(m[1], want) <= len(want)/4) {
+						typos = append(typos, tagTypo{Key: m[1], Want: want, Pos: pkg.fset.Position(field.Tag.Pos())})
+					}
+				}
+			}
+			return true
+		})
+	}
+	return typos
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			next := diagonal + cost
+			if row[j]+1 < next {
+				next = row[j] + 1
+			}
+			if row[j-1]+1 < next {
+				next = row[j-1] + 1
+			}
+			diagonal, row[j] = row[j], next
+		}
+	}
+	return row[len(b)]
+}
@@ -0,0 +1,6 @@
+package app
+
+type Queries struct {
+	FindUser   string `query:"FindUser"`
+	DeleteUser string `query:"DeleteUser"`
+}
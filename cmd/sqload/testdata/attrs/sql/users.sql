@@ -0,0 +1,5 @@
+-- query: FindUser timeout=2s
+SELECT id, name FROM user WHERE id = :id;
+
+-- query: DeleteUser
+DELETE FROM user WHERE id = :id;
@@ -0,0 +1,15 @@
+-- query: Ping
+SELECT 1;
+
+-- query: users.FindUser
+-- alias: users.FindUserV1
+SELECT * FROM user WHERE id = :id;
+
+-- query: find_user_by_email
+SELECT * FROM user WHERE email = :email;
+
+-- query: billing.invoices.CreateInvoice
+INSERT INTO invoice (user_id) VALUES (:user_id);
+
+-- query: users.DeleteUser
+DELETE FROM user WHERE id = :id;
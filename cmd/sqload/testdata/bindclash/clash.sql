@@ -0,0 +1,5 @@
+-- query: Users
+SELECT 1;
+
+-- query: users.FindUser
+SELECT 2;
@@ -0,0 +1,5 @@
+-- query: Ping
+SELECT 1;
+
+-- query: root.FindUser
+SELECT * FROM user WHERE id = :id;
@@ -0,0 +1,14 @@
+package app
+
+import "github.com/midir99/sqload"
+
+type Users struct {
+	FindUser string `query:"FindUser"`
+}
+
+var Q = sqload.MustLoadFromFS[struct {
+	Ping      string `query:"Ping"`
+	FindCats  string `query:"FindCatz"`
+	DeleteCat string `Query:"DeleteCat"`
+	Users     Users
+}](nil)
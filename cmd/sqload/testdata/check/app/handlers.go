@@ -0,0 +1,37 @@
+package app
+
+import "fmt"
+
+func FindUserSQL(name string) string {
+	return Q.Users.FindUser + " AND name = '" + name + "'"
+}
+
+func PingSQL() string {
+	return Q.Ping + " LIMIT 1"
+}
+
+func FindCatsSQL(order string) string {
+	sql := fmt.Sprintf("%s ORDER BY %s", Q.FindCats, order)
+	return sql
+}
+
+func DeleteCatSQL(ids string) string {
+	sql := "DELETE FROM cat"
+	sql += " WHERE id IN (" + ids + ")"
+	return sql
+}
+
+func PingTwiceSQL(sep string) string {
+	q := Q
+	q.Ping += sep
+	return q.Ping
+}
+
+// Event has a field named like a query field, which is not bound to a query.
+type Event struct {
+	Ping string
+}
+
+func EventSQL(e Event, suffix string) string {
+	return e.Ping + suffix
+}
@@ -0,0 +1,8 @@
+-- query: Ping
+SELECT 1;
+
+-- query: FindCats
+SELECT * FROM cat;
+
+-- query: Ping
+SELECT 2;
@@ -0,0 +1,5 @@
+-- query: Users.FindUser
+SELECT * FROM user WHERE id = :id;
+
+-- query: Users.FindUser variant=v2
+SELECT * FROM user_v2 WHERE id = :id;
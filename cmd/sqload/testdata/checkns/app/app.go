@@ -0,0 +1,12 @@
+package app
+
+type Billing struct {
+	FindInvoice string `query:"FindInvoice"`
+}
+
+type Queries struct {
+	Users struct {
+		FindUser string `query:"FindUser"`
+	}
+	Billing Billing
+}
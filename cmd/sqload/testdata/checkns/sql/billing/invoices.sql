@@ -0,0 +1,2 @@
+-- query: FindInvoice
+SELECT id, total FROM invoice WHERE id = :id;
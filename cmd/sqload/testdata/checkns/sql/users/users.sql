@@ -0,0 +1,2 @@
+-- query: FindUser
+SELECT id, name FROM user WHERE id = :id;
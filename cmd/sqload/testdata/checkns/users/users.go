@@ -0,0 +1,5 @@
+package users
+
+type Queries struct {
+	FindUser string `query:"FindUser"`
+}
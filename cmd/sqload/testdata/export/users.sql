@@ -0,0 +1,10 @@
+-- query: FindUserById
+-- Returns the user with the id.
+SELECT * FROM user WHERE id = :id AND org = :org;
+
+-- query: billing.CreateInvoice
+-- tags: billing
+INSERT INTO invoice (user_id) VALUES (:user_id) RETURNING id;
+
+-- query: DeleteHTMLCache
+DELETE FROM html_cache;
@@ -0,0 +1,16 @@
+-- Queries of the users.
+
+
+  -- query:   FindUser  
+  --tags:a, b
+--Finds a user.
+--
+
+select *
+	from user   
+  where id = :id;
+
+
+
+-- query: DeleteUser   variant=soft
+update user set deleted = true;
\ No newline at end of file
@@ -0,0 +1,5 @@
+-- name: find-user-by-id
+SELECT 1;
+
+-- name: find/users
+SELECT 2;
@@ -0,0 +1,3 @@
+-- name: find-user-by-id
+-- tags: users
+SELECT * FROM user WHERE id = :id;
@@ -0,0 +1,7 @@
+-- name: GetAuthor :one
+-- Returns the author with the id.
+SELECT * FROM authors
+WHERE id = sqlc.arg(id) LIMIT 1;
+
+-- name: DeleteAuthor :exec
+DELETE FROM authors WHERE id = $1;
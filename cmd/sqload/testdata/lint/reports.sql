@@ -0,0 +1,6 @@
+-- query: TeamReport
+SELECT u.name, t.name, o.name FROM "user" u JOIN team t ON t.id = u.team_id JOIN org o ON o.id = t.org_id;
+
+-- query: OrgReport
+-- budget: max-joins=2
+SELECT u.name, t.name, o.name FROM "user" u JOIN team t ON t.id = u.team_id JOIN org o ON o.id = t.org_id;
@@ -0,0 +1,7 @@
+-- query: SeedUsers
+-- Seeds the users of the demo.
+INSERT INTO user (email, password)
+VALUES ('admin@example.com', 'hunter2');
+
+-- query: FindSessions
+SELECT id FROM session WHERE token_type = 'bearer';
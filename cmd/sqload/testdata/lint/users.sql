@@ -0,0 +1,10 @@
+-- query: FindUsers
+-- tags: paged
+SELECT * FROM user;
+
+-- query: DumpUsers
+-- lint-ignore: deny-select-star
+SELECT * FROM auth.user;
+
+-- query: CountUsers
+SELECT COUNT(*) FROM user;
@@ -0,0 +1,16 @@
+package queries
+
+type Users struct {
+	FindUser   string `query:"FindUser"`
+	DeleteUser string `query:"DeleteUser"`
+}
+
+type Q struct {
+	Ping  string `query:"Ping"`
+	Users Users
+	Cats  struct {
+		FindCat string `query:"FindCat"`
+	} `namespace:"cat"`
+	Again  string `query:"Ping,dialect=mysql"`
+	hidden Users
+}
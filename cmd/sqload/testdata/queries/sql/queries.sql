@@ -0,0 +1,11 @@
+-- query: Ping
+-- TODO: write the SQL code bound to Ping.
+
+-- query: Users.FindUser
+-- TODO: write the SQL code bound to Users.FindUser.
+
+-- query: Users.DeleteUser
+-- TODO: write the SQL code bound to Users.DeleteUser.
+
+-- query: cat.FindCat
+-- TODO: write the SQL code bound to Cats.FindCat.
@@ -0,0 +1,20 @@
+-- query: CreateUserTable
+-- Holds the users.
+CREATE TABLE user (
+    id SERIAL PRIMARY KEY,
+    email TEXT NOT NULL
+);
+
+-- query: FindUserByEmail
+SELECT * FROM user WHERE email = :email;
+
+-- query: CreateUserEmailIndex
+CREATE UNIQUE INDEX user_email ON user (email)
+
+-- query: CreateTouchFunction
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
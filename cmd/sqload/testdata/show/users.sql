@@ -0,0 +1,6 @@
+-- query: FindUser
+-- Finds a user.
+SELECT * FROM user WHERE {{if true}}id = :id{{end}};
+
+-- query: FindUser variant=fast
+SELECT * FROM user_by_id WHERE id = :id;
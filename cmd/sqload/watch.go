@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/midir99/sqload"
+)
+
+// runWatch validates a tree of .sql files, and optionally the struct they are bound to,
+// every time one of their files changes, printing the diagnostics that appear and
+// disappear, until it is interrupted.
+func runWatch(args []string, stdout io.Writer) error {
+	fs := newFlagSet("watch", "[-struct ./pkg/queries.Q] [-interval d] [-config file] [dir]")
+	structRef := fs.String("struct", "", "check the queries bound by the struct `ref`, like ./pkg/queries.Q")
+	interval := fs.Duration("interval", 500*time.Millisecond, "look for changes every `duration`")
+	configFile := fs.String("config", ".sqload.yaml", "read the attributes of the queries from `file`, if it exists")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := readConfig(*configFile)
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	w := &watcher{dir: dirArg(fs.Args()), structRef: *structRef, opts: cfg.options()}
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		if err := w.poll(stdout); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// stamp identifies a version of a file.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher validates the .sql files of a directory when they change.
+type watcher struct {
+	dir       string
+	structRef string
+	opts      []sqload.Option // the options the .sql files are parsed with
+	stamps    map[string]stamp
+	diags     map[string]bool // the diagnostics of the last validation, as text
+}
+
+// poll validates the files watched by w if any of them changed since the last call and
+// writes to out the diagnostics that disappeared, prefixed with "fixed:", the ones that
+// appeared, and a summary line.
+func (w *watcher) poll(out io.Writer) error {
+	stamps, err := w.snapshot()
+	if err != nil {
+		return err
+	}
+	if w.stamps != nil && sameStamps(w.stamps, stamps) {
+		return nil
+	}
+	w.stamps = stamps
+	diags := map[string]bool{}
+	var order []string
+	for _, d := range w.validate() {
+		if text := d.String(); !diags[text] {
+			diags[text] = true
+			order = append(order, text)
+		}
+	}
+	fixed := []string{}
+	for text := range w.diags {
+		if !diags[text] {
+			fixed = append(fixed, text)
+		}
+	}
+	sort.Strings(fixed)
+	for _, text := range fixed {
+		fmt.Fprintf(out, "fixed: %s\n", text)
+	}
+	for _, text := range order {
+		if !w.diags[text] {
+			fmt.Fprintln(out, text)
+		}
+	}
+	w.diags = diags
+	switch len(diags) {
+	case 0:
+		_, err = fmt.Fprintln(out, "ok")
+	case 1:
+		_, err = fmt.Fprintln(out, "1 problem")
+	default:
+		_, err = fmt.Fprintf(out, "%d problems\n", len(diags))
+	}
+	return err
+}
+
+// snapshot returns the stamps of the .sql files of the watched directory and of the Go
+// files of the package of the watched struct.
+func (w *watcher) snapshot() (map[string]stamp, error) {
+	stamps := map[string]stamp{}
+	add := func(path string, info fs.FileInfo) {
+		stamps[path] = stamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	err := filepath.WalkDir(w.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.ToLower(filepath.Ext(path)) != ".sql" {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		add(path, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if w.structRef == "" {
+		return stamps, nil
+	}
+	dir, _, err := splitStructRef(w.structRef)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		add(filepath.Join(dir, entry.Name()), info)
+	}
+	return stamps, nil
+}
+
+// validate returns the diagnostics of the watched files, sorted by position.
+func (w *watcher) validate() []diagnostic {
+	fsys := os.DirFS(w.dir)
+	if _, err := sqload.ExtractQueryMapFromFS(fsys, w.opts...); err != nil {
+		return []diagnostic{{Kind: "invalid", Message: err.Error()}}
+	}
+	defs, err := scanDefinitions(fsys, false)
+	if err != nil {
+		return []diagnostic{{Kind: "invalid", Message: err.Error()}}
+	}
+	if w.structRef == "" {
+		return duplicateDiagnostics(defs, w.dir)
+	}
+	bound, err := loadStructQueries(w.structRef, definedNames(defs))
+	if err != nil {
+		return []diagnostic{{Kind: "invalid", Message: err.Error()}}
+	}
+	diags := compareQueries(bound, defs, w.dir, "")
+	sortDiagnostics(diags)
+	return diags
+}
+
+// sameStamps reports whether a and b hold the same files with the same stamps.
+func sameStamps(a, b map[string]stamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for path, s := range a {
+		if t, found := b[path]; !found || !t.modTime.Equal(s.modTime) || t.size != s.size {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatcherPoll(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "queries.sql")
+	write := func(sql string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(sql), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	write("-- query: Ping\nSELECT 1;\n", start)
+	w := &watcher{dir: dir, structRef: "./testdata/queries.Q"}
+	steps := []struct {
+		sql        string
+		wantOutput string
+	}{
+		{
+			wantOutput: `testdata/queries/queries.go:4: query Users.FindUser bound to field Users.FindUser is not defined
+testdata/queries/queries.go:5: query Users.DeleteUser bound to field Users.DeleteUser is not defined
+testdata/queries/queries.go:12: query cat.FindCat bound to field Cats.FindCat is not defined
+3 problems
+`,
+		},
+		{
+			sql: "-- query: Ping\nSELECT 1;\n-- query: Users.FindUser\nSELECT 2;\n-- query: Users.DeleteUser\nSELECT 3;\n",
+			wantOutput: `fixed: testdata/queries/queries.go:4: query Users.FindUser bound to field Users.FindUser is not defined
+fixed: testdata/queries/queries.go:5: query Users.DeleteUser bound to field Users.DeleteUser is not defined
+1 problem
+`,
+		},
+		{
+			wantOutput: "",
+		},
+		{
+			sql: "-- query: bad-name\nSELECT 1;\n",
+			wantOutput: `fixed: testdata/queries/queries.go:12: query cat.FindCat bound to field Cats.FindCat is not defined
+cannot load queries: invalid query name bad-name (queries.sql:1)
+1 problem
+`,
+		},
+	}
+	for i, step := range steps {
+		if step.sql != "" {
+			write(step.sql, start.Add(time.Duration(i)*time.Minute))
+		}
+		var out bytes.Buffer
+		if err := w.poll(&out); err != nil {
+			t.Fatalf("step %d: err must be nil, got %s", i, err)
+		}
+		if out.String() != step.wantOutput {
+			t.Errorf("step %d: got %s, want %s", i, out.String(), step.wantOutput)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code is the stable code of a kind of error of the Load functions, so platforms can
+// alert on them and document their remediation. The meaning of a code never changes;
+// new kinds of errors get new codes. Use ErrorCode to get the code of an error:
+//
+//	if sqload.ErrorCode(err) == sqload.CodeMissingQuery {
+//		...
+//	}
+type Code string
+
+const (
+	// CodeInvalidName is the code of the invalid names of queries, aliases, attributes
+	// and directory namespaces.
+	CodeInvalidName Code = "SQLOAD001"
+	// CodeMissingQuery is the code of the fields bound to queries that do not exist.
+	CodeMissingQuery Code = "SQLOAD002"
+	// CodeBadField is the code of the fields that can not be bound: their type is not
+	// supported, they can not be set, their tags are invalid or they reject their value.
+	CodeBadField Code = "SQLOAD003"
+	// CodeBadTarget is the code of the values that can not hold queries, like a nil
+	// pointer, or a QuerySet updated without being loaded from a file system.
+	CodeBadTarget Code = "SQLOAD004"
+	// CodeUnreadable is the code of the files and directories that can not be read.
+	CodeUnreadable Code = "SQLOAD005"
+	// CodeNoDefaultVariant is the code of the queries with variants but no default
+	// version.
+	CodeNoDefaultVariant Code = "SQLOAD006"
+	// CodeConflict is the code of the definitions that clash with each other, like an
+	// alias with the name of another query or an attribute written twice.
+	CodeConflict Code = "SQLOAD007"
+	// CodeCheckFailed is the code of the queries rejected by an assertion, a validator, a
+	// policy or a size limit.
+	CodeCheckFailed Code = "SQLOAD008"
+	// CodeExpansionFailed is the code of the failures of the code that produces or
+	// rewrites queries: templates, transforms, preprocessors, upserts, models and
+	// annotation handlers.
+	CodeExpansionFailed Code = "SQLOAD009"
+	// CodeNoMatchingFile is the code of the queries that have no version for the dialect
+	// or the engines loaded.
+	CodeNoMatchingFile Code = "SQLOAD010"
+	// CodeInvalidAnnotation is the code of the annotations with invalid values, like a
+	// kind annotation naming an unknown kind.
+	CodeInvalidAnnotation Code = "SQLOAD011"
+	// CodeInvalidOption is the code of the options given invalid values, like a nil model
+	// registered with WithModel.
+	CodeInvalidOption Code = "SQLOAD012"
+)
+
+// ErrorCode returns the code of err, or an empty string if it is not an error of the Load
+// functions or it has no code.
+func ErrorCode(err error) Code {
+	var c coder
+	if errors.As(err, &c) {
+		return c.errorCode()
+	}
+	return ""
+}
+
+// coder is implemented by the errors that have a code.
+type coder interface {
+	errorCode() Code
+}
+
+// codedError is an error with a code.
+type codedError struct {
+	code Code
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+func (e *codedError) errorCode() Code {
+	return e.code
+}
+
+// errorf is like fmt.Errorf but the error has the code code.
+func errorf(code Code, format string, a ...any) error {
+	return &codedError{code: code, err: fmt.Errorf(format, a...)}
+}
@@ -0,0 +1,67 @@
+//go:build !sqload_noos
+
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestErrorCode(t *testing.T) {
+	type Queries struct {
+		FindUser string `query:"FindUser"`
+	}
+	load := func(sql string, opts ...Option) error {
+		_, err := LoadFromString[Queries](sql, opts...)
+		return err
+	}
+	qs, err := LoadQuerySet(fstest.MapFS{"a.sql": {Data: []byte("-- query: FindUser\nSELECT 1;")}})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		err  error
+		want Code
+	}{
+		{load("-- query: find-user\nSELECT 1;"), CodeInvalidName},
+		{load("-- query: FindUser\n-- alias: find-user\nSELECT 1;"), CodeInvalidName},
+		{load("-- query: FindCat\nSELECT 1;"), CodeMissingQuery},
+		{load("-- query: Ping\nSELECT 1;\n-- query: Pong\nSELECT 1;"), CodeMissingQuery},
+		{func() error {
+			_, err := LoadFromString[struct {
+				FindUser int `query:"FindUser"`
+			}]("-- query: FindUser\nSELECT 1;")
+			return err
+		}(), CodeBadField},
+		{qs.Into((*Queries)(nil)), CodeBadTarget},
+		{func() error {
+			_, err := LoadFromDir[Queries]("i-dont-exist")
+			return err
+		}(), CodeUnreadable},
+		{load("-- query: FindUser variant=fast\nSELECT 1;"), CodeNoDefaultVariant},
+		{load("-- query: FindUser a=1 a=2\nSELECT 1;", WithAttributes("a")), CodeConflict},
+		{load("-- query: FindUser\n-- assert: contains \"WHERE\"\nSELECT 1;"), CodeCheckFailed},
+		{load("-- query: FindUser\nSELECT 1;", WithMaxQuerySize(2)), CodeCheckFailed},
+		{load("-- query: FindUser\nSELECT 1;", WithTransform(func(name, sql string) (string, error) {
+			return "", errors.New("boom")
+		})), CodeExpansionFailed},
+		{load("-- query: FindUser\nSELECT 1;"), ""},
+		{errors.New("boom"), ""},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if got := ErrorCode(tc.err); got != tc.want {
+				t.Errorf("got %q, want %q (%v)", got, tc.want, tc.err)
+			}
+		})
+	}
+	_, report, err := LoadPartialFromString[Queries]("-- query: FindCat\nSELECT 1;")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if got := ErrorCode(report.Failures[0]); got != CodeMissingQuery {
+		t.Errorf("got %q, want %q", got, CodeMissingQuery)
+	}
+}
@@ -0,0 +1,131 @@
+package sqload
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Coverage records which queries of a QuerySet are run, like the tests of a package do
+// with its code, so the queries no test runs can be found. The statements are recorded
+// by the drivers returned by Driver, or by calling Record, and traced back to their
+// queries with NameOf:
+//
+//	var coverage *sqload.Coverage
+//
+//	func TestMain(m *testing.M) {
+//		qs, err := sqload.LoadQuerySet(sqlFiles)
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		coverage = sqload.NewCoverage(qs)
+//		sql.Register("covered-postgres", coverage.Driver(&pq.Driver{}))
+//		code := m.Run()
+//		fmt.Print(coverage.Report())
+//		os.Exit(code)
+//	}
+//
+// It is safe for concurrent use.
+type Coverage struct {
+	qs   *QuerySet
+	mu   sync.Mutex
+	runs map[string]int // times each query was run, by key
+}
+
+// NewCoverage returns a Coverage of the queries and variants of qs. The aliases are not
+// counted, since they are the same queries under other names.
+func NewCoverage(qs *QuerySet) *Coverage {
+	return &Coverage{qs: qs, runs: map[string]int{}}
+}
+
+// Driver returns a driver that runs the statements with the driver d and records them.
+// The statements that return rows are recorded when their rows are closed.
+func (c *Coverage) Driver(d driver.Driver) driver.Driver {
+	return wrapDriver(d, func(query string, _ time.Duration, _ int64, _ error) {
+		c.Record(query)
+	})
+}
+
+// Record records that the SQL code sql was run. It is only needed to record the
+// statements that do not go through a driver returned by Driver.
+func (c *Coverage) Record(sql string) {
+	keys := c.qs.keysOf(sql)
+	if len(keys) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		c.runs[key]++
+	}
+}
+
+// Report returns the coverage of the queries recorded so far.
+func (c *Coverage) Report() CoverageReport {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := CoverageReport{Runs: map[string]int{}, Untested: []string{}}
+	for name, q := range c.qs.queries {
+		if q.AliasOf != "" {
+			continue
+		}
+		keys := []string{name}
+		for variant := range c.qs.variants[name] {
+			keys = append(keys, variantKey(name, variant))
+		}
+		for _, key := range keys {
+			r.Total++
+			if n := c.runs[key]; n > 0 {
+				r.Used++
+				r.Runs[key] = n
+			} else {
+				r.Untested = append(r.Untested, key)
+			}
+		}
+	}
+	sort.Strings(r.Untested)
+	return r
+}
+
+// CoverageReport is the coverage of the queries of a QuerySet, see Coverage. Variants are
+// named Name:variant (see QuerySet.Select).
+type CoverageReport struct {
+	// Used is the number of queries run at least once.
+	Used int
+	// Total is the number of queries.
+	Total int
+	// Runs are the times each query was run, by name, for the queries run at least once.
+	Runs map[string]int
+	// Untested are the names of the queries never run, sorted alphabetically.
+	Untested []string
+}
+
+// Percent returns the percentage of the queries run at least once, or 100 if there are
+// no queries.
+func (r CoverageReport) Percent() float64 {
+	if r.Total == 0 {
+		return 100
+	}
+	return 100 * float64(r.Used) / float64(r.Total)
+}
+
+// String returns the report as text, with one untested query per line:
+//
+//	queries: 3/5 (60.0%)
+//	untested:
+//		DeleteUser
+//		FindUser:fast
+func (r CoverageReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "queries: %d/%d (%.1f%%)\n", r.Used, r.Total, r.Percent())
+	if len(r.Untested) > 0 {
+		b.WriteString("untested:\n")
+		for _, name := range r.Untested {
+			fmt.Fprintf(&b, "\t%s\n", name)
+		}
+	}
+	return b.String()
+}
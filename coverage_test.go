@@ -0,0 +1,79 @@
+package sqload
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCoverage(t *testing.T) {
+	queries := `-- query: FindUser
+SELECT * FROM user WHERE id = :id;
+
+-- query: FindUser variant=fast
+SELECT * FROM user_by_id WHERE id = :id;
+
+-- query: DeleteUser
+-- alias: RemoveUser
+DELETE FROM user WHERE id = :id;
+
+-- query: CountUsers
+SELECT count(*) FROM user;
+`
+	qs, err := LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte(queries)}}, WithDialect(DialectPostgres))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	coverage := NewCoverage(qs)
+	fakeDrivers.Lock()
+	fakeDrivers.n++
+	name := fmt.Sprintf("sqloadcovered%d", fakeDrivers.n)
+	fakeDrivers.Unlock()
+	sql.Register(name, coverage.Driver(&fakeDriver{}))
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if want := "queries: 0/4 (0.0%)\nuntested:\n\tCountUsers\n\tDeleteUser\n\tFindUser\n\tFindUser:fast\n"; coverage.Report().String() != want {
+		t.Errorf("got %q, want %q", coverage.Report().String(), want)
+	}
+	ctx := context.Background()
+	find, args, err := qs.Bind("FindUser", map[string]any{"id": 1})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	for i := 0; i < 2; i++ {
+		rows, err := db.QueryContext(ctx, find, args...)
+		if err != nil {
+			t.Fatalf("err must be nil, got %s", err)
+		}
+		rows.Close()
+	}
+	stmt, err := db.PrepareContext(ctx, "DELETE FROM user WHERE id = $1;")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if _, err := stmt.ExecContext(ctx, 1); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	stmt.Close()
+	if _, err := db.ExecContext(ctx, "UPDATE user SET name = 'x';"); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	coverage.Record("SELECT * FROM user_by_id WHERE id = :id;")
+
+	r := coverage.Report()
+	if want := "queries: 3/4 (75.0%)\nuntested:\n\tCountUsers\n"; r.String() != want {
+		t.Errorf("got %q, want %q", r.String(), want)
+	}
+	if want := "map[DeleteUser:1 FindUser:2 FindUser:fast:1]"; fmt.Sprint(r.Runs) != want {
+		t.Errorf("got %v, want %s", r.Runs, want)
+	}
+	if got := (CoverageReport{}).Percent(); got != 100 {
+		t.Errorf("got %f, want 100", got)
+	}
+}
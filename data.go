@@ -0,0 +1,83 @@
+package sqload
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+)
+
+// dataRefPattern matches the references to the values of the load-time data, like
+// {{.Schema}} or {{ .Schema }}, capturing the key.
+var dataRefPattern = regexp.MustCompile(`{{\s*\.([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+
+// LoadFromFSWithData is like LoadFromFS, but it replaces the references to the keys of
+// data written in the SQL code of the queries, like {{.Schema}}, with their values, so
+// the literals that change from an environment to another are set at load time:
+//
+//	-- query: FindUser
+//	SELECT * FROM {{.Schema}}.user WHERE id = :id;
+//
+//	q, err := sqload.LoadFromFSWithData[Queries](fsys, map[string]any{"Schema": "staging"})
+//
+// Unlike the templates (see WithTemplateData), the references are plain replacements with
+// no logic. The values are written as fmt.Print writes them, without quoting them, so
+// they must not come from the users of a program. If a query references a key that is
+// not in data, it will return an error, unless the templates are enabled, in which case
+// the reference is expanded by the templates.
+func LoadFromFSWithData[V Struct](fsys fs.FS, data map[string]any, opts ...Option) (*V, error) {
+	return LoadFromFS[V](fsys, append(opts, withData(data))...)
+}
+
+// MustLoadFromFSWithData is like LoadFromFSWithData but panics if any error occurs.
+func MustLoadFromFSWithData[V Struct](fsys fs.FS, data map[string]any, opts ...Option) *V {
+	v, err := LoadFromFSWithData[V](fsys, data, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// withData sets the data whose values replace the references to their keys in the
+// queries, see LoadFromFSWithData.
+func withData(data map[string]any) Option {
+	return func(cfg *config) {
+		if cfg.data == nil {
+			cfg.data = map[string]any{}
+		}
+		for key, value := range data {
+			cfg.data[key] = value
+		}
+	}
+}
+
+// substituteData replaces the references to the keys of the data of cfg in the SQL code
+// of every query with their values, and returns a new map with the results. When the
+// templates are enabled, the references to keys that are not in the data are left for
+// the templates to expand.
+func substituteData(queries map[string]string, cfg *config) (map[string]string, error) {
+	substituted := make(map[string]string, len(queries))
+	for _, name := range sortedKeys(queries) {
+		sql := queries[name]
+		if !strings.Contains(sql, "{{") {
+			substituted[name] = sql
+			continue
+		}
+		var missing string
+		substituted[name] = dataRefPattern.ReplaceAllStringFunc(sql, func(ref string) string {
+			key := dataRefPattern.FindStringSubmatch(ref)[1]
+			value, found := cfg.data[key]
+			if !found {
+				if missing == "" && !cfg.templates {
+					missing = key
+				}
+				return ref
+			}
+			return fmt.Sprint(value)
+		})
+		if missing != "" {
+			return nil, errorf(CodeExpansionFailed, "%w: query %s: missing value for {{.%s}}", ErrCannotLoadQueries, name, missing)
+		}
+	}
+	return substituted, nil
+}
@@ -0,0 +1,63 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"text/template"
+)
+
+func TestLoadFromFSWithData(t *testing.T) {
+	type UserQuery struct {
+		FindUser string `query:"FindUser"`
+	}
+	testCases := []struct {
+		sql     string
+		data    map[string]any
+		opts    []Option
+		want    string
+		wantErr error
+	}{
+		{
+			sql:  "-- query: FindUser\nSELECT * FROM {{.Schema}}.user WHERE id = :id LIMIT {{ .Limit }};",
+			data: map[string]any{"Schema": "staging", "Limit": 10},
+			want: "SELECT * FROM staging.user WHERE id = :id LIMIT 10;",
+		},
+		{
+			sql:  "-- query: FindUser\nSELECT * FROM user;",
+			data: nil,
+			want: "SELECT * FROM user;",
+		},
+		{
+			sql:     "-- query: FindUser\nSELECT * FROM {{.Schema}}.user WHERE region = '{{.Region}}';",
+			data:    map[string]any{"Schema": "staging"},
+			wantErr: fmt.Errorf("%w: query FindUser: missing value for {{.Region}}", ErrCannotLoadQueries),
+		},
+		{
+			sql:  "-- query: FindUser\nSELECT * FROM {{.Schema}}.user WHERE {{notDeleted}};",
+			data: map[string]any{"Schema": "staging"},
+			opts: []Option{WithTemplateFuncs(template.FuncMap{
+				"notDeleted": func() string { return "deleted_at IS NULL" },
+			})},
+			want: "SELECT * FROM staging.user WHERE deleted_at IS NULL;",
+		},
+		{
+			sql:  "-- query: FindUser\nSELECT * FROM {{.Schema}}.user WHERE region = '{{.Region}}';",
+			data: map[string]any{"Schema": "staging"},
+			opts: []Option{WithTemplateData(map[string]any{"Region": "eu"})},
+			want: "SELECT * FROM staging.user WHERE region = 'eu';",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			fsys := fstest.MapFS{"users.sql": {Data: []byte(tc.sql)}}
+			q, err := LoadFromFSWithData[UserQuery](fsys, tc.data, tc.opts...)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if err == nil && q.FindUser != tc.want {
+				t.Errorf("got %s, want %s", q.FindUser, tc.want)
+			}
+		})
+	}
+}
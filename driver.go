@@ -0,0 +1,296 @@
+package sqload
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"time"
+)
+
+// observer receives the statements run through a driver wrapped by wrapDriver, once they
+// have run: their SQL code, the time they took, the number of rows they returned or
+// affected (-1 if it is not known) and their error, if any. The statements that return
+// rows are reported when their rows are closed.
+type observer func(query string, duration time.Duration, rows int64, err error)
+
+// wrapDriver returns a driver that runs the statements with the driver d and passes them
+// to observe.
+func wrapDriver(d driver.Driver, observe observer) driver.Driver {
+	return &observedDriver{Driver: d, observe: observe}
+}
+
+type observedDriver struct {
+	driver.Driver
+	observe observer
+}
+
+func (d *observedDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &observedConn{Conn: conn, observe: d.observe}, nil
+}
+
+// observedConn is a connection of an observedDriver. It implements the optional
+// interfaces of the connections by forwarding them to the wrapped connection when it
+// implements them, and by doing what database/sql would do otherwise.
+type observedConn struct {
+	driver.Conn
+	observe observer
+}
+
+func (c *observedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *observedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &observedStmt{Stmt: stmt, query: query, observe: c.observe}, nil
+}
+
+func (c *observedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := e.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.observe(query, time.Since(start), rowsAffected(result, err), err)
+	}
+	return result, err
+}
+
+func (c *observedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, query, args)
+	if err == driver.ErrSkip {
+		return nil, err
+	}
+	return observeRows(rows, err, query, start, c.observe)
+}
+
+func (c *observedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != 0 || opts.ReadOnly {
+		return nil, errors.New("the driver does not support transaction options")
+	}
+	return c.Conn.Begin()
+}
+
+func (c *observedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *observedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *observedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *observedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// observedStmt is a prepared statement of an observedConn.
+type observedStmt struct {
+	driver.Stmt
+	query   string
+	observe observer
+}
+
+func (s *observedStmt) Exec(args []driver.Value) (driver.Result, error) {
+	start := time.Now()
+	result, err := s.Stmt.Exec(args)
+	s.observe(s.query, time.Since(start), rowsAffected(result, err), err)
+	return result, err
+}
+
+func (s *observedStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := s.Stmt.Query(args)
+	return observeRows(rows, err, s.query, start, s.observe)
+}
+
+func (s *observedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Exec(values)
+	}
+	start := time.Now()
+	result, err := e.ExecContext(ctx, args)
+	s.observe(s.query, time.Since(start), rowsAffected(result, err), err)
+	return result, err
+}
+
+func (s *observedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		values, err := namedValues(args)
+		if err != nil {
+			return nil, err
+		}
+		return s.Query(values)
+	}
+	start := time.Now()
+	rows, err := q.QueryContext(ctx, args)
+	return observeRows(rows, err, s.query, start, s.observe)
+}
+
+func (s *observedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// namedValues returns the values of args for the drivers that do not support named
+// arguments.
+func namedValues(args []driver.NamedValue) ([]driver.Value, error) {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		if arg.Name != "" {
+			return nil, errors.New("the driver does not support named arguments")
+		}
+		values[i] = arg.Value
+	}
+	return values, nil
+}
+
+// rowsAffected returns the number of rows affected by a statement that returned result
+// and err, or -1 if it is not known.
+func rowsAffected(result driver.Result, err error) int64 {
+	if err != nil || result == nil {
+		return -1
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// observeRows returns the rows returned by the statement query, started at start, so it
+// is passed to observe once they are closed. If the statement failed with err, it is
+// passed to observe at once.
+func observeRows(rows driver.Rows, err error, query string, start time.Time, observe observer) (driver.Rows, error) {
+	if err != nil {
+		observe(query, time.Since(start), -1, err)
+		return nil, err
+	}
+	return &observedRows{Rows: rows, query: query, start: start, observe: observe}, nil
+}
+
+// observedRows are the rows returned by a statement of an observedConn. They count the
+// rows read and report the statement once they are closed. Like observedConn, they
+// implement the optional interfaces of the rows.
+type observedRows struct {
+	driver.Rows
+	query   string
+	start   time.Time
+	observe observer
+	count   int64
+	err     error
+}
+
+func (r *observedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.count++
+	case err != io.EOF && r.err == nil:
+		r.err = err
+	}
+	return err
+}
+
+func (r *observedRows) Close() error {
+	err := r.Rows.Close()
+	r.observe(r.query, time.Since(r.start), r.count, r.err)
+	return err
+}
+
+func (r *observedRows) HasNextResultSet() bool {
+	if s, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return s.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *observedRows) NextResultSet() error {
+	if s, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return s.NextResultSet()
+	}
+	return io.EOF
+}
+
+func (r *observedRows) ColumnTypeScanType(index int) reflect.Type {
+	if t, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return t.ColumnTypeScanType(index)
+	}
+	return reflect.TypeOf(new(any)).Elem()
+}
+
+func (r *observedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if t, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return t.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *observedRows) ColumnTypeLength(index int) (int64, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return t.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *observedRows) ColumnTypeNullable(index int) (bool, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return t.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *observedRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if t, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return t.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,49 @@
+//go:build !sqload_noos
+
+package sqload
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// CheckEmbed returns the .sql files of the directory dir of the operating system that are
+// missing from the embedded file system, sorted by path. A go:embed pattern like
+// sql/*.sql does not match the files of new subdirectories, so they are silently left out
+// of the binary; call it from a test to catch them:
+//
+//	//go:embed sql/*.sql
+//	var sqlFiles embed.FS
+//
+//	func TestEmbed(t *testing.T) {
+//		missing, err := sqload.CheckEmbed(sqlFiles, ".")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		for _, file := range missing {
+//			t.Errorf("%s is not embedded", file)
+//		}
+//	}
+//
+// The paths are slash-separated and relative to dir, and embedded must be rooted at the
+// same directory: pass the directory of the package for an embed.FS, or use fs.Sub and
+// the matching subdirectory.
+func CheckEmbed(embedded fs.FS, dir string) ([]string, error) {
+	files, err := findFilesWithExt(os.DirFS(dir), ".sql")
+	if err != nil {
+		return nil, err
+	}
+	missing := []string{}
+	for _, file := range files {
+		_, err := fs.Stat(embedded, file)
+		if errors.Is(err, fs.ErrNotExist) {
+			missing = append(missing, file)
+			continue
+		}
+		if err != nil {
+			return nil, errorf(CodeUnreadable, "%w: %s", ErrCannotLoadQueries, err)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,46 @@
+//go:build !sqload_noos
+
+package sqload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCheckEmbed(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"sql/users.sql", "sql/orders/place.sql", "sql/orders/cancel.sql", "main.go"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("-- query: Ping\nSELECT 1;\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	embedded := fstest.MapFS{
+		"sql/users.sql": {Data: []byte("-- query: Ping\nSELECT 1;\n")},
+	}
+	missing, err := CheckEmbed(embedded, dir)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := "[sql/orders/cancel.sql sql/orders/place.sql]"; fmt.Sprint(missing) != want {
+		t.Errorf("got %v, want %s", missing, want)
+	}
+	embedded["sql/orders/cancel.sql"] = &fstest.MapFile{}
+	embedded["sql/orders/place.sql"] = &fstest.MapFile{}
+	missing, err = CheckEmbed(embedded, dir)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if len(missing) != 0 {
+		t.Errorf("got %v, want no missing files", missing)
+	}
+	if _, err := CheckEmbed(embedded, filepath.Join(dir, "i-dont-exist")); ErrorCode(err) != CodeUnreadable {
+		t.Errorf("got %v, want an error with code %s", err, CodeUnreadable)
+	}
+}
@@ -0,0 +1,66 @@
+package sqload
+
+import (
+	"sort"
+	"strings"
+)
+
+// ParseEvents are the functions called while the Load functions parse the queries, see
+// WithParseEvents. Any of them can be nil.
+type ParseEvents struct {
+	// OnFile is called with the path of every file before it is parsed.
+	OnFile func(path string)
+	// OnQuery is called with every query found, in the order they are written, along with
+	// the file it was read from, or an empty string if it was not read from a file, and
+	// the line of its query comment. The query has its name, variant, SQL code, kind,
+	// parameters, doc comment, tags, annotations and attributes; the names given to the
+	// queries of a directory by WithDirNamespaces are not added yet.
+	OnQuery func(file string, line int, q Query)
+	// OnWarning is called with every problem that does not make the load fail, the same
+	// ones that are added to the report set with WithReport.
+	OnWarning func(err error)
+}
+
+// emitFile calls the OnFile event of cfg, if any, with the path of a file.
+func (cfg *config) emitFile(path string) {
+	if cfg.events.OnFile != nil {
+		cfg.events.OnFile(path)
+	}
+}
+
+// emitQueries calls the OnQuery event of cfg, if any, with the queries parsed, sorted by
+// their position.
+func (cfg *config) emitQueries(queries map[string]string, headers map[string]header) {
+	if cfg.events.OnQuery == nil {
+		return
+	}
+	keys := make([]string, 0, len(queries))
+	for key := range queries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := headers[keys[i]], headers[keys[j]]
+		if a.order != b.order {
+			return a.order < b.order
+		}
+		return keys[i] < keys[j]
+	})
+	for _, key := range keys {
+		sql, h := queries[key], headers[key]
+		name, variant, _ := strings.Cut(key, variantSeparator)
+		// An invalid kind annotation falls back to the classified kind
+		kind, _ := queryKind(sql, h.annotations, cfg.dialect)
+		cfg.events.OnQuery(h.file, h.line, Query{
+			Name:        name,
+			Variant:     variant,
+			SQL:         sql,
+			Kind:        kind,
+			Params:      ExtractParams(sql, cfg.dialect, cfg.paramStyles...),
+			Doc:         h.doc,
+			Tags:        h.annotations.List("tags"),
+			Attributes:  h.attrs,
+			Annotations: h.annotations,
+			order:       h.order,
+		})
+	}
+}
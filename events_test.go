@@ -0,0 +1,90 @@
+//go:build !sqload_noos
+
+package sqload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithParseEvents(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("-- query: FindUser owner=users\n-- Finds a user.\nSELECT * FROM user WHERE id = :id;\n\n-- query: DeleteUser, RemoveUser\nDELETE FROM user WHERE id = :id;\n")},
+		"cats.sql":  {Data: []byte("-- query: FindCat\nSELECT * FROM cat;\n")},
+	}
+	var events []string
+	record := WithParseEvents(ParseEvents{
+		OnFile: func(path string) {
+			events = append(events, "file "+path)
+		},
+		OnQuery: func(file string, line int, q Query) {
+			events = append(events, fmt.Sprintf("query %s:%d %s %v %v %q", file, line, q.Name, q.Kind, q.Params, q.Doc))
+		},
+	})
+	want := []string{
+		`file cats.sql`,
+		`query cats.sql:1 FindCat dml [] ""`,
+		`file users.sql`,
+		`query users.sql:1 FindUser dml [id] "Finds a user."`,
+		`query users.sql:5 DeleteUser dml [id] ""`,
+		`query users.sql:5 RemoveUser dml [id] ""`,
+	}
+	if _, err := LoadQuerySet(fsys, record, WithAttributes("owner")); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", events, want)
+	}
+
+	events = nil
+	if _, err := LoadFromString[map[string]string]("-- query: FindCat\nSELECT * FROM cat;\n", record); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := `[query :1 FindCat dml [] ""]`; fmt.Sprint(events) != want {
+		t.Errorf("got %q, want %s", events, want)
+	}
+
+	events = nil
+	if _, err := LoadFromString[map[string]string]("-- query: Migrate\n-- kind: ddl\nSELECT migrate();\n", record); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := `[query :1 Migrate ddl [] ""]`; fmt.Sprint(events) != want {
+		t.Errorf("got %q, want %s", events, want)
+	}
+
+	dir := t.TempDir()
+	for name, file := range fsys {
+		if err := os.WriteFile(filepath.Join(dir, name), file.Data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	events = nil
+	if _, err := LoadFromDir[map[string]string](dir, record, WithMmap(), WithAttributes("owner")); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(events) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", events, want)
+	}
+
+	// Permission-based tests do not work on Windows
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if err := os.Chmod(filepath.Join(dir, "cats.sql"), 0o222); err != nil {
+		t.Fatal(err)
+	}
+	var warnings []error
+	_, err := LoadFromDir[map[string]string](dir, WithSkipUnreadable(), WithParseEvents(ParseEvents{
+		OnWarning: func(err error) { warnings = append(warnings, err) },
+	}), WithAttributes("owner"))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if len(warnings) != 1 || !os.IsPermission(warnings[0]) {
+		t.Errorf("got %v, want a permission error", warnings)
+	}
+}
@@ -0,0 +1,178 @@
+package sqload
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is a database/sql driver that records the statements it receives instead
+// of running them. Statements containing the word FAIL return an error.
+type fakeDriver struct {
+	mu    sync.Mutex
+	conns int
+	log   []string
+}
+
+var fakeDrivers = struct {
+	sync.Mutex
+	n int
+}{}
+
+// openFakeDB registers a new fakeDriver and returns it with a *sql.DB using it.
+func openFakeDB(t *testing.T) (*fakeDriver, *sql.DB) {
+	fakeDrivers.Lock()
+	fakeDrivers.n++
+	name := fmt.Sprintf("sqloadfake%d", fakeDrivers.n)
+	fakeDrivers.Unlock()
+	d := &fakeDriver{}
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("unable to open the fake database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return d, db
+}
+
+// record logs the event of the connection conn.
+func (d *fakeDriver) record(conn int, event string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, fmt.Sprintf("%d: %s", conn, event))
+}
+
+// Log returns the events recorded so far.
+func (d *fakeDriver) Log() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.log...)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.conns++
+	return &fakeConn{driver: d, id: d.conns}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	id     int
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	c.driver.record(c.id, "PREPARE "+query)
+	return &fakeStmt{conn: c, query: query}, nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	event := "BEGIN"
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		event += " ISOLATION LEVEL " + sql.IsolationLevel(opts.Isolation).String()
+	}
+	c.driver.record(c.id, event)
+	return &fakeTx{conn: c}, nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.record(c.id, query)
+	if strings.Contains(query, "FAIL") {
+		return nil, errors.New("statement failed")
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.record(c.id, query)
+	if strings.Contains(query, "FAIL") {
+		return nil, errors.New("statement failed")
+	}
+	return &fakeRows{sets: strings.Count(query, ";") - 1}, nil
+}
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.conn.driver.record(tx.conn.id, "COMMIT")
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.conn.driver.record(tx.conn.id, "ROLLBACK")
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, nil)
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, nil)
+}
+
+// fakeRows returns a single row with a single column in each of its result sets, one per
+// semicolon of the query.
+type fakeRows struct {
+	done bool
+	sets int // result sets left after the current one
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"n"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func (r *fakeRows) HasNextResultSet() bool {
+	return r.sets > 0
+}
+
+func (r *fakeRows) NextResultSet() error {
+	if r.sets <= 0 {
+		return io.EOF
+	}
+	r.sets--
+	r.done = false
+	return nil
+}
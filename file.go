@@ -0,0 +1,200 @@
+//go:build !sqload_noos
+
+package sqload
+
+import "os"
+
+// extractQueriesFromFile extracts the queries of the file filename, along with their
+// headers.
+func extractQueriesFromFile(filename string, cfg *config) (map[string]string, map[string]header, error) {
+	cfg.emitFile(filename)
+	var queries map[string]string
+	var headers map[string]header
+	if cfg.mmap {
+		var err error
+		if queries, headers, err = parseMappedFile(filename, filename, cfg); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return nil, nil, errorf(CodeUnreadable, "%w: %s", ErrCannotLoadQueries, err)
+		}
+		data, err = preprocess(filename, data, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		queries, headers, err = parseQueries(string(data), filename, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if info, err := os.Stat(filename); err == nil {
+		setFileInfo(headers, info)
+	}
+	if err := checkVariants(queries); err != nil {
+		return nil, nil, err
+	}
+	return queries, headers, nil
+}
+
+// ExtractQueryMapFromFile is like ExtractQueryMap but it extracts the queries from the
+// file filename. The errors found while parsing it point to the line of the file they
+// were found in.
+//
+// Only the options in opts that affect the parsing, like WithPreprocessor and
+// WithKeepComments, have an effect.
+func ExtractQueryMapFromFile(filename string, opts ...Option) (map[string]string, error) {
+	queries, _, err := extractQueriesFromFile(filename, newConfig(opts))
+	return withoutVariants(queries), err
+}
+
+// LoadFromFile loads the SQL code from the file filename and returns a pointer to a
+// struct. Each struct field will contain the SQL query code it was tagged with.
+//
+// If some query has an invalid name in the string or is not found in the string, it
+// will return a nil pointer and an error.
+//
+// If the file can not be read or does not exist, it will return a nil pointer and an
+// error.
+//
+// File queries.sql:
+//
+//	-- query: FindUserById
+//	SELECT first_name,
+//	       last_name,
+//	       dob,
+//	       email
+//	  FROM user
+//	 WHERE id = :id;
+//
+//	-- query: UpdateFirstNameById
+//	UPDATE user
+//	   SET first_name = 'Ernesto'
+//	 WHERE id = :id;
+//
+//	-- query: DeleteUserById
+//	DELETE FROM user
+//	      WHERE id = :id;
+//
+// File main.go:
+//
+//	package main
+//
+//	import (
+//		"fmt"
+//		"os"
+//
+//		"github.com/midir99/sqload"
+//	)
+//
+//	func main() {
+//		q, err := sqload.LoadFromFile[struct {
+//			FindUserById        string `query:"FindUserById"`
+//			UpdateFirstNameById string `query:"UpdateFirstNameById"`
+//			DeleteUserById      string `query:"DeleteUserById"`
+//		}]("queries.sql")
+//		if err != nil {
+//			fmt.Printf("Unable to load SQL queries: %s\n", err)
+//			os.Exit(1)
+//		}
+//		fmt.Printf("- FindUserById\n%s\n\n", q.FindUserById)
+//		fmt.Printf("- UpdateFirstNameById\n%s\n\n", q.UpdateFirstNameById)
+//		fmt.Printf("- DeleteUserById\n%s\n\n", q.DeleteUserById)
+//	}
+func LoadFromFile[V Struct](filename string, opts ...Option) (*V, error) {
+	cfg := newConfig(opts)
+	queries, headers, err := extractQueriesFromFile(filename, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return loadFromQueryMap[V](queries, headers, cfg)
+}
+
+// MustLoadFromFile is like LoadFromFile but panics if any error occurs. It simplifies
+// the safe initialization of global variables holding struct pointers containing SQL
+// queries.
+func MustLoadFromFile[V Struct](filename string, opts ...Option) *V {
+	v, err := LoadFromFile[V](filename, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// LoadFromDir loads the SQL code from all the .sql files in the directory dirname
+// (recursively) and returns a pointer to a struct. Each struct field will contain the
+// SQL query code it was tagged with.
+//
+// If some query has an invalid name in the string or is not found in the string, it
+// will return a nil pointer and an error.
+//
+// If the directory can not be read or does not exist, it will return a nil pointer and
+// an error.
+//
+// If any .sql file can not be read, it will return a nil pointer and an error.
+//
+// Project directory:
+//
+//	.
+//	├── go.mod
+//	├── main.go
+//	└── sql
+//	    ├── cats.sql
+//	    └── users.sql
+//
+// File sql/cats.sql:
+//
+//	-- query: CreatePsychoCat
+//	INSERT INTO Cat (name, color) VALUES ('Puca', 'Orange');
+//
+// File sql/users.sql:
+//
+//	-- query: DeleteUserById
+//	DELETE FROM user WHERE id = :id;
+//
+// File main.go:
+//
+//	package main
+//
+//	import (
+//		"fmt"
+//		"os"
+//
+//		"github.com/midir99/sqload"
+//	)
+//
+//	func main() {
+//		q, err := sqload.LoadFromDir[struct {
+//			CreatePsychoCat string `query:"CreatePsychoCat"`
+//			DeleteUserById  string `query:"DeleteUserById"`
+//		}]("sql")
+//		if err != nil {
+//			fmt.Printf("Unable to load SQL queries: %s\n", err)
+//			os.Exit(1)
+//		}
+//		fmt.Printf("- CreatePsychoCat\n%s\n\n", q.CreatePsychoCat)
+//		fmt.Printf("- DeleteUserById\n%s\n\n", q.DeleteUserById)
+//	}
+func LoadFromDir[V Struct](dirname string, opts ...Option) (*V, error) {
+	fsys := os.DirFS(dirname)
+	cfg := newConfig(opts)
+	cfg.dir = dirname
+	queries, headers, err := extractQueriesFromFS(fsys, cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.dialectQueries = dialectSource(fsys, cfg)
+	return loadFromQueryMap[V](queries, headers, cfg)
+}
+
+// MustLoadFromDir is like LoadFromDir but panics if any error occurs. It simplifies the
+// safe initialization of global variables holding struct pointers containing SQL
+// queries.
+func MustLoadFromDir[V Struct](dirname string, opts ...Option) *V {
+	v, err := LoadFromDir[V](dirname, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,368 @@
+//go:build !sqload_noos
+
+package sqload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFromFile(t *testing.T) {
+	type CatQuery struct {
+		CreateCatTable  string `query:"CreateCatTable"`
+		CreatePsychoCat string `query:"CreatePsychoCat"`
+		CreateNormalCat string `query:"CreateNormalCat"`
+		UpdateColorById string `query:"UpdateColorById"`
+	}
+	_, err := LoadFromFile[CatQuery]("testdata/i-dont-exist.sql")
+	if err == nil {
+		t.Fatalf("file testdata/i-dont-exist.sql must not exists so this test can fail")
+	}
+	// test using LF line endings
+	catQuery, err := LoadFromFile[CatQuery]("testdata/cat-queries.sql")
+	if err != nil {
+		t.Fatalf("error loading testdata/cat-queries.sql: %s", err)
+	}
+	if catQuery.CreateCatTable != CatTestQueries["CreateCatTable"] {
+		t.Errorf("got %s, want %s", catQuery.CreateCatTable, CatTestQueries["CreateCatTable"])
+	}
+	if catQuery.CreatePsychoCat != CatTestQueries["CreatePsychoCat"] {
+		t.Errorf("got %s, want %s", catQuery.CreatePsychoCat, CatTestQueries["CreatePsychoCat"])
+	}
+	if catQuery.CreateNormalCat != CatTestQueries["CreateNormalCat"] {
+		t.Errorf("got %s, want %s", catQuery.CreateNormalCat, CatTestQueries["CreateNormalCat"])
+	}
+	if catQuery.UpdateColorById != CatTestQueries["UpdateColorById"] {
+		t.Errorf("got %s, want %s", catQuery.UpdateColorById, CatTestQueries["UpdateColorById"])
+	}
+	// test using CRLF line endings
+	catQuery, err = LoadFromFile[CatQuery]("testdata/cat-queries.crlf.sql")
+	if err != nil {
+		t.Fatalf("error loading testdata/cat-queries.sql: %s", err)
+	}
+	if catQuery.CreateCatTable != CatTestQueries["CreateCatTable"] {
+		t.Errorf("got %s, want %s", catQuery.CreateCatTable, CatTestQueries["CreateCatTable"])
+	}
+	if catQuery.CreatePsychoCat != CatTestQueries["CreatePsychoCat"] {
+		t.Errorf("got %s, want %s", catQuery.CreatePsychoCat, CatTestQueries["CreatePsychoCat"])
+	}
+	if catQuery.CreateNormalCat != CatTestQueries["CreateNormalCat"] {
+		t.Errorf("got %s, want %s", catQuery.CreateNormalCat, CatTestQueries["CreateNormalCat"])
+	}
+	if catQuery.UpdateColorById != CatTestQueries["UpdateColorById"] {
+		t.Errorf("got %s, want %s", catQuery.UpdateColorById, CatTestQueries["UpdateColorById"])
+	}
+}
+
+func TestMustLoadFromFile(t *testing.T) {
+	// Test that the function panics if any error occurs
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("function did not panic")
+			}
+		}()
+		MustLoadFromFile[struct{}]("testdata/i-dont-exist.sql")
+	}()
+	// Test that the function does not panic if no errors occur
+	MustLoadFromFile[struct{}]("testdata/cat-queries.sql")
+}
+
+func TestLoadFromDir(t *testing.T) {
+	type RandomQuery struct {
+		CreateCatTable      string `query:"CreateCatTable"`
+		CreatePsychoCat     string `query:"CreatePsychoCat"`
+		CreateNormalCat     string `query:"CreateNormalCat"`
+		UpdateColorById     string `query:"UpdateColorById"`
+		FindUserById        string `query:"FindUserById"`
+		UpdateFirstNameById string `query:"UpdateFirstNameById"`
+		DeleteUserById      string `query:"DeleteUserById"`
+		FindRiders          string `query:"FindRiders"`
+	}
+	// Test that the function fails when the directory does not exist
+	_, err := LoadFromDir[RandomQuery]("testdata/i-dont-exist")
+	if err == nil {
+		t.Fatalf("dir testdata/i-dont-exist must not exists so this test can fail")
+	}
+
+	// Permission-based tests do not work on Windows
+	if runtime.GOOS != "windows" {
+		// Test that the function fails when it can not read some .sql file
+		unreadableFilename := "testdata/test-load-from-dir/unreadable-file.sql"
+		unreadableFile, err := os.Create(unreadableFilename)
+		if err != nil {
+			t.Fatalf("unable to create %s: %s", unreadableFilename, err)
+		}
+		defer unreadableFile.Close()
+		err = os.Chmod(unreadableFilename, 0222)
+		if err != nil {
+			t.Fatalf("unable to set the permissions of %s to 0222: %s", unreadableFilename, err)
+		}
+		_, err = LoadFromDir[RandomQuery]("testdata/test-load-from-dir")
+		if err == nil {
+			t.Fatal("error is nil")
+		}
+		err = os.Remove(unreadableFilename)
+		if err != nil {
+			t.Fatalf("unable to remove %s: %s", unreadableFilename, err)
+		}
+	}
+	// Test that the function succeeds when using the happy path
+	queries, err := LoadFromDir[RandomQuery]("testdata/test-load-from-dir")
+	if err != nil {
+		t.Fatalf("error loading testdata/test-load-from-dir: %s", err)
+	}
+	if queries.CreateCatTable != CatTestQueries["CreateCatTable"] {
+		t.Errorf("got %s, want %s", queries.CreateCatTable, CatTestQueries["CreateCatTable"])
+	}
+	if queries.CreatePsychoCat != CatTestQueries["CreatePsychoCat"] {
+		t.Errorf("got %s, want %s", queries.CreatePsychoCat, CatTestQueries["CreatePsychoCat"])
+	}
+	if queries.CreateNormalCat != CatTestQueries["CreateNormalCat"] {
+		t.Errorf("got %s, want %s", queries.CreateNormalCat, CatTestQueries["CreateNormalCat"])
+	}
+	if queries.UpdateColorById != CatTestQueries["UpdateColorById"] {
+		t.Errorf("got %s, want %s", queries.UpdateColorById, CatTestQueries["UpdateColorById"])
+	}
+	if queries.FindUserById != UserTestQueries["FindUserById"] {
+		t.Errorf("got %s, want %s", queries.FindUserById, UserTestQueries["FindUserById"])
+	}
+	if queries.UpdateFirstNameById != UserTestQueries["UpdateFirstNameById"] {
+		t.Errorf("got %s, want %s", queries.UpdateFirstNameById, UserTestQueries["UpdateFirstNameById"])
+	}
+	if queries.DeleteUserById != UserTestQueries["DeleteUserById"] {
+		t.Errorf("got %s, want %s", queries.DeleteUserById, UserTestQueries["DeleteUserById"])
+	}
+	if queries.FindRiders != RiderTestQueries["FindRiders"] {
+		t.Errorf("got %s, want %s", queries.FindRiders, RiderTestQueries["FindRiders"])
+	}
+}
+
+func TestMustLoadFromDir(t *testing.T) {
+	// Test that the function panics if any error occurs
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("function did not panic")
+			}
+		}()
+		MustLoadFromDir[struct{}]("testdata/i-dont-exist")
+	}()
+	// Test that the function does not panic if no errors occur
+	MustLoadFromDir[struct{}]("testdata/test-load-from-dir")
+}
+
+func TestLoadPostgresFunctions(t *testing.T) {
+	q, err := LoadFromFile[struct {
+		CreateIncFunction string `query:"CreateIncFunction"`
+		NotifyCats        string `query:"NotifyCats"`
+	}]("testdata/pg-functions.sql")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedIncFunction := strings.TrimSpace(`
+CREATE OR REPLACE FUNCTION inc(i integer) RETURNS integer AS $$
+BEGIN
+    -- Increments i by one; nothing else.
+    RETURN i + 1;
+END;
+$$ LANGUAGE plpgsql;
+`)
+	if q.CreateIncFunction != wantedIncFunction {
+		t.Errorf("got %s, want %s", q.CreateIncFunction, wantedIncFunction)
+	}
+	wantedNotifyCats := strings.TrimSpace(`
+DO $body$
+BEGIN
+    -- Say hello to the cats.
+    RAISE NOTICE 'meow';
+END
+$body$;
+`)
+	if q.NotifyCats != wantedNotifyCats {
+		t.Errorf("got %s, want %s", q.NotifyCats, wantedNotifyCats)
+	}
+}
+
+func TestExtractQueryMapFromFile(t *testing.T) {
+	queries, err := ExtractQueryMapFromFile("testdata/cat-queries.sql")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(queries) != fmt.Sprint(CatTestQueries) {
+		t.Errorf("got %v, want %v", queries, CatTestQueries)
+	}
+	filename := filepath.Join(t.TempDir(), "bad.sql")
+	if err := os.WriteFile(filename, []byte("-- query: FindCat\nSELECT 1;\n\n-- query: find-dog\nSELECT 2;"), 0644); err != nil {
+		t.Fatalf("unable to write %s: %s", filename, err)
+	}
+	_, err = ExtractQueryMapFromFile(filename)
+	want := fmt.Errorf("%w: invalid query name find-dog (%s:4)", ErrCannotLoadQueries, filename)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %s", err, want)
+	}
+	if _, err := ExtractQueryMapFromFile("testdata/i-dont-exist.sql"); !errors.Is(err, ErrCannotLoadQueries) {
+		t.Errorf("err must be ErrCannotLoadQueries, got %v", err)
+	}
+}
+
+func TestLoadFromFileWithPreprocessor(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cat.sql")
+	if err := os.WriteFile(filename, []byte("-- query: FindCat\nSELECT * FROM %TABLE%;"), 0644); err != nil {
+		t.Fatalf("unable to write %s: %s", filename, err)
+	}
+	var got string
+	q, err := LoadFromFile[struct {
+		FindCat string `query:"FindCat"`
+	}](filename, WithPreprocessor(func(path string, data []byte) ([]byte, error) {
+		got = path
+		return bytes.ReplaceAll(data, []byte("%TABLE%"), []byte("cat")), nil
+	}))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.FindCat != "SELECT * FROM cat;" {
+		t.Errorf("got %s, want %s", q.FindCat, "SELECT * FROM cat;")
+	}
+	if got != filename {
+		t.Errorf("got %s, want %s", got, filename)
+	}
+}
+
+func TestLoadWithRegistered(t *testing.T) {
+	defer resetRegistry()
+	type RegisteredQuery struct {
+		Ping            string `query:"Ping"`
+		CreatePsychoCat string `query:"CreatePsychoCat"`
+	}
+	if err := Register("Ping", "SELECT 1;"); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if err := Register("CreatePsychoCat", "SELECT 2;"); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	// Registered queries are ignored unless they are requested
+	_, err := LoadFromFile[RegisteredQuery]("testdata/cat-queries.sql")
+	want := fmt.Errorf("%w: could not find query Ping", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Fatalf("got %s, want %s", err, want)
+	}
+	q, err := LoadFromFile[RegisteredQuery]("testdata/cat-queries.sql", WithRegistered())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.Ping != "SELECT 1;" {
+		t.Errorf("got %s, want %s", q.Ping, "SELECT 1;")
+	}
+	// The queries from the source take precedence over the registered ones
+	if q.CreatePsychoCat != CatTestQueries["CreatePsychoCat"] {
+		t.Errorf("got %s, want %s", q.CreatePsychoCat, CatTestQueries["CreatePsychoCat"])
+	}
+}
+
+func TestLookup(t *testing.T) {
+	defer resetRegistry()
+	type CatQuery struct {
+		CreatePsychoCat string `query:"CreatePsychoCat"`
+	}
+	if _, found := Lookup("CreatePsychoCat"); found {
+		t.Fatal("query CreatePsychoCat must not be in the registry yet")
+	}
+	// Loads only publish their queries when they are asked to
+	MustLoadFromFile[CatQuery]("testdata/cat-queries.sql")
+	if _, found := Lookup("CreatePsychoCat"); found {
+		t.Fatal("query CreatePsychoCat must not be in the registry yet")
+	}
+	// Loads that fail do not publish anything
+	_, err := LoadFromFile[struct {
+		Missing string `query:"Missing"`
+	}]("testdata/cat-queries.sql", WithPublish())
+	if err == nil {
+		t.Fatal("err is nil")
+	}
+	if _, found := Lookup("CreatePsychoCat"); found {
+		t.Fatal("query CreatePsychoCat must not be in the registry yet")
+	}
+	MustLoadFromFile[CatQuery]("testdata/cat-queries.sql", WithPublish())
+	// Every query of the source is published, not only the bound ones
+	for name, wantedSql := range CatTestQueries {
+		sql, found := Lookup(name)
+		if !found {
+			t.Fatalf("query %s not found", name)
+		}
+		if sql != wantedSql {
+			t.Errorf("got %s, want %s", sql, wantedSql)
+		}
+	}
+	if err := Register("Ping", "SELECT 1;"); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if sql, _ := Lookup("Ping"); sql != "SELECT 1;" {
+		t.Errorf("got %s, want %s", sql, "SELECT 1;")
+	}
+}
+
+func TestWithSkipUnreadable(t *testing.T) {
+	// Permission-based tests do not work on Windows
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not supported")
+	}
+	dir := t.TempDir()
+	files := map[string]string{
+		"users.sql":          "-- query: FindUser\nSELECT * FROM user;\n",
+		"secret.sql":         "-- query: FindSecret\nSELECT * FROM secret;\n",
+		"private/orders.sql": "-- query: FindOrder\nSELECT * FROM \"order\";\n",
+	}
+	for name, sql := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(sql), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chmod(filepath.Join(dir, "secret.sql"), 0o222); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(filepath.Join(dir, "private"), 0o111); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chmod(filepath.Join(dir, "private"), 0o755)
+
+	if _, err := LoadFromDir[map[string]string](dir); err == nil {
+		t.Fatal("err is nil")
+	}
+	for _, mmap := range []bool{false, true} {
+		var report LoadReport
+		opts := []Option{WithSkipUnreadable(), WithReport(&report)}
+		if mmap {
+			opts = append(opts, WithMmap())
+		}
+		queries, err := LoadFromDir[map[string]string](dir, opts...)
+		if err != nil {
+			t.Fatalf("err must be nil, got %s", err)
+		}
+		if want := "map[FindUser:SELECT * FROM user;]"; fmt.Sprint(*queries) != want {
+			t.Errorf("got %v, want %s", *queries, want)
+		}
+		if len(report.Warnings) != 2 {
+			t.Fatalf("got %v, want 2 warnings", report.Warnings)
+		}
+		for i, want := range []string{"private", "secret.sql"} {
+			if !os.IsPermission(report.Warnings[i]) {
+				t.Errorf("got %s, want a permission error for %s", report.Warnings[i], want)
+			}
+		}
+	}
+	_, err := LoadFromDir[map[string]string](filepath.Join(dir, "i-dont-exist"), WithSkipUnreadable())
+	if err == nil {
+		t.Error("err is nil, a missing root must not be skipped")
+	}
+}
@@ -0,0 +1,179 @@
+package sqload
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var filterOperators = map[string]string{
+	"eq":    "=",
+	"ne":    "<>",
+	"lt":    "<",
+	"lte":   "<=",
+	"gt":    ">",
+	"gte":   ">=",
+	"like":  "LIKE",
+	"ilike": "ILIKE",
+	"in":    "IN",
+	"null":  "IS NULL",
+}
+
+// BuildWhere converts the filter struct filter into the conditions of a WHERE clause,
+// joined by AND, with a named parameter (:name) for each value. It returns the
+// conditions and the values of the parameters, ready for BindParams.
+//
+// Only the fields tagged with filter are used, and only when they are set: nil pointers,
+// nil or empty slices and zero values are skipped, so a filter struct can describe every
+// optional criterion of a search endpoint. The tag holds the column and, optionally, an
+// operator: eq (the default), ne, lt, lte, gt, gte, like, ilike, in (for slices) and
+// null (for *bool fields, true means IS NULL and false means IS NOT NULL).
+//
+//	type UserFilter struct {
+//		Email  *string  `filter:"email"`
+//		MinAge *int     `filter:"age,gte"`
+//		Roles  []string `filter:"role,in"`
+//	}
+//
+//	age := 18
+//	where, args, err := sqload.BuildWhere(UserFilter{MinAge: &age, Roles: []string{"admin", "owner"}})
+//	// where: age >= :age_gte AND role IN (:role_in_0, :role_in_1)
+//	// args:  map[age_gte:18 role_in_0:admin role_in_1:owner]
+//
+// If there are no conditions, it will return an empty string.
+func BuildWhere(filter any) (string, map[string]any, error) {
+	return buildWhere(filter, ":")
+}
+
+func buildWhere(filter any, prefix string) (string, map[string]any, error) {
+	value := reflect.ValueOf(filter)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("%T is not a struct", filter)
+	}
+	conditions := []string{}
+	args := map[string]any{}
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		tag := field.Tag.Get("filter")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		column, op, _ := strings.Cut(tag, ",")
+		if op == "" {
+			op = "eq"
+		}
+		operator, ok := filterOperators[op]
+		if !ok {
+			return "", nil, fmt.Errorf("field %s has an unknown operator %s", field.Name, op)
+		}
+		v := value.Field(i)
+		if v.IsZero() || (v.Kind() == reflect.Slice && v.Len() == 0) {
+			continue
+		}
+		for v.Kind() == reflect.Pointer {
+			v = v.Elem()
+		}
+		name := paramName(column)
+		if op != "eq" {
+			name += "_" + op
+		}
+		switch op {
+		case "null":
+			if v.Kind() != reflect.Bool {
+				return "", nil, fmt.Errorf("field %s must be a bool to use the null operator", field.Name)
+			}
+			if v.Bool() {
+				conditions = append(conditions, column+" IS NULL")
+			} else {
+				conditions = append(conditions, column+" IS NOT NULL")
+			}
+		case "in":
+			if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+				return "", nil, fmt.Errorf("field %s must be a slice to use the in operator", field.Name)
+			}
+			params := make([]string, v.Len())
+			for j := 0; j < v.Len(); j++ {
+				params[j] = fmt.Sprintf("%s%s_%d", prefix, name, j)
+				args[fmt.Sprintf("%s_%d", name, j)] = v.Index(j).Interface()
+			}
+			conditions = append(conditions, fmt.Sprintf("%s IN (%s)", column, strings.Join(params, ", ")))
+		default:
+			conditions = append(conditions, fmt.Sprintf("%s %s %s%s", column, operator, prefix, name))
+			args[name] = v.Interface()
+		}
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// paramName turns a column, maybe qualified with a table name, into a parameter name.
+func paramName(column string) string {
+	return strings.Map(func(r rune) rune {
+		if isWordRune(r) && r != '$' {
+			return r
+		}
+		return '_'
+	}, column)
+}
+
+// AppendWhere appends the conditions built by BuildWhere from filter to the base query
+// base, written in dialect d. The conditions are added with AND if base already has a
+// WHERE clause, whose conditions are then grouped in parentheses, or as a new WHERE
+// clause otherwise, before the terminating semicolon and the trailing comments of base,
+// which are dropped. The base query must end where the conditions are expected to go,
+// for example right after its FROM or WHERE clause.
+//
+//	-- query: FindUsers
+//	SELECT * FROM user WHERE deleted_at IS NULL;
+//
+//	sql, args, err := sqload.AppendWhere(Q.FindUsers, sqload.DialectPostgres, filter)
+//	// SELECT * FROM user WHERE (deleted_at IS NULL) AND age >= :age_gte;
+func AppendWhere(base string, d Dialect, filter any) (string, map[string]any, error) {
+	return appendWhere(base, d, filter, ":")
+}
+
+func appendWhere(base string, d Dialect, filter any, prefix string) (string, map[string]any, error) {
+	conditions, args, err := buildWhere(filter, prefix)
+	if err != nil || conditions == "" {
+		return base, args, err
+	}
+	code, terminator := cutTerminator(base, d)
+	where := -1
+	depth := 0
+	for _, tok := range tokenize(code, d) {
+		switch text := tok.text(code); {
+		case text == "(":
+			depth++
+		case text == ")":
+			depth--
+		case depth == 0 && tok.kind == tokenWord && strings.EqualFold(text, "WHERE"):
+			where = tok.end
+		}
+	}
+	if where == -1 {
+		return code + " WHERE " + conditions + terminator, args, nil
+	}
+	// The existing conditions are grouped so an OR in them does not swallow the new ones
+	existing := strings.TrimSpace(code[where:])
+	return code[:where] + " (" + existing + ") AND " + conditions + terminator, args, nil
+}
+
+// cutTerminator splits the SQL code sql, written in dialect d, into its code, without the
+// trailing spaces and comments, and its terminating semicolon, if any. So the code can be
+// extended without the additions landing inside a trailing -- comment.
+func cutTerminator(sql string, d Dialect) (string, string) {
+	tokens := significantTokens(sql, d)
+	if len(tokens) == 0 {
+		return "", ""
+	}
+	last := tokens[len(tokens)-1]
+	if last.kind != tokenSemicolon {
+		return sql[:last.end], ""
+	}
+	if len(tokens) == 1 {
+		return "", ";"
+	}
+	return sql[:tokens[len(tokens)-2].end], ";"
+}
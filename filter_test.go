@@ -0,0 +1,147 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+type TestUserFilter struct {
+	Email   *string  `filter:"u.email"`
+	MinAge  *int     `filter:"age,gte"`
+	Name    string   `filter:"name,like"`
+	Roles   []string `filter:"role,in"`
+	Deleted *bool    `filter:"deleted_at,null"`
+	Limit   int
+}
+
+func TestBuildWhere(t *testing.T) {
+	email := "neto@example.com"
+	age := 0
+	deleted := false
+	testCases := []struct {
+		filter   any
+		wantSql  string
+		wantArgs map[string]any
+		wantErr  bool
+	}{
+		{TestUserFilter{}, "", map[string]any{}, false},
+		{
+			&TestUserFilter{Email: &email, MinAge: &age, Limit: 10},
+			"u.email = :u_email AND age >= :age_gte",
+			map[string]any{"u_email": email, "age_gte": 0},
+			false,
+		},
+		{
+			TestUserFilter{Name: "Ern%", Roles: []string{"admin", "owner"}, Deleted: &deleted},
+			"name LIKE :name_like AND role IN (:role_in_0, :role_in_1) AND deleted_at IS NOT NULL",
+			map[string]any{"name_like": "Ern%", "role_in_0": "admin", "role_in_1": "owner"},
+			false,
+		},
+		{1, "", nil, true},
+		{struct {
+			Age int `filter:"age,between"`
+		}{1}, "", nil, true},
+		{struct {
+			Age int `filter:"age,in"`
+		}{1}, "", nil, true},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			sql, args, err := BuildWhere(testCase.filter)
+			if testCase.wantErr {
+				if err == nil {
+					t.Fatal("err is nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if sql != testCase.wantSql {
+				t.Errorf("got %s, want %s", sql, testCase.wantSql)
+			}
+			if fmt.Sprint(args) != fmt.Sprint(testCase.wantArgs) {
+				t.Errorf("got %v, want %v", args, testCase.wantArgs)
+			}
+		})
+	}
+}
+
+func TestAppendWhere(t *testing.T) {
+	email := "neto@example.com"
+	filter := TestUserFilter{Email: &email}
+	testCases := []struct {
+		base string
+		want string
+	}{
+		{"SELECT * FROM user u", "SELECT * FROM user u WHERE u.email = :u_email"},
+		{"SELECT * FROM user u;\n", "SELECT * FROM user u WHERE u.email = :u_email;"},
+		{
+			"SELECT * FROM user u WHERE u.deleted_at IS NULL OR u.admin;",
+			"SELECT * FROM user u WHERE (u.deleted_at IS NULL OR u.admin) AND u.email = :u_email;",
+		},
+		{"SELECT * FROM user u -- all the users", "SELECT * FROM user u WHERE u.email = :u_email"},
+		{"SELECT * FROM user u; -- all the users\n", "SELECT * FROM user u WHERE u.email = :u_email;"},
+		{
+			"SELECT * FROM user u\nWHERE u.active -- only the active ones\n;",
+			"SELECT * FROM user u\nWHERE (u.active) AND u.email = :u_email;",
+		},
+		{
+			"SELECT * FROM user u JOIN (SELECT id FROM org WHERE active) o ON o.id = u.org_id",
+			"SELECT * FROM user u JOIN (SELECT id FROM org WHERE active) o ON o.id = u.org_id WHERE u.email = :u_email",
+		},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			sql, _, err := AppendWhere(testCase.base, DialectGeneric, filter)
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if sql != testCase.want {
+				t.Errorf("got %s, want %s", sql, testCase.want)
+			}
+		})
+	}
+	// Without conditions the base query is returned untouched
+	sql, _, err := AppendWhere("SELECT * FROM user;", DialectGeneric, TestUserFilter{})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if sql != "SELECT * FROM user;" {
+		t.Errorf("got %s, want %s", sql, "SELECT * FROM user;")
+	}
+}
+
+func TestQuerySetFilter(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("-- query: FindUsers\nSELECT * FROM user u WHERE u.org = @org;")},
+	}
+	qs, err := LoadQuerySet(fsys, WithDialect(DialectPostgres), WithParamStyles(ParamAt))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	email := "neto@example.com"
+	_, _, err = qs.Filter("FindUsers", TestUserFilter{Email: &email}, nil)
+	if err == nil || err.Error() != "missing value for parameter org" {
+		t.Fatalf("got %v, want %s", err, "missing value for parameter org")
+	}
+	sql, args, err := qs.Filter("FindUsers", TestUserFilter{Email: &email}, map[string]any{"org": "cats"})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "SELECT * FROM user u WHERE (u.org = $1) AND u.email = $2;"
+	if sql != want {
+		t.Errorf("got %s, want %s", sql, want)
+	}
+	if fmt.Sprint(args) != fmt.Sprint([]any{"cats", email}) {
+		t.Errorf("got %v, want %v", args, []any{"cats", email})
+	}
+	_, _, err = qs.Filter("FindUsers", TestUserFilter{Email: &email}, map[string]any{"org": "cats", "u_email": "tom@example.com"})
+	if want := "parameter u_email of the filter is also in args"; fmt.Sprint(err) != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+	if _, _, err := qs.Filter("FindCats", TestUserFilter{}, nil); err == nil {
+		t.Error("err is nil")
+	}
+}
@@ -0,0 +1,63 @@
+package sqload
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// fingerprintVersion starts the data hashed by Fingerprint, so a change in the way the
+// queries are hashed can not produce the fingerprint of another bundle.
+const fingerprintVersion = "sqload-fingerprint-v2"
+
+// Fingerprint returns a hash of the queries of the .sql files of the fsys file system
+// (recursively), chosen and parsed as the Load functions do with the options opts, so
+// build pipelines can stamp their artifacts with it and check that two environments run
+// the same SQL code:
+//
+//	fingerprint, err := sqload.Fingerprint(sqlFiles)
+//	...
+//	log.Printf("serving the queries %s", fingerprint)
+//
+// The hash is the hex-encoded SHA-256 of the names, the SQL code, the annotations and
+// the attributes (see WithAttributes) of the queries, in the order of their names, with the line endings normalized. So it does
+// not change when the queries are moved between files or reordered, when the line
+// endings of the files change, or when the comments outside the SQL code of the queries
+// change.
+func Fingerprint(fsys fs.FS, opts ...Option) (string, error) {
+	queries, headers, err := extractQueriesFromFS(fsys, newConfig(opts))
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	io.WriteString(h, fingerprintVersion)
+	for _, name := range sortedKeys(queries) {
+		writeFingerprintField(h, name)
+		writeFingerprintField(h, strings.ReplaceAll(queries[name], "\r\n", "\n"))
+		annotations := headers[name].annotations
+		fmt.Fprintf(h, "%d;", len(annotations))
+		for _, key := range sortedKeys(annotations) {
+			writeFingerprintField(h, key)
+			fmt.Fprintf(h, "%d;", len(annotations[key]))
+			for _, value := range annotations[key] {
+				writeFingerprintField(h, strings.ReplaceAll(value, "\r", ""))
+			}
+		}
+		attrs := headers[name].attrs
+		fmt.Fprintf(h, "%d;", len(attrs))
+		for _, key := range sortedKeys(attrs) {
+			writeFingerprintField(h, key)
+			writeFingerprintField(h, attrs[key])
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeFingerprintField writes the field s to w prefixed by its length, so the fields
+// hashed by Fingerprint can not run into each other.
+func writeFingerprintField(w io.Writer, s string) {
+	fmt.Fprintf(w, "%d:%s", len(s), s)
+}
@@ -0,0 +1,80 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFingerprint(t *testing.T) {
+	bundle := fstest.MapFS{
+		"users.sql":  {Data: []byte("-- query: FindUser\n-- tags: users\nSELECT * FROM user WHERE id = :id;\n\n-- query: DeleteUser\nDELETE FROM user\nWHERE id = :id;\n")},
+		"orders.sql": {Data: []byte("-- query: FindOrder\nSELECT * FROM \"order\";\n")},
+	}
+	want, err := Fingerprint(bundle)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		fsys fstest.MapFS
+		same bool
+	}{
+		{
+			// Queries moved between files and reordered, CRLF line endings and other comments
+			fstest.MapFS{
+				"a.sql": {Data: []byte("-- Orders.\r\n-- query: FindOrder\r\nSELECT * FROM \"order\";\r\n\r\n-- query: DeleteUser\r\n-- Deletes a user.\r\nDELETE FROM user\r\nWHERE id = :id;\r\n")},
+				"b.sql": {Data: []byte("-- query: FindUser\n-- tags: users\nSELECT * FROM user WHERE id = :id;")},
+			},
+			true,
+		},
+		{
+			fstest.MapFS{
+				"users.sql":  bundle["users.sql"],
+				"orders.sql": {Data: []byte("-- query: FindOrder\nSELECT id FROM \"order\";\n")},
+			},
+			false,
+		},
+		{
+			fstest.MapFS{
+				"users.sql":  {Data: []byte("-- query: FindUser\n-- tags: admin\nSELECT * FROM user WHERE id = :id;\n\n-- query: DeleteUser\nDELETE FROM user\nWHERE id = :id;\n")},
+				"orders.sql": bundle["orders.sql"],
+			},
+			false,
+		},
+		{
+			fstest.MapFS{
+				"users.sql":  bundle["users.sql"],
+				"orders.sql": {Data: []byte("-- query: FindOrders\nSELECT * FROM \"order\";\n")},
+			},
+			false,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got, err := Fingerprint(tc.fsys)
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if (got == want) != tc.same {
+				t.Errorf("got %s, want a fingerprint that is the same as %s: %t", got, want, tc.same)
+			}
+		})
+	}
+	if _, err := Fingerprint(fstest.MapFS{"bad.sql": {Data: []byte("-- query: find-user\nSELECT 1;")}}); err == nil {
+		t.Error("err is nil")
+	}
+}
+
+func TestFingerprintAttributes(t *testing.T) {
+	payments, err := Fingerprint(fstest.MapFS{"q.sql": {Data: []byte("-- query: FindUser owner=payments\nSELECT 1;\n")}}, WithAttributes("owner"))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	billing, err := Fingerprint(fstest.MapFS{"q.sql": {Data: []byte("-- query: FindUser owner=billing\nSELECT 1;\n")}}, WithAttributes("owner"))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if payments == billing {
+		t.Errorf("got the same fingerprint %s for queries with different attributes", payments)
+	}
+}
@@ -0,0 +1,117 @@
+package sqload
+
+import "strings"
+
+// KeywordCase is the case Format writes the SQL keywords in.
+type KeywordCase int
+
+const (
+	// KeywordsAsWritten keeps the keywords as they are written.
+	KeywordsAsWritten KeywordCase = iota
+	// KeywordsUpper writes the keywords in upper case.
+	KeywordsUpper
+	// KeywordsLower writes the keywords in lower case.
+	KeywordsLower
+)
+
+// FormatStyle configures how Format rewrites SQL code.
+type FormatStyle struct {
+	// Keywords is the case of the keywords.
+	Keywords KeywordCase
+	// Indent is the text of a level of indentation, like "\t" or "  ". If it is empty,
+	// the indentation is kept as it is written.
+	Indent string
+	// IndentWidth is the number of columns of a level of indentation as it is written,
+	// counting a tab as IndentWidth columns. If it is zero, 4 is used.
+	IndentWidth int
+}
+
+// formatKeywords are the keywords whose case is changed by Format. Words that are also
+// common table or column names, like USER or NAME, are left out, because in some
+// databases the case of unquoted identifiers matters.
+var formatKeywords = map[string]bool{
+	"ADD": true, "ALL": true, "ALTER": true, "AND": true, "ANY": true, "AS": true,
+	"ASC": true, "BEGIN": true, "BETWEEN": true, "BY": true, "CASE": true, "CAST": true,
+	"CHECK": true, "COLUMN": true, "COMMIT": true, "CONFLICT": true, "CONSTRAINT": true,
+	"CREATE": true, "CROSS": true, "DEFAULT": true, "DELETE": true, "DESC": true,
+	"DISTINCT": true, "DO": true, "DROP": true, "ELSE": true, "END": true, "EXCEPT": true,
+	"EXISTS": true, "FALSE": true, "FETCH": true, "FOREIGN": true, "FROM": true,
+	"FULL": true, "GROUP": true, "HAVING": true, "IF": true, "ILIKE": true, "IN": true,
+	"INDEX": true, "INNER": true, "INSERT": true, "INTERSECT": true, "INTO": true,
+	"IS": true, "JOIN": true, "LEFT": true, "LIKE": true, "LIMIT": true, "NOT": true,
+	"NOTHING": true, "NULL": true, "OFFSET": true, "ON": true, "OR": true, "ORDER": true,
+	"OUTER": true, "OVER": true, "PARTITION": true, "PRIMARY": true, "REFERENCES": true,
+	"RETURNING": true, "RIGHT": true, "ROLLBACK": true, "SELECT": true, "SET": true,
+	"TABLE": true, "THEN": true, "TRUE": true, "UNION": true, "UNIQUE": true,
+	"UPDATE": true, "USING": true, "VALUES": true, "VIEW": true, "WHEN": true,
+	"WHERE": true, "WITH": true,
+}
+
+// Format rewrites the SQL code sql, written in dialect d, following style: it changes
+// the case of the keywords, rewrites the indentation of the lines and removes the
+// spaces at the end of the lines. Strings, quoted identifiers and comments are never
+// changed.
+//
+//	sql := sqload.Format("select id\n\tfrom user", sqload.DialectGeneric, sqload.FormatStyle{
+//		Keywords: sqload.KeywordsUpper,
+//		Indent:   "  ",
+//	})
+//	// SELECT id
+//	//   FROM user
+func Format(sql string, d Dialect, style FormatStyle) string {
+	if style.IndentWidth <= 0 {
+		style.IndentWidth = 4
+	}
+	var b strings.Builder
+	b.Grow(len(sql))
+	for i, tok := range tokenize(sql, d) {
+		text := tok.text(sql)
+		switch tok.kind {
+		case tokenWord:
+			if formatKeywords[strings.ToUpper(text)] {
+				switch style.Keywords {
+				case KeywordsUpper:
+					text = strings.ToUpper(text)
+				case KeywordsLower:
+					text = strings.ToLower(text)
+				}
+			}
+		case tokenSpace:
+			text = formatSpace(text, i == 0, style)
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
+
+// formatSpace rewrites the white space space following style: the spaces before a line
+// break are removed and the indentation after the last one is rewritten. If first is
+// true, space is at the beginning of the code, so it is an indentation too.
+func formatSpace(space string, first bool, style FormatStyle) string {
+	lines := strings.Split(space, "\n")
+	last := len(lines) - 1
+	for i := range lines[:last] {
+		cr := strings.HasSuffix(lines[i], "\r")
+		lines[i] = strings.TrimRight(lines[i], " \t\r\f\v")
+		if cr {
+			lines[i] += "\r"
+		}
+	}
+	if style.Indent != "" && (last > 0 || first) {
+		lines[last] = reindent(lines[last], style)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reindent rewrites the indentation indent following style.
+func reindent(indent string, style FormatStyle) string {
+	columns := 0
+	for _, c := range indent {
+		if c == '\t' {
+			columns += style.IndentWidth
+		} else {
+			columns++
+		}
+	}
+	return strings.Repeat(style.Indent, columns/style.IndentWidth) + strings.Repeat(" ", columns%style.IndentWidth)
+}
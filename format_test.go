@@ -0,0 +1,45 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		sql   string
+		d     Dialect
+		style FormatStyle
+		want  string
+	}{
+		{
+			sql:   "select id   \n\tfrom user\n    where name = 'select\n\tfrom' -- select from\n",
+			style: FormatStyle{Keywords: KeywordsUpper, Indent: "  "},
+			want:  "SELECT id\n  FROM user\n  WHERE name = 'select\n\tfrom' -- select from\n",
+		},
+		{
+			sql:   "  SELECT \"FROM\" FROM t\r\n      WHERE x",
+			style: FormatStyle{Keywords: KeywordsLower, Indent: "\t", IndentWidth: 2},
+			want:  "\tselect \"FROM\" from t\r\n\t\t\twhere x",
+		},
+		{
+			sql:   "SELECT 1\n\t FROM t;",
+			style: FormatStyle{},
+			want:  "SELECT 1\n\t FROM t;",
+		},
+		{
+			sql:   "CREATE FUNCTION f() AS $$\n  select 1;\n$$;",
+			d:     DialectPostgres,
+			style: FormatStyle{Keywords: KeywordsLower, Indent: "\t"},
+			want:  "create FUNCTION f() as $$\n  select 1;\n$$;",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got := Format(tc.sql, tc.d, tc.style)
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package sqload
+
+import "fmt"
+
+// AnnotationHandler handles the annotations of the queries with a given key, so other
+// packages can give meaning to their own -- key: value directives without changing the
+// parser. It is registered with the WithAnnotationHandler option.
+//
+// For every loaded query that has annotations with the key returned by Key,
+// HandleAnnotation receives the query and the values of those annotations, and returns
+// structured data that is attached to the metadata of the query (see Query.Data). If it
+// returns an error, the load fails, so invalid annotations are reported early.
+//
+//	type cacheHandler struct{}
+//
+//	func (cacheHandler) Key() string { return "cache-ttl" }
+//
+//	func (cacheHandler) HandleAnnotation(q sqload.Query, values []string) (any, error) {
+//		return time.ParseDuration(values[0])
+//	}
+//
+//	qs, err := sqload.LoadQuerySet(fsys, sqload.WithAnnotationHandler(cacheHandler{}))
+//	...
+//	q, _ := qs.Query("FindUserById")
+//	ttl, cached := q.Data["cache-ttl"].(time.Duration)
+type AnnotationHandler interface {
+	// Key returns the key of the annotations handled, in lower case.
+	Key() string
+	// HandleAnnotation returns the data of the annotations of q with the key, whose
+	// values are values.
+	HandleAnnotation(q Query, values []string) (any, error)
+}
+
+// handleAnnotations passes the annotations of q to the handlers configured in cfg and
+// returns the data they return, by key. If q has no handled annotations, it returns nil.
+func handleAnnotations(q Query, cfg *config) (map[string]any, error) {
+	var data map[string]any
+	for _, handler := range cfg.annotationHandlers {
+		key := handler.Key()
+		values, found := q.Annotations[key]
+		if !found {
+			continue
+		}
+		value, err := handler.HandleAnnotation(q, values)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s: %s", key, cfg.detail(err))
+		}
+		if data == nil {
+			data = map[string]any{}
+		}
+		data[key] = value
+	}
+	return data, nil
+}
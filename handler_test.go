@@ -0,0 +1,67 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type cacheTestHandler struct{}
+
+func (cacheTestHandler) Key() string {
+	return "cache-ttl"
+}
+
+func (cacheTestHandler) HandleAnnotation(q Query, values []string) (any, error) {
+	if q.Kind != KindDML {
+		return nil, errors.New("only DML queries can be cached")
+	}
+	return time.ParseDuration(values[0])
+}
+
+func TestLoadWithAnnotationHandler(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cat.sql": {Data: []byte(`
+-- query: FindCat
+-- cache-ttl: 5m
+SELECT * FROM cat WHERE id = :id;
+
+-- query: DeleteCat
+DELETE FROM cat WHERE id = :id;
+`)},
+	}
+	qs, err := LoadQuerySet(fsys, WithAnnotationHandler(cacheTestHandler{}))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	q, _ := qs.Query("FindCat")
+	if ttl, ok := q.Data["cache-ttl"].(time.Duration); !ok || ttl != 5*time.Minute {
+		t.Errorf("got %v, want %s", q.Data["cache-ttl"], 5*time.Minute)
+	}
+	if q, _ := qs.Query("DeleteCat"); q.Data != nil {
+		t.Errorf("got %v, want nil", q.Data)
+	}
+
+	testCases := []struct {
+		sql  string
+		want error
+	}{
+		{"-- query: FindCat\n-- cache-ttl: forever\nSELECT 1;", fmt.Errorf(`%w: query FindCat: annotation cache-ttl: time: invalid duration "forever"`, ErrCannotLoadQueries)},
+		{"-- query: CreateCat\n-- cache-ttl: 5m\nCREATE TABLE cat (id INT);", fmt.Errorf("%w: query CreateCat: annotation cache-ttl: only DML queries can be cached", ErrCannotLoadQueries)},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := LoadQuerySet(fstest.MapFS{"cat.sql": {Data: []byte(testCase.sql)}}, WithAnnotationHandler(cacheTestHandler{}))
+			if fmt.Sprint(err) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %s", err, testCase.want)
+			}
+			// The struct loads reject the same queries
+			_, err = LoadFromString[struct{}](testCase.sql, WithAnnotationHandler(cacheTestHandler{}))
+			if fmt.Sprint(err) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %s", err, testCase.want)
+			}
+		})
+	}
+}
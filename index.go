@@ -0,0 +1,65 @@
+package sqload
+
+import (
+	"sort"
+	"strings"
+)
+
+// NameOf returns the name of the query whose SQL code is sql, so the statements seen by
+// a driver or written in a log can be traced back to their queries. The SQL code can be
+// the one loaded or the one returned by Bind, and the surrounding spaces are ignored.
+// Variants are named Name:variant (see QuerySet.Select). If several queries have the
+// same SQL code, the first one in alphabetical order is returned; aliases are never
+// returned.
+//
+//	if name, found := qs.NameOf(statement); found {
+//		log.Printf("slow query %s", name)
+//	}
+//
+// The SQL code built on top of a query, like the one returned by Filter or OrderBy, is
+// not recognized.
+func (qs *QuerySet) NameOf(sql string) (string, bool) {
+	keys := qs.keysOf(sql)
+	if len(keys) == 0 {
+		return "", false
+	}
+	return keys[0], true
+}
+
+// keysOf returns the keys of the queries and variants whose SQL code is sql, sorted
+// alphabetically, see NameOf.
+func (qs *QuerySet) keysOf(sql string) []string {
+	qs.indexOnce.Do(qs.buildIndex)
+	return qs.index[strings.TrimSpace(sql)]
+}
+
+// buildIndex builds the index of the queries and variants of qs by SQL code, both as
+// loaded and as bound by Bind.
+func (qs *QuerySet) buildIndex() {
+	qs.index = map[string][]string{}
+	add := func(key string, q Query) {
+		forms := []string{strings.TrimSpace(q.SQL)}
+		args := make(map[string]any, len(q.Params))
+		for _, p := range q.Params {
+			args[p] = nil
+		}
+		if bound, _, err := BindParams(q.SQL, qs.dialect, args, qs.paramStyles...); err == nil && strings.TrimSpace(bound) != forms[0] {
+			forms = append(forms, strings.TrimSpace(bound))
+		}
+		for _, form := range forms {
+			qs.index[form] = append(qs.index[form], key)
+		}
+	}
+	for name, q := range qs.queries {
+		if q.AliasOf != "" {
+			continue
+		}
+		add(name, q)
+		for variant, v := range qs.variants[name] {
+			add(variantKey(name, variant), v)
+		}
+	}
+	for _, keys := range qs.index {
+		sort.Strings(keys)
+	}
+}
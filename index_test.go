@@ -0,0 +1,47 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestQuerySetNameOf(t *testing.T) {
+	sql := `-- query: FindUser
+SELECT * FROM user WHERE id = :id AND org = :org;
+
+-- query: FindUser variant=fast
+SELECT * FROM user_by_id WHERE id = :id;
+
+-- query: RemoveUser, DeleteUser
+DELETE FROM user WHERE id = :id;
+
+-- query: Ping
+-- alias: Hello
+SELECT 1;
+`
+	qs, err := LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte(sql)}}, WithDialect(DialectPostgres))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		sql   string
+		want  string
+		found bool
+	}{
+		{"SELECT * FROM user WHERE id = :id AND org = :org;", "FindUser", true},
+		{"SELECT * FROM user WHERE id = $1 AND org = $2;", "FindUser", true},
+		{"SELECT * FROM user_by_id WHERE id = $1;", "FindUser:fast", true},
+		{"DELETE FROM user WHERE id = $1;", "DeleteUser", true},
+		{"  SELECT 1;\n", "Ping", true},
+		{"SELECT 2;", "", false},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			name, found := qs.NameOf(tc.sql)
+			if name != tc.want || found != tc.found {
+				t.Errorf("got %s, %t, want %s, %t", name, found, tc.want, tc.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package sqload
+
+import "io/fs"
+
+// queryMap returns the SQL code of the queries of qs, and of their variants, by name.
+func (qs *QuerySet) queryMap() map[string]string {
+	queries := make(map[string]string, len(qs.queries))
+	for name, q := range qs.queries {
+		queries[name] = q.SQL
+		for variant, v := range qs.variants[name] {
+			queries[variantKey(name, variant)] = v.SQL
+		}
+	}
+	return queries
+}
+
+// headerMap returns the headers of the queries of qs, and of their variants, by name.
+func (qs *QuerySet) headerMap() map[string]header {
+	headers := make(map[string]header, len(qs.queries))
+	for name, q := range qs.queries {
+		headers[name] = q.header()
+		for variant, v := range qs.variants[name] {
+			headers[variantKey(name, variant)] = v.header()
+		}
+	}
+	return headers
+}
+
+// header returns the header the query q was read with.
+func (q Query) header() header {
+	return header{annotations: q.Annotations, doc: q.Doc, file: q.file, line: q.line, order: q.order, aliasOf: q.AliasOf, attrs: q.Attributes}
+}
+
+// Into binds the queries of qs into the struct pointed by v, like the Load functions do,
+// so several structs can be bound from a single parse of the files. Only the options in
+// opts that affect the binding, like WithPrefix, have an effect.
+//
+//	qs, err := sqload.LoadQuerySet(fsys, sqload.WithDirNamespaces())
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := qs.Into(&users.Q, sqload.WithPrefix("users.")); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := qs.Into(&orders.Q, sqload.WithPrefix("orders.")); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// If some query is not found or v is not a pointer to a struct, it will return an error.
+func (qs *QuerySet) Into(v Struct, opts ...Option) error {
+	cfg := newConfig(opts)
+	cfg.dialect = qs.dialect
+	cfg.headers = qs.headerMap()
+	return loadQueriesIntoStruct(withPrefix(qs.queryMap(), cfg.prefix), v, cfg)
+}
+
+// LoadMany loads the SQL code from all the .sql files in the fsys file system
+// (recursively) once and binds the queries into each of the structs pointed by dests.
+// It is a shortcut for LoadQuerySet followed by QuerySet.Into, which also accept
+// options.
+//
+//	var a struct {
+//		FindCat string `query:"FindCat"`
+//	}
+//	var b struct {
+//		FindDog string `query:"FindDog"`
+//	}
+//	err := sqload.LoadMany(fsys, &a, &b)
+func LoadMany(fsys fs.FS, dests ...Struct) error {
+	qs, err := LoadQuerySet(fsys)
+	if err != nil {
+		return err
+	}
+	for _, v := range dests {
+		if err := qs.Into(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
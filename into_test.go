@@ -0,0 +1,70 @@
+package sqload
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+func TestQuerySetInto(t *testing.T) {
+	qs, err := LoadQuerySet(os.DirFS("testdata/namespaces"), WithDirNamespaces(), WithDialect(DialectPostgres))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	var users struct {
+		FindUser []string `query:"FindUser"`
+	}
+	if err := qs.Into(&users, WithPrefix("users.")); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(users.FindUser) != "[SELECT * FROM user WHERE id = :id;]" {
+		t.Errorf("got %v, want %v", users.FindUser, "[SELECT * FROM user WHERE id = :id;]")
+	}
+	var all struct {
+		Ping   string `query:"Ping"`
+		Orders struct {
+			FindOrder string `query:"FindOrder"`
+		} `namespace:"orders_v2"`
+	}
+	if err := qs.Into(&all); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if all.Ping != "SELECT 1;" || all.Orders.FindOrder != `SELECT * FROM "order" WHERE id = :id;` {
+		t.Errorf("got %+v, want Ping and Orders.FindOrder", all)
+	}
+	var missing struct {
+		FindUser string `query:"FindUser"`
+	}
+	want := fmt.Errorf("%w: could not find query FindUser", ErrCannotLoadQueries)
+	if err := qs.Into(&missing); fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
+
+func TestLoadMany(t *testing.T) {
+	fsys := fstest.MapFS{
+		"pets.sql": {Data: []byte("-- query: FindCat\nSELECT * FROM cat;\n-- query: FindDog\nSELECT * FROM dog;\n-- query: FindDog variant=fast\nSELECT * FROM dog_cache;")},
+	}
+	var cats struct {
+		FindCat string `query:"FindCat"`
+	}
+	var dogs struct {
+		FindDog     string `query:"FindDog"`
+		FindDogFast string `query:"FindDog:fast"`
+	}
+	if err := LoadMany(fsys, &cats, &dogs); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if cats.FindCat != "SELECT * FROM cat;" {
+		t.Errorf("got %s, want %s", cats.FindCat, "SELECT * FROM cat;")
+	}
+	if dogs.FindDog != "SELECT * FROM dog;" || dogs.FindDogFast != "SELECT * FROM dog_cache;" {
+		t.Errorf("got %+v, want FindDog and its fast variant", dogs)
+	}
+	var notStruct int
+	want := fmt.Errorf("%w: v is not a pointer to a struct", ErrCannotLoadQueries)
+	if err := LoadMany(fsys, &cats, &notStruct); fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,68 @@
+package sqload
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// ParseIsolation returns the isolation level named name without regard to case, with
+// the words separated by spaces, dashes or underscores: default, read-uncommitted,
+// read-committed, write-committed, repeatable-read, snapshot, serializable or
+// linearizable.
+func ParseIsolation(name string) (sql.IsolationLevel, error) {
+	normalized := strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), " ")
+	for level := sql.LevelDefault; level <= sql.LevelLinearizable; level++ {
+		if strings.EqualFold(normalized, level.String()) {
+			return level, nil
+		}
+	}
+	return sql.LevelDefault, fmt.Errorf("invalid isolation level %s", name)
+}
+
+// TxOptions returns the options of the transactions the query must run in, with the
+// isolation level written in its isolation annotation, or nil if it has none:
+//
+//	-- query: TransferFunds
+//	-- isolation: serializable
+//	UPDATE account SET balance = balance + :amount WHERE id = :id;
+//
+//	tx, err := db.BeginTx(ctx, q.TxOptions())
+//
+// The isolation annotation is checked when the queries are loaded.
+func (q Query) TxOptions() *sql.TxOptions {
+	return isolationOptions(q.Annotations)
+}
+
+// isolationOptions returns the transaction options with the isolation level written in
+// the isolation annotation of annotations, or nil if there is none or it is invalid.
+func isolationOptions(annotations Annotations) *sql.TxOptions {
+	name := annotations.Get("isolation")
+	if name == "" {
+		return nil
+	}
+	level, err := ParseIsolation(name)
+	if err != nil {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: level}
+}
+
+// txOptions returns the options of the transaction of the script: the isolation level
+// written in its isolation annotation or, if it has none, the strictest isolation level
+// of its steps, the queries of qs. It returns nil if neither has one.
+func (tx TxScript) txOptions(qs *QuerySet) *sql.TxOptions {
+	if opts := isolationOptions(tx.Annotations); opts != nil {
+		return opts
+	}
+	var strictest *sql.TxOptions
+	for _, step := range tx.Steps {
+		opts := qs.queries[step].TxOptions()
+		if opts != nil && (strictest == nil || opts.Isolation > strictest.Isolation) {
+			strictest = opts
+		}
+	}
+	return strictest
+}
@@ -0,0 +1,72 @@
+package sqload
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseIsolation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		want    sql.IsolationLevel
+		wantErr error
+	}{
+		{"serializable", sql.LevelSerializable, nil},
+		{"read-committed", sql.LevelReadCommitted, nil},
+		{"REPEATABLE_READ", sql.LevelRepeatableRead, nil},
+		{"Read Uncommitted", sql.LevelReadUncommitted, nil},
+		{"default", sql.LevelDefault, nil},
+		{"eventual", sql.LevelDefault, errors.New("invalid isolation level eventual")},
+		{"read", sql.LevelDefault, errors.New("invalid isolation level read")},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			level, err := ParseIsolation(testCase.name)
+			if level != testCase.want || fmt.Sprint(err) != fmt.Sprint(testCase.wantErr) {
+				t.Errorf("got %s, %v, want %s, %v", level, err, testCase.want, testCase.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryTxOptions(t *testing.T) {
+	testCases := []struct {
+		sql     string
+		want    *sql.TxOptions
+		wantErr error
+	}{
+		{
+			"-- query: TransferFunds\n-- isolation: serializable\nUPDATE account SET balance = balance + :amount;",
+			&sql.TxOptions{Isolation: sql.LevelSerializable},
+			nil,
+		},
+		{
+			"-- query: TransferFunds\nUPDATE account SET balance = balance + :amount;",
+			nil,
+			nil,
+		},
+		{
+			"-- query: TransferFunds\n-- isolation: eventual\nUPDATE account SET balance = balance + :amount;",
+			nil,
+			fmt.Errorf("%w: query TransferFunds: invalid isolation level eventual", ErrCannotLoadQueries),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			qs, err := LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte(tc.sql)}})
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			q, _ := qs.Query("TransferFunds")
+			if got := q.TxOptions(); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+//go:build go1.23
+
+package sqload
+
+import (
+	"io/fs"
+	"iter"
+)
+
+// All returns an iterator over the queries in the set, sorted by name, yielding the name
+// of each query along with the query.
+//
+//	for name, q := range qs.All() {
+//		fmt.Println(name, q.Kind)
+//	}
+func (qs *QuerySet) All() iter.Seq2[string, Query] {
+	return func(yield func(string, Query) bool) {
+		for _, name := range qs.Names() {
+			if !yield(name, qs.queries[name]) {
+				return
+			}
+		}
+	}
+}
+
+// Queries returns an iterator over the queries of all the .sql files in the fsys file
+// system (recursively). The files are read one at a time, as the iteration goes, and
+// their queries are yielded in the order they are defined, so large bundles can be
+// processed without loading them whole:
+//
+//	for q, err := range sqload.Queries(os.DirFS("sql")) {
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		fmt.Println(q.Name)
+//	}
+//
+// Since no file knows about the others, a query defined in several files is yielded
+// once for each of them, and the options that need the whole set of queries, like
+// WithRegistered and WithModel, have no effect. Only the default versions of the queries
+// are yielded, but their variants can be defined in other files; a query with variants
+// but no default version in any file is reported once all the files are read. If any error occurs, it is
+// yielded along with a zero Query and the iteration stops.
+func Queries(fsys fs.FS, opts ...Option) iter.Seq2[Query, error] {
+	return func(yield func(Query, error) bool) {
+		cfg := newConfig(opts)
+		cfg.registered = false
+		cfg.models = nil
+		files, err := findQueryFiles(fsys, cfg)
+		if err != nil {
+			yield(Query{}, err)
+			return
+		}
+		keys := map[string]string{}
+		for _, filename := range files {
+			qs, err := loadFile(fsys, filename, cfg)
+			if err != nil {
+				yield(Query{}, err)
+				return
+			}
+			for name, variants := range qs.variants {
+				for variant := range variants {
+					keys[variantKey(name, variant)] = ""
+				}
+			}
+			for _, q := range qs.InOrder() {
+				keys[q.Name] = ""
+				if !yield(q, nil) {
+					return
+				}
+			}
+		}
+		if err := checkVariants(keys); err != nil {
+			yield(Query{}, err)
+		}
+	}
+}
+
+// loadFile returns a QuerySet holding the queries of the file filename of the fsys file
+// system.
+func loadFile(fsys fs.FS, filename string, cfg *config) (*QuerySet, error) {
+	queries, headers, err := parseFile(fsys, filename, cfg)
+	if err != nil {
+		return nil, err
+	}
+	queries, headers, err = resolveQueries(queries, headers, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return newQuerySet(queries, headers, cfg)
+}
@@ -0,0 +1,87 @@
+//go:build go1.23
+
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestQuerySetAll(t *testing.T) {
+	qs, err := newQuerySet(CatTestQueries, nil, &config{})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	names := []string{}
+	for name, q := range qs.All() {
+		if q.SQL != CatTestQueries[name] {
+			t.Errorf("got %s, want %s", q.SQL, CatTestQueries[name])
+		}
+		names = append(names, name)
+		if len(names) == 2 {
+			break
+		}
+	}
+	if fmt.Sprint(names) != "[CreateCatTable CreateNormalCat]" {
+		t.Errorf("got %v, want %s", names, "[CreateCatTable CreateNormalCat]")
+	}
+}
+
+func TestQueries(t *testing.T) {
+	testCases := []struct {
+		fsys      fstest.MapFS
+		wantNames string
+		wantErr   error
+	}{
+		{
+			fsys: fstest.MapFS{
+				"a.sql": {Data: []byte("-- query: Zebra\nSELECT 1;\n-- query: Apple\nSELECT 2;")},
+				"b.sql": {Data: []byte("-- query: Mango\nSELECT 3;")},
+			},
+			wantNames: "[Zebra Apple Mango]",
+		},
+		{
+			fsys: fstest.MapFS{
+				"a.sql": {Data: []byte("-- query: Zebra\nSELECT 1;")},
+				"b.sql": {Data: []byte("-- query: not-valid\nSELECT 3;")},
+			},
+			wantNames: "[Zebra]",
+			wantErr:   fmt.Errorf("%w: invalid query name not-valid (b.sql:1)", ErrCannotLoadQueries),
+		},
+		{
+			fsys: fstest.MapFS{
+				"a.sql": {Data: []byte("-- query: FindUser variant=fast\nSELECT 1;")},
+				"b.sql": {Data: []byte("-- query: FindUser\nSELECT 2;")},
+			},
+			wantNames: "[FindUser]",
+		},
+		{
+			fsys: fstest.MapFS{
+				"a.sql": {Data: []byte("-- query: FindUser variant=fast\nSELECT 1;")},
+				"b.sql": {Data: []byte("-- query: Ping\nSELECT 2;")},
+			},
+			wantNames: "[Ping]",
+			wantErr:   fmt.Errorf("%w: query FindUser has variants but no default version", ErrCannotLoadQueries),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			names := []string{}
+			var err error
+			for q, qErr := range Queries(tc.fsys) {
+				if qErr != nil {
+					err = qErr
+					continue
+				}
+				names = append(names, q.Name)
+			}
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Errorf("got %s, want %s", err, tc.wantErr)
+			}
+			if fmt.Sprint(names) != tc.wantNames {
+				t.Errorf("got %v, want %s", names, tc.wantNames)
+			}
+		})
+	}
+}
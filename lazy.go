@@ -0,0 +1,59 @@
+package sqload
+
+import (
+	"io/fs"
+	"sync"
+)
+
+// Lazy returns a function that calls load the first time it is called and returns its
+// results on every call, so a heavy bundle of queries can be loaded on first use instead
+// of at package initialization. It is safe for concurrent use; if load panics, every
+// call panics with the same value, like sync.OnceValues does.
+//
+//	var queries = sqload.Lazy(func() (*Queries, error) {
+//		return sqload.LoadFromFS[Queries](fsys, sqload.WithDialect(sqload.DialectPostgres))
+//	})
+//
+//	func FindUser(ctx context.Context, id int) (*User, error) {
+//		q, err := queries()
+//		if err != nil {
+//			return nil, err
+//		}
+//		row := db.QueryRowContext(ctx, q.FindUserById, id)
+//		...
+//	}
+func Lazy[V Struct](load func() (*V, error)) func() (*V, error) {
+	var (
+		once     sync.Once
+		v        *V
+		err      error
+		panicked bool
+		p        any
+	)
+	return func() (*V, error) {
+		once.Do(func() {
+			panicked = true
+			defer func() {
+				if panicked {
+					p = recover()
+				}
+			}()
+			v, err = load()
+			panicked = false
+		})
+		if panicked {
+			panic(p)
+		}
+		return v, err
+	}
+}
+
+// LazyFromFS returns a function that loads the queries of the fsys file system into a
+// struct like LoadFromFS does, the first time it is called, see Lazy.
+//
+//	var queries = sqload.LazyFromFS[Queries](fsys)
+func LazyFromFS[V Struct](fsys fs.FS, opts ...Option) func() (*V, error) {
+	return Lazy(func() (*V, error) {
+		return LoadFromFS[V](fsys, opts...)
+	})
+}
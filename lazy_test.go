@@ -0,0 +1,64 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLazy(t *testing.T) {
+	calls := 0
+	load := Lazy(func() (*map[string]string, error) {
+		calls++
+		return LoadFromString[map[string]string]("-- query: Ping\nSELECT 1;")
+	})
+	if calls != 0 {
+		t.Fatalf("got %d calls, want 0 before the first use", calls)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q, err := load()
+			if err != nil || (*q)["Ping"] != "SELECT 1;" {
+				t.Errorf("got %v and %v, want the queries", q, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("got %d calls, want 1", calls)
+	}
+
+	failing := errors.New("unavailable")
+	loadErr := Lazy(func() (*map[string]string, error) { return nil, failing })
+	for i := 0; i < 2; i++ {
+		if _, err := loadErr(); err != failing {
+			t.Errorf("got %v, want %s", err, failing)
+		}
+	}
+
+	loadPanic := Lazy(func() (*map[string]string, error) { panic("boom") })
+	for i := 0; i < 2; i++ {
+		func() {
+			defer func() {
+				if p := recover(); p != "boom" {
+					t.Errorf("got %v, want boom", p)
+				}
+			}()
+			loadPanic()
+		}()
+	}
+
+	lazyFS := LazyFromFS[map[string]string](fstest.MapFS{"q.sql": {Data: []byte("-- query: Ping\nSELECT 1;")}})
+	q, err := lazyFS()
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(*q) != "map[Ping:SELECT 1;]" {
+		t.Errorf("got %v, want map[Ping:SELECT 1;]", *q)
+	}
+}
@@ -0,0 +1,204 @@
+package sqload
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Dialect identifies the flavor of SQL a piece of code is written in. It decides which
+// quoting and comment rules are used when the code has to be tokenized.
+type Dialect string
+
+const (
+	// DialectGeneric understands the syntax shared by most databases: single-quoted
+	// strings, double-quoted identifiers, -- and /* */ comments and dollar-quoted
+	// strings.
+	DialectGeneric  Dialect = ""
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite"
+	DialectTSQL     Dialect = "tsql"
+)
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota // keywords, identifiers and numbers
+	tokenSpace
+	tokenLineComment
+	tokenBlockComment
+	tokenString
+	tokenQuotedIdent
+	tokenDollarQuoted
+	tokenSemicolon
+	tokenOther // punctuation and operators, one character each
+)
+
+type token struct {
+	kind  tokenKind
+	start int
+	end   int
+}
+
+func (t token) text(sql string) string {
+	return sql[t.start:t.end]
+}
+
+// isTrivia reports whether the token carries no SQL code.
+func (t token) isTrivia() bool {
+	return t.kind == tokenSpace || t.kind == tokenLineComment || t.kind == tokenBlockComment
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// tokenize splits sql into tokens following the rules of dialect d. It never fails;
+// an unterminated string or comment extends until the end of sql.
+func tokenize(sql string, d Dialect) []token {
+	tokens := []token{}
+	for i := 0; i < len(sql); {
+		tok := nextToken(sql, i, d)
+		tokens = append(tokens, tok)
+		i = tok.end
+	}
+	return tokens
+}
+
+// nextToken returns the token starting at index i of sql, which must be lower than
+// len(sql).
+func nextToken(sql string, i int, d Dialect) token {
+	start := i
+	kind := tokenOther
+	c := sql[i]
+	switch {
+	case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+		kind = tokenSpace
+		for i < len(sql) && strings.IndexByte(" \t\n\r\f\v", sql[i]) >= 0 {
+			i++
+		}
+	case strings.HasPrefix(sql[i:], "--") || (c == '#' && d == DialectMySQL):
+		kind = tokenLineComment
+		i = lineEnd(sql, i)
+	case strings.HasPrefix(sql[i:], "/*"):
+		kind = tokenBlockComment
+		i = blockCommentEnd(sql, i, d == DialectPostgres)
+	case c == '\'':
+		kind = tokenString
+		i = quotedEnd(sql, i+1, '\'', d == DialectMySQL)
+	case (c == 'E' || c == 'e') && d == DialectPostgres && strings.HasPrefix(sql[i+1:], "'"):
+		kind = tokenString
+		i = quotedEnd(sql, i+2, '\'', true)
+	case c == '"':
+		if d == DialectMySQL {
+			kind = tokenString
+			i = quotedEnd(sql, i+1, '"', true)
+		} else {
+			kind = tokenQuotedIdent
+			i = quotedEnd(sql, i+1, '"', false)
+		}
+	case c == '`':
+		kind = tokenQuotedIdent
+		i = quotedEnd(sql, i+1, '`', false)
+	case c == '[' && (d == DialectTSQL || d == DialectSQLite):
+		kind = tokenQuotedIdent
+		i = quotedEnd(sql, i+1, ']', false)
+	case c == '$' && d != DialectMySQL && d != DialectTSQL && dollarTag(sql[i:]) != "":
+		tag := dollarTag(sql[i:])
+		kind = tokenDollarQuoted
+		if end := strings.Index(sql[i+len(tag):], tag); end >= 0 {
+			i += len(tag) + end + len(tag)
+		} else {
+			i = len(sql)
+		}
+	case c == ';':
+		kind = tokenSemicolon
+		i++
+	default:
+		r, size := utf8.DecodeRuneInString(sql[i:])
+		i += size
+		if r != '$' && isWordRune(r) {
+			kind = tokenWord
+			for i < len(sql) {
+				r, size := utf8.DecodeRuneInString(sql[i:])
+				if !isWordRune(r) {
+					break
+				}
+				i += size
+			}
+		}
+	}
+	return token{kind: kind, start: start, end: i}
+}
+
+// lineEnd returns the index of the line break that ends the line containing i, or
+// len(sql) if it is the last line.
+func lineEnd(sql string, i int) int {
+	if end := strings.IndexByte(sql[i:], '\n'); end >= 0 {
+		return i + end
+	}
+	return len(sql)
+}
+
+func blockCommentEnd(sql string, i int, nested bool) int {
+	depth := 0
+	for i < len(sql) {
+		switch {
+		case strings.HasPrefix(sql[i:], "/*"):
+			if depth == 0 || nested {
+				depth++
+			}
+			i += 2
+		case strings.HasPrefix(sql[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(sql)
+}
+
+// quotedEnd returns the index right after the quote that closes the literal whose
+// content starts at i. A doubled quote is an escaped quote and, if backslash is true, so
+// is a quote preceded by a backslash.
+func quotedEnd(sql string, i int, quote byte, backslash bool) int {
+	for i < len(sql) {
+		switch sql[i] {
+		case '\\':
+			if backslash {
+				i++
+			}
+		case quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i++
+			} else {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(sql)
+}
+
+// dollarTag returns the opening tag of the dollar-quoted string at the beginning of s,
+// like $$ or $body$, or an empty string if s does not start with one. Positional
+// parameters like $1 are not tags.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '$':
+			return s[:i+1]
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+		case c >= '0' && c <= '9' && i > 1:
+		default:
+			return ""
+		}
+	}
+	return ""
+}
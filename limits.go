@@ -0,0 +1,25 @@
+package sqload
+
+import "fmt"
+
+// checkQuerySizes checks that none of the queries is larger than the maximum size set in
+// cfg, if any, see WithMaxQuerySize. The headers of the queries, in headers, tell the
+// files they were read from.
+func checkQuerySizes(queries map[string]string, headers map[string]header, cfg *config) error {
+	if cfg.maxQuerySize <= 0 {
+		return nil
+	}
+	names := sortedKeys(queries)
+	for _, name := range names {
+		size := len(queries[name])
+		if size <= cfg.maxQuerySize {
+			continue
+		}
+		err := errorf(CodeCheckFailed, "%w: query %s has %d bytes, more than the maximum of %d", ErrCannotLoadQueries, name, size, cfg.maxQuerySize)
+		if h := headers[name]; h.file != "" {
+			return fmt.Errorf("%w (%s:%d)", err, h.file, h.line)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithMaxQuerySize(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("-- query: Ping\nSELECT 1;\n\n-- query: Dump\nINSERT INTO user VALUES (1), (2), (3);\n")},
+	}
+	testCases := []struct {
+		load    func() error
+		wantErr error
+	}{
+		{
+			func() error { _, err := LoadQuerySet(fsys, WithMaxQuerySize(1024)); return err },
+			nil,
+		},
+		{
+			func() error { _, err := LoadQuerySet(fsys); return err },
+			nil,
+		},
+		{
+			func() error { _, err := LoadQuerySet(fsys, WithMaxQuerySize(16)); return err },
+			fmt.Errorf("%w: query Dump has 38 bytes, more than the maximum of 16 (users.sql:4)", ErrCannotLoadQueries),
+		},
+		{
+			func() error {
+				_, err := LoadFromString[map[string]string]("-- query: Dump\nINSERT INTO user VALUES (1), (2), (3);", WithMaxQuerySize(16))
+				return err
+			},
+			fmt.Errorf("%w: query Dump has 38 bytes, more than the maximum of 16", ErrCannotLoadQueries),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if err := tc.load(); fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Errorf("got %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
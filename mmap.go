@@ -0,0 +1,70 @@
+package sqload
+
+import "unsafe"
+
+// parseMappedFile is like parseFile but it maps the file at path, named filename in the
+// errors and the headers, into memory instead of reading it, see WithMmap. The queries
+// and their headers are copied out of the mapping before it is released, so the contents
+// of the file are never held twice on the heap.
+func parseMappedFile(path, filename string, cfg *config) (map[string]string, map[string]header, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil && cfg.skipUnreadable {
+		cfg.warn(err)
+		return map[string]string{}, map[string]header{}, nil
+	}
+	if err != nil {
+		return nil, nil, errorf(CodeUnreadable, "%w: %s", ErrCannotLoadQueries, err)
+	}
+	defer unmap()
+	data, err = preprocess(filename, data, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The SQL code is only viewed as a string while it is parsed, see cloneParsed, so the
+	// queries are only passed to the OnQuery event once they are copied
+	sql := *(*string)(unsafe.Pointer(&data))
+	parseCfg := *cfg
+	parseCfg.events.OnQuery = nil
+	queries, headers, err := parseQueries(sql, filename, &parseCfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	queries, headers = cloneParsed(queries, headers)
+	cfg.emitQueries(queries, headers)
+	return queries, headers, nil
+}
+
+// cloneParsed returns a copy of the queries and the headers returned by parseQueries
+// that does not share memory with the SQL code they were parsed from.
+func cloneParsed(queries map[string]string, headers map[string]header) (map[string]string, map[string]header) {
+	clonedQueries := make(map[string]string, len(queries))
+	for name, sql := range queries {
+		clonedQueries[cloneString(name)] = cloneString(sql)
+	}
+	clonedHeaders := make(map[string]header, len(headers))
+	for name, h := range headers {
+		annotations := make(Annotations, len(h.annotations))
+		for key, values := range h.annotations {
+			for _, value := range values {
+				annotations[cloneString(key)] = append(annotations[cloneString(key)], cloneString(value))
+			}
+		}
+		h.annotations = annotations
+		if h.attrs != nil {
+			attrs := make(map[string]string, len(h.attrs))
+			for key, value := range h.attrs {
+				attrs[cloneString(key)] = cloneString(value)
+			}
+			h.attrs = attrs
+		}
+		h.doc = cloneString(h.doc)
+		clonedHeaders[cloneString(name)] = h
+	}
+	return clonedQueries, clonedHeaders
+}
+
+// cloneString returns a copy of s that does not share memory with it, like strings.Clone
+// of Go 1.20.
+func cloneString(s string) string {
+	return string([]byte(s))
+}
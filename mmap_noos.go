@@ -0,0 +1,12 @@
+//go:build sqload_noos
+
+package sqload
+
+import "errors"
+
+// mapFile fails, since the files of the operating system can not be read in the builds
+// with the sqload_noos tag. It is never called, since only LoadFromDir enables the
+// mapping of files, see WithMmap.
+func mapFile(path string) ([]byte, func() error, error) {
+	return nil, nil, errors.New("files can not be mapped into memory in this build")
+}
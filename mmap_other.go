@@ -0,0 +1,15 @@
+//go:build (!(darwin || dragonfly || freebsd || linux || netbsd || openbsd) || tinygo) && !sqload_noos
+
+package sqload
+
+import "os"
+
+// mapFile reads the file at path, since files can not be mapped into memory on this
+// platform, and returns its contents along with a function that does nothing.
+func mapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
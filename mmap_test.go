@@ -0,0 +1,70 @@
+//go:build !sqload_noos
+
+package sqload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadWithMmap(t *testing.T) {
+	for _, filename := range []string{"testdata/cat-queries.sql", "testdata/cat-queries.crlf.sql"} {
+		queries, err := LoadFromFile[map[string]string](filename, WithMmap())
+		if err != nil {
+			t.Fatalf("err must be nil, got %s", err)
+		}
+		if fmt.Sprint(*queries) != fmt.Sprint(CatTestQueries) {
+			t.Errorf("got %v, want %v", *queries, CatTestQueries)
+		}
+	}
+	want, err := LoadFromDir[map[string]string]("testdata/test-load-from-dir")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	got, err := LoadFromDir[map[string]string]("testdata/test-load-from-dir", WithMmap())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(*got) != fmt.Sprint(*want) {
+		t.Errorf("got %v, want %v", *got, *want)
+	}
+
+	dir := t.TempDir()
+	sql := "-- query: FindUser timeout=2s\n-- Finds a user.\n-- tags: users\nSELECT * FROM user;\n"
+	if err := os.WriteFile(filepath.Join(dir, "users.sql"), []byte(sql), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "empty.sql"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	star := WithPreprocessor(func(path string, data []byte) ([]byte, error) {
+		for i := range data {
+			if data[i] == '*' {
+				data[i] = '1'
+			}
+		}
+		return data, nil
+	})
+	meta, err := LoadFromDir[struct {
+		FindUser    string `query:"FindUser"`
+		FindUserDoc string `querydoc:"FindUser"`
+		Timeout     string `queryattr:"FindUser.timeout"`
+	}](dir, WithMmap(), star, WithAttributes("timeout"))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if meta.FindUser != "SELECT 1 FROM user;" || meta.FindUserDoc != "Finds a user." || meta.Timeout != "2s" {
+		t.Errorf("got %+v, want the query, its doc and its timeout", *meta)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "users.sql")); string(data) != sql {
+		t.Errorf("the preprocessor must not change the file, got %s", data)
+	}
+
+	_, err = LoadFromFile[map[string]string]("testdata/i-dont-exist.sql", WithMmap())
+	wantErr := fmt.Errorf("%w: open testdata/i-dont-exist.sql: no such file or directory", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %s, want %s", err, wantErr)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build (darwin || dragonfly || freebsd || linux || netbsd || openbsd) && !tinygo && !sqload_noos
+
+package sqload
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps the file at path into memory and returns its contents along with the
+// function that releases them. The mapping is private, so writing to the contents does
+// not change the file.
+func mapFile(path string) ([]byte, func() error, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		return nil, func() error { return nil }, nil
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+	if err != nil {
+		return nil, nil, &os.PathError{Op: "mmap", Path: path, Err: err}
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
@@ -0,0 +1,232 @@
+package sqload
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// modelField is a field of a model struct mapped to a column.
+type modelField struct {
+	column string
+	index  []int
+	pk     bool
+}
+
+// modelFields returns the fields of the struct type t mapped to columns, as described in
+// Columns. Unexported fields are skipped, and the fields of embedded structs are
+// included as if they were fields of t.
+func modelFields(t reflect.Type) ([]modelField, error) {
+	if t == nil {
+		return nil, fmt.Errorf("nil is not a struct")
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct", t)
+	}
+	fields := []modelField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" && field.Type.Kind() == reflect.Struct {
+			embedded, err := modelFields(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range embedded {
+				f.index = append([]int{i}, f.index...)
+				fields = append(fields, f)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		tagOptions := strings.Split(tag, ",")
+		column := tagOptions[0]
+		if column == "" {
+			column = strings.ToLower(field.Name)
+		}
+		pk := false
+		for _, option := range tagOptions[1:] {
+			pk = pk || option == "pk"
+		}
+		fields = append(fields, modelField{column: column, index: field.Index, pk: pk})
+	}
+	return fields, nil
+}
+
+// primaryKey returns the index in fields of the primary key, or -1 if there is none.
+func primaryKey(fields []modelField) int {
+	pk := -1
+	for i, field := range fields {
+		if field.pk {
+			return i
+		}
+		if field.column == "id" && pk == -1 {
+			pk = i
+		}
+	}
+	return pk
+}
+
+// Columns returns the names of the columns of the model struct (or pointer to struct)
+// model. The column name of each field is taken from its db tag, as sqlx does, or is the
+// lowercased field name if the tag is missing; fields tagged with db:"-" are skipped. The
+// primary key is the column whose tag has the pk option (db:"user_id,pk") or, if there
+// is none, the id column.
+//
+// Columns is also available as the columns function of the templates (see
+// WithTemplateData), so SELECT lists can be kept in sync with the models:
+//
+//	-- query: FindUserById
+//	SELECT {{columns .User "u"}} FROM user u WHERE u.id = :id;
+func Columns(model any) ([]string, error) {
+	fields, err := modelFields(reflect.TypeOf(model))
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.column
+	}
+	return columns, nil
+}
+
+// ScanDest returns pointers to the fields of the struct pointed by ptr, in the same order
+// as the columns returned by Columns, ready to be passed to Scan.
+//
+//	var u User
+//	err := db.QueryRow(Q.FindUserById, id).Scan(sqload.MustScanDest(&u)...)
+func ScanDest(ptr any) ([]any, error) {
+	value := reflect.ValueOf(ptr)
+	if value.Kind() != reflect.Pointer || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a pointer to a struct", ptr)
+	}
+	fields, err := modelFields(value.Type())
+	if err != nil {
+		return nil, err
+	}
+	elem := value.Elem()
+	dest := make([]any, len(fields))
+	for i, field := range fields {
+		dest[i] = elem.FieldByIndex(field.index).Addr().Interface()
+	}
+	return dest, nil
+}
+
+// MustScanDest is like ScanDest but panics if any error occurs.
+func MustScanDest(ptr any) []any {
+	dest, err := ScanDest(ptr)
+	if err != nil {
+		panic(err)
+	}
+	return dest
+}
+
+// columnsFunc is the columns function of the templates. It returns the comma-separated
+// column list of model, qualified with the table alias if one is given.
+func columnsFunc(model any, alias ...string) (string, error) {
+	columns, err := Columns(model)
+	if err != nil {
+		return "", err
+	}
+	if len(alias) > 0 && alias[0] != "" {
+		for i := range columns {
+			columns[i] = alias[0] + "." + columns[i]
+		}
+	}
+	return strings.Join(columns, ", "), nil
+}
+
+// GenerateInsert returns an INSERT statement for the model struct model into the table
+// table. Every column except the primary key, which is usually generated by the
+// database, is inserted using a named parameter with the name of the column.
+//
+//	sqload.GenerateInsert("user", User{})
+//	// INSERT INTO user (first_name, email) VALUES (:first_name, :email);
+func GenerateInsert(table string, model any) (string, error) {
+	fields, err := modelFields(reflect.TypeOf(model))
+	if err != nil {
+		return "", err
+	}
+	pk := primaryKey(fields)
+	columns := []string{}
+	params := []string{}
+	for i, field := range fields {
+		if i != pk {
+			columns = append(columns, field.column)
+			params = append(params, ":"+field.column)
+		}
+	}
+	if len(columns) == 0 {
+		return "", fmt.Errorf("%T has no columns to insert", model)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", table, strings.Join(columns, ", "), strings.Join(params, ", ")), nil
+}
+
+// GenerateUpdate returns an UPDATE statement for the model struct model in the table
+// table. Every column except the primary key is set, and the row is selected by its
+// primary key, all using named parameters with the names of the columns. The model must
+// have a primary key, see Columns.
+//
+//	sqload.GenerateUpdate("user", User{})
+//	// UPDATE user SET first_name = :first_name, email = :email WHERE id = :id;
+func GenerateUpdate(table string, model any) (string, error) {
+	fields, err := modelFields(reflect.TypeOf(model))
+	if err != nil {
+		return "", err
+	}
+	pk := primaryKey(fields)
+	if pk == -1 {
+		return "", fmt.Errorf("%T has no primary key", model)
+	}
+	assignments := []string{}
+	for i, field := range fields {
+		if i != pk {
+			assignments = append(assignments, field.column+" = :"+field.column)
+		}
+	}
+	if len(assignments) == 0 {
+		return "", fmt.Errorf("%T has no columns to update", model)
+	}
+	column := fields[pk].column
+	return fmt.Sprintf("UPDATE %s SET %s WHERE %s = :%s;", table, strings.Join(assignments, ", "), column, column), nil
+}
+
+// registeredModel is a model registered with the WithModel option.
+type registeredModel struct {
+	table string
+	value any
+}
+
+// generateModelQueries returns the queries generated for the models registered in cfg,
+// named Insert<Model> and Update<Model> after the name of the model type.
+func generateModelQueries(cfg *config) (map[string]string, error) {
+	queries := map[string]string{}
+	for _, m := range cfg.models {
+		t := reflect.TypeOf(m.value)
+		if t == nil {
+			return nil, errorf(CodeInvalidOption, "%w: the model of table %s is nil", ErrCannotLoadQueries, m.table)
+		}
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		insert, err := GenerateInsert(m.table, m.value)
+		if err != nil {
+			return nil, errorf(CodeExpansionFailed, "%w: %s", ErrCannotLoadQueries, err)
+		}
+		queries["Insert"+t.Name()] = insert
+		update, err := GenerateUpdate(m.table, m.value)
+		if err != nil {
+			return nil, errorf(CodeExpansionFailed, "%w: %s", ErrCannotLoadQueries, err)
+		}
+		queries["Update"+t.Name()] = update
+	}
+	return queries, nil
+}
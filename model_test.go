@@ -0,0 +1,199 @@
+package sqload
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type TestAudit struct {
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
+
+type TestUser struct {
+	Id        int64  `db:"id"`
+	FirstName string `db:"first_name"`
+	Email     string
+	Password  string `db:"-"`
+	TestAudit
+	internal string
+}
+
+func TestColumns(t *testing.T) {
+	columns, err := Columns(TestUser{})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "[id first_name email created_at updated_at]"
+	if fmt.Sprint(columns) != want {
+		t.Errorf("got %v, want %s", columns, want)
+	}
+	columns, err = Columns(&TestUser{})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(columns) != want {
+		t.Errorf("got %v, want %s", columns, want)
+	}
+	if _, err := Columns(1); err == nil {
+		t.Error("err is nil")
+	}
+}
+
+func TestScanDest(t *testing.T) {
+	u := TestUser{}
+	dest, err := ScanDest(&u)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if len(dest) != 5 {
+		t.Fatalf("got %d destinations, want 5", len(dest))
+	}
+	*dest[0].(*int64) = 7
+	*dest[1].(*string) = "Ernesto"
+	*dest[4].(*time.Time) = time.Unix(0, 0)
+	if u.Id != 7 || u.FirstName != "Ernesto" || !u.UpdatedAt.Equal(time.Unix(0, 0)) {
+		t.Errorf("destinations do not point to the fields of u: %+v", u)
+	}
+	if _, err := ScanDest(u); err == nil {
+		t.Error("err is nil")
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("function did not panic")
+			}
+		}()
+		MustScanDest(nil)
+	}()
+}
+
+func TestColumnsTemplate(t *testing.T) {
+	sql := strings.TrimSpace(`
+-- query: FindUserById
+SELECT {{columns .User "u"}} FROM user u WHERE u.id = :id;
+-- query: FindAllUsers
+SELECT {{columns .User}} FROM user;
+`)
+	q, err := LoadFromString[struct {
+		FindUserById string `query:"FindUserById"`
+		FindAllUsers string `query:"FindAllUsers"`
+	}](sql, WithTemplateData(map[string]any{"User": TestUser{}}))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "SELECT u.id, u.first_name, u.email, u.created_at, u.updated_at FROM user u WHERE u.id = :id;"
+	if q.FindUserById != want {
+		t.Errorf("got %s, want %s", q.FindUserById, want)
+	}
+	want = "SELECT id, first_name, email, created_at, updated_at FROM user;"
+	if q.FindAllUsers != want {
+		t.Errorf("got %s, want %s", q.FindAllUsers, want)
+	}
+	// Models that were not provided make the load fail
+	_, err = LoadFromString[struct{}](sql, WithTemplateData(map[string]any{"Cat": TestUser{}}))
+	if err == nil {
+		t.Error("err is nil")
+	}
+}
+
+func TestGenerateInsertAndUpdate(t *testing.T) {
+	type Cat struct {
+		Name  string `db:"name"`
+		Color string `db:"color"`
+		Code  string `db:"code,pk"`
+		Id    int    `db:"id"`
+	}
+	type Log struct {
+		Message string `db:"message"`
+	}
+	testCases := []struct {
+		model      any
+		wantInsert string
+		wantUpdate string
+	}{
+		{
+			TestUser{},
+			"INSERT INTO user (first_name, email, created_at, updated_at) VALUES (:first_name, :email, :created_at, :updated_at);",
+			"UPDATE user SET first_name = :first_name, email = :email, created_at = :created_at, updated_at = :updated_at WHERE id = :id;",
+		},
+		{
+			&Cat{},
+			"INSERT INTO user (name, color, id) VALUES (:name, :color, :id);",
+			"UPDATE user SET name = :name, color = :color, id = :id WHERE code = :code;",
+		},
+		{
+			Log{},
+			"INSERT INTO user (message) VALUES (:message);",
+			"",
+		},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			insert, err := GenerateInsert("user", testCase.model)
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if insert != testCase.wantInsert {
+				t.Errorf("got %s, want %s", insert, testCase.wantInsert)
+			}
+			update, err := GenerateUpdate("user", testCase.model)
+			if testCase.wantUpdate == "" {
+				if err == nil {
+					t.Fatal("err is nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if update != testCase.wantUpdate {
+				t.Errorf("got %s, want %s", update, testCase.wantUpdate)
+			}
+		})
+	}
+}
+
+func TestWithModel(t *testing.T) {
+	sql := strings.TrimSpace(`
+-- query: UpdateTestUser
+UPDATE user SET email = :email WHERE id = :id;
+-- query: FindTestUser
+SELECT {{columns .TestUser}} FROM user WHERE id = :id;
+`)
+	q, err := LoadFromString[struct {
+		InsertTestUser string `query:"InsertTestUser"`
+		UpdateTestUser string `query:"UpdateTestUser"`
+		FindTestUser   string `query:"FindTestUser"`
+	}](sql, WithModel("user", TestUser{}), WithTemplateFuncs(nil))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "INSERT INTO user (first_name, email, created_at, updated_at) VALUES (:first_name, :email, :created_at, :updated_at);"
+	if q.InsertTestUser != want {
+		t.Errorf("got %s, want %s", q.InsertTestUser, want)
+	}
+	// Queries from the source take precedence over generated ones
+	want = "UPDATE user SET email = :email WHERE id = :id;"
+	if q.UpdateTestUser != want {
+		t.Errorf("got %s, want %s", q.UpdateTestUser, want)
+	}
+	want = "SELECT id, first_name, email, created_at, updated_at FROM user WHERE id = :id;"
+	if q.FindTestUser != want {
+		t.Errorf("got %s, want %s", q.FindTestUser, want)
+	}
+	_, err = LoadFromString[struct{}]("", WithModel("user", 1))
+	if err == nil {
+		t.Error("err is nil")
+	}
+	_, err = LoadFromString[struct{}]("", WithModel("user", nil))
+	wantErr := fmt.Errorf("%w: the model of table user is nil", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %s, want %s", err, wantErr)
+	}
+	if ErrorCode(err) != CodeInvalidOption {
+		t.Errorf("got %s, want %s", ErrorCode(err), CodeInvalidOption)
+	}
+}
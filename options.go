@@ -0,0 +1,540 @@
+package sqload
+
+import (
+	"io/fs"
+	"reflect"
+	"text/template"
+)
+
+// Option configures how the Load functions read and bind queries.
+type Option func(*config)
+
+type config struct {
+	registered         bool
+	publish            bool
+	history            int
+	keepComments       bool
+	dialect            Dialect
+	paramStyles        []ParamStyle
+	templates          bool
+	templateFuncs      template.FuncMap
+	templateData       map[string]any
+	data               map[string]any // values of the references of the queries, see LoadFromFSWithData
+	models             []registeredModel
+	transforms         []Transform
+	preprocessors      []Preprocessor
+	annotationHandlers []AnnotationHandler
+	validators         []Validator
+	policies           []Policy
+	includeTags        []string
+	excludeTags        []string
+	attributes         []string // keys of the attributes of the query comments, see WithAttributes
+	prefix             string
+	dirNamespaces      bool
+	allFiles           bool
+	fileFilters        []func(path string, d fs.DirEntry) bool
+	locale             string
+	engines            []string
+	deprecationHandler func(alias, name string)
+	mmap               bool
+	maxLineSize        int
+	maxQuerySize       int
+	skipUnreadable     bool
+	report             *LoadReport
+	partial            bool            // whether the fields that can not be bound are skipped
+	missing            map[string]bool // queries not found while binding a struct, see config.fail
+	events             ParseEvents
+	redactErrors       bool
+
+	// dir is the directory of the operating system the file system being loaded is
+	// rooted at, or an empty string if it is not known. It is set by LoadFromDir.
+	dir string
+
+	// headers are the headers of the queries being bound, by name, see bindAttrField.
+	headers map[string]header
+
+	// dialectQueries returns the queries for the dialect d, for the fields that override
+	// the dialect. It is only set by the Load functions that read files.
+	dialectQueries func(d Dialect) (map[string]string, error)
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithRegistered makes the Load functions merge the queries added with Register and
+// RegisterMap with the queries read from the source. If a query is defined in both
+// places, the one from the source wins.
+func WithRegistered() Option {
+	return func(cfg *config) {
+		cfg.registered = true
+	}
+}
+
+// WithPublish makes the Load functions add every loaded query to the process-wide
+// registry once the load succeeds, so it can be retrieved by name with Lookup.
+func WithPublish() Option {
+	return func(cfg *config) {
+		cfg.publish = true
+	}
+}
+
+// WithKeepComments makes the Load functions keep the -- comment lines of the queries,
+// including their doc comments. By default those lines are removed from the SQL code.
+// Block comments, like optimizer hints, are kept either way.
+func WithKeepComments() Option {
+	return func(cfg *config) {
+		cfg.keepComments = true
+	}
+}
+
+// WithDialect sets the dialect the queries are written in. It is used wherever SQL code
+// has to be tokenized, for example to split the statements loaded into []string fields.
+// By default DialectGeneric is used.
+func WithDialect(d Dialect) Option {
+	return func(cfg *config) {
+		cfg.dialect = d
+	}
+}
+
+// WithParamStyles sets the syntaxes recognized for named parameters, for example to
+// accept @name besides :name. By default only ParamColon is recognized.
+func WithParamStyles(styles ...ParamStyle) Option {
+	return func(cfg *config) {
+		cfg.paramStyles = styles
+	}
+}
+
+// WithTemplateFuncs enables the expansion of the queries as text/template templates at
+// load time and makes the functions in funcs available to them, so the SQL idioms of an
+// organization can be written once:
+//
+//	-- query: FindActiveUsers
+//	SELECT * FROM user WHERE {{notDeleted "user"}};
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithTemplateFuncs(template.FuncMap{
+//		"notDeleted": func(table string) string { return table + ".deleted_at IS NULL" },
+//	}))
+//
+// Templates are parsed when the queries are loaded, so a query calling a function that
+// was not registered makes the load fail. It can be used several times; the functions
+// are merged.
+//
+// Besides the functions in funcs, the templates can use the columns function, see
+// Columns.
+func WithTemplateFuncs(funcs template.FuncMap) Option {
+	return func(cfg *config) {
+		cfg.templates = true
+		if cfg.templateFuncs == nil {
+			cfg.templateFuncs = template.FuncMap{}
+		}
+		for name, fn := range funcs {
+			cfg.templateFuncs[name] = fn
+		}
+	}
+}
+
+// WithTemplateData enables the expansion of the queries as text/template templates at
+// load time (see WithTemplateFuncs) and makes data available to them as the dot. It is
+// mostly useful to pass the models used by the columns function:
+//
+//	-- query: FindUserById
+//	SELECT {{columns .User}} FROM user WHERE id = :id;
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithTemplateData(map[string]any{
+//		"User": User{},
+//	}))
+//
+// It can be used several times; the entries are merged.
+func WithTemplateData(data map[string]any) Option {
+	return func(cfg *config) {
+		cfg.templates = true
+		if cfg.templateData == nil {
+			cfg.templateData = map[string]any{}
+		}
+		for key, value := range data {
+			cfg.templateData[key] = value
+		}
+	}
+}
+
+// WithModel generates the queries Insert<Model> and Update<Model>, named after the type of
+// the model struct model, for the table table (see GenerateInsert and GenerateUpdate),
+// so the .sql files only need to hold the interesting queries. Queries with the same
+// names in the source take precedence over the generated ones.
+//
+//	q, err := sqload.LoadFromFS[struct {
+//		InsertUser string `query:"InsertUser"`
+//		UpdateUser string `query:"UpdateUser"`
+//	}](fsys, sqload.WithModel("user", User{}))
+//
+// The model is also added to the data of the templates under the name of its type, so
+// it can be used with the columns function.
+func WithModel(table string, m any) Option {
+	return func(cfg *config) {
+		cfg.models = append(cfg.models, registeredModel{table: table, value: m})
+		t := reflect.TypeOf(m)
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t != nil {
+			if cfg.templateData == nil {
+				cfg.templateData = map[string]any{}
+			}
+			cfg.templateData[t.Name()] = m
+		}
+	}
+}
+
+// WithTransform adds the transforms in transforms to the ones applied to every query at
+// load time. Transforms run in the order they were added, after every other step of the
+// load, so they see the final SQL code of the queries:
+//
+//	tagQueries := func(name, sql string) (string, error) {
+//		return "/* " + name + " */ " + sql, nil
+//	}
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithTransform(tagQueries))
+//
+// It can be used several times.
+func WithTransform(transforms ...Transform) Option {
+	return func(cfg *config) {
+		cfg.transforms = append(cfg.transforms, transforms...)
+	}
+}
+
+// WithPreprocessor adds the preprocessors in preprocessors to the ones applied to the
+// contents of every file read by the Load functions, before its queries are parsed.
+// Preprocessors run in the order they were added. They are not applied to the strings
+// given to LoadFromString.
+//
+//	stripHeader := func(path string, data []byte) ([]byte, error) {
+//		_, body, _ := bytes.Cut(data, []byte("-- END HEADER\n"))
+//		return body, nil
+//	}
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithPreprocessor(stripHeader))
+//
+// It can be used several times.
+func WithPreprocessor(preprocessors ...Preprocessor) Option {
+	return func(cfg *config) {
+		cfg.preprocessors = append(cfg.preprocessors, preprocessors...)
+	}
+}
+
+// WithAnnotationHandler adds the handlers in handlers to the ones that receive the
+// annotations of the queries at load time, see AnnotationHandler. If two handlers handle
+// the same key, both receive the annotation and the value of the last one is kept.
+func WithAnnotationHandler(handlers ...AnnotationHandler) Option {
+	return func(cfg *config) {
+		cfg.annotationHandlers = append(cfg.annotationHandlers, handlers...)
+	}
+}
+
+// WithValidator adds the validators in validators to the ones run on every query at load
+// time, so the house rules of a team are enforced when the queries are loaded:
+//
+//	requireDoc := func(q sqload.Query) error {
+//		if q.Doc == "" {
+//			return errors.New("the query has no doc comment")
+//		}
+//		return nil
+//	}
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithValidator(requireDoc))
+//
+// It can be used several times.
+func WithValidator(validators ...Validator) Option {
+	return func(cfg *config) {
+		cfg.validators = append(cfg.validators, validators...)
+	}
+}
+
+// WithPolicy makes the Load functions fail if any query violates a rule of the policy p,
+// see Policy. It can be used several times; all the policies are checked.
+func WithPolicy(p Policy) Option {
+	return func(cfg *config) {
+		cfg.policies = append(cfg.policies, p)
+	}
+}
+
+// WithTags makes the Load functions keep only the queries tagged with any of the tags in
+// tags, so different programs can load different subsets of the same SQL files:
+//
+//	-- query: MonthlyRevenue
+//	-- tags: reporting, slow
+//	SELECT ...
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithTags("reporting"))
+//
+// Tags are compared without regard to case. It can be used several times; the tags are
+// merged.
+func WithTags(tags ...string) Option {
+	return func(cfg *config) {
+		cfg.includeTags = append(cfg.includeTags, tags...)
+	}
+}
+
+// WithAttributes declares the keys of the attributes that the query comments can have
+// besides variant (see Query.Attributes), so a misspelled key, like varient=fast, is
+// reported instead of turning a variant into a second default version of its query:
+//
+//	-- query: FindUser timeout=2s owner=payments
+//	SELECT ...
+//
+//	qs, err := sqload.LoadQuerySet(fsys, sqload.WithAttributes("timeout", "owner"))
+//
+// If a query comment has an attribute whose key was not declared, the Load functions
+// will return an error. It can be used several times; the keys are merged.
+func WithAttributes(keys ...string) Option {
+	return func(cfg *config) {
+		cfg.attributes = append(cfg.attributes, keys...)
+	}
+}
+
+// WithoutTags makes the Load functions skip the queries tagged with any of the tags in
+// tags, see WithTags. It can be used several times; the tags are merged.
+func WithoutTags(tags ...string) Option {
+	return func(cfg *config) {
+		cfg.excludeTags = append(cfg.excludeTags, tags...)
+	}
+}
+
+// WithPrefix makes the Load functions that bind queries into a struct consider only the
+// queries whose names start with prefix, and match the tags of the struct against their
+// names without the prefix. It lets several packages bind non-overlapping views of the
+// same SQL files:
+//
+//	-- query: billing.FindInvoice
+//	SELECT * FROM invoice WHERE id = :id;
+//
+//	q, err := sqload.LoadFromFS[struct {
+//		FindInvoice string `query:"FindInvoice"`
+//	}](fsys, sqload.WithPrefix("billing."))
+func WithPrefix(prefix string) Option {
+	return func(cfg *config) {
+		cfg.prefix = prefix
+	}
+}
+
+// WithDirNamespaces makes the queries of the files in each top-level subdirectory of
+// the file system belong to a namespace named after the directory, so sql/users/find.sql
+// defines users.FindUser instead of FindUser. Along with the binding of nested structs
+// to namespaces (see LoadFromFS), it organizes large projects without writing the
+// namespaces by hand:
+//
+//	q, err := sqload.LoadFromFS[struct {
+//		Users struct {
+//			FindUser string `query:"FindUser"`
+//		}
+//	}](fsys, sqload.WithDirNamespaces())
+//
+// The names of the directories must be valid query names. It has no effect on
+// LoadFromString and LoadFromFile.
+func WithDirNamespaces() Option {
+	return func(cfg *config) {
+		cfg.dirNamespaces = true
+	}
+}
+
+// WithAllFiles makes the Load functions that read file systems (see LoadFromFS) read all
+// the files instead of only the .sql files, for the projects that store each
+// query in a file named after it, without an extension. A file without query comments
+// holds a single query named after the file, without its extension:
+//
+//	sql
+//	├── FindUser
+//	└── users
+//	    └── DeleteUser
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithAllFiles())
+//
+// The hidden files and directories, whose names start with a dot, are skipped. The names
+// of the files holding a single query must be valid query names. LoadFromFile also reads
+// a file without query comments as a single query with this option.
+func WithAllFiles() Option {
+	return func(cfg *config) {
+		cfg.allFiles = true
+	}
+}
+
+// WithFileFilter makes the Load functions that read file systems (see LoadFromFS) only
+// read the files accepted by filter, which is called with the path of every file they
+// would read, as fs.WalkDir calls its function, and decides with any logic of its own:
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithFileFilter(func(path string, d fs.DirEntry) bool {
+//		return !strings.HasSuffix(path, "_draft.sql")
+//	}))
+//
+// The filter is also called with every directory below the root, and the directories it
+// rejects are skipped. Using this option several times adds filters, and a file is only
+// read if all of them accept it. The filters choose the files before the engine and
+// locale versions are chosen, see WithEngines and WithLocale.
+func WithFileFilter(filter func(path string, d fs.DirEntry) bool) Option {
+	return func(cfg *config) {
+		cfg.fileFilters = append(cfg.fileFilters, filter)
+	}
+}
+
+// WithEngines sets the chain of engines whose files are preferred by the Load functions
+// that read files, see LoadFromFS. For every file, the version named after the first
+// engine of the chain that has one is loaded and, if none has, the version without an
+// engine suffix. So most queries are written once and only the ones that really differ
+// get a version for a specific engine:
+//
+//	sql
+//	├── users.sql
+//	├── users.postgres.sql
+//	└── users.cockroach.sql
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys,
+//		sqload.WithDialect(sqload.DialectPostgres),
+//		sqload.WithEngines("cockroach", "postgres"),
+//	)
+//
+// The engines can be named after the dialects or have any other name, like cockroach.
+// The dialect set with WithDialect is still the one used to parse the queries. By
+// default, the chain only holds that dialect.
+func WithEngines(engines ...string) Option {
+	return func(cfg *config) {
+		cfg.engines = engines
+	}
+}
+
+// WithLocale makes the Load functions that read files prefer the versions of the files
+// for the locale, or tenant, locale. A file with a suffix, like welcome_email.de.sql, is
+// the version for that locale of the file without it, welcome_email.sql, which is loaded
+// for the other locales. A locale with a region, like de-AT, falls back to its language:
+//
+//	sql
+//	├── welcome_email.sql
+//	├── welcome_email.de.sql
+//	└── welcome_email.fr.sql
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithLocale("de-AT"))
+//	// loads welcome_email.de.sql
+//
+// Without WithLocale, all the files are loaded. It has no effect on LoadFromString and
+// LoadFromFile.
+func WithLocale(locale string) Option {
+	return func(cfg *config) {
+		cfg.locale = locale
+	}
+}
+
+// WithDeprecationHandler makes handler be called every time a query is looked up by one
+// of its aliases (see Query.Aliases), with the alias and the current name of the query,
+// either when a struct field is bound to it by the Load functions or when it is looked up
+// in a QuerySet. It can be used to log a warning and find the code that still uses the
+// old names:
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithDeprecationHandler(func(alias, name string) {
+//		log.Printf("query %s is deprecated, use %s instead", alias, name)
+//	}))
+func WithDeprecationHandler(handler func(alias, name string)) Option {
+	return func(cfg *config) {
+		cfg.deprecationHandler = handler
+	}
+}
+
+// WithMmap makes LoadFromFile and LoadFromDir map the .sql files into memory instead of
+// reading them, on the platforms that support it (falling back to reading them on the
+// others), so loading very large generated files does not hold their contents twice on
+// the heap. Only the SQL code of the queries is copied out of each file. It has no effect
+// on the other Load functions.
+//
+// The contents given to the preprocessors are a private mapping of the file: they can be
+// changed without changing the file.
+func WithMmap() Option {
+	return func(cfg *config) {
+		cfg.mmap = true
+	}
+}
+
+// WithMaxLineSize sets the length, in bytes, of the longest line LoadFromReader accepts;
+// longer lines make it fail. It is 1 MiB by default. It has no effect on the other Load
+// functions, which read whole files.
+func WithMaxLineSize(n int) Option {
+	return func(cfg *config) {
+		cfg.maxLineSize = n
+	}
+}
+
+// WithMaxQuerySize makes the Load functions fail if the SQL code of any query is larger
+// than n bytes, naming the query and the file it was read from, so a data dump saved as a
+// .sql file by mistake is not embedded as a query.
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithMaxQuerySize(64<<10))
+func WithMaxQuerySize(n int) Option {
+	return func(cfg *config) {
+		cfg.maxQuerySize = n
+	}
+}
+
+// WithReport makes the Load functions add to report the problems they find that do not
+// make them fail, like the files skipped with WithSkipUnreadable and the queries defined
+// in more than one file. The problems of each load are appended to the ones already in
+// report.
+//
+//	var report sqload.LoadReport
+//	q, err := sqload.LoadFromDir[Queries]("sql", sqload.WithSkipUnreadable(), sqload.WithReport(&report))
+//	for _, w := range report.Warnings {
+//		log.Printf("warning: %s", w)
+//	}
+func WithReport(report *LoadReport) Option {
+	return func(cfg *config) {
+		cfg.report = report
+	}
+}
+
+// WithSkipUnreadable makes the Load functions that read a tree of files skip the files
+// and the directories that can not be read, for example because of their permissions,
+// instead of failing, so a stray file can not take a whole program down. The skipped
+// files are reported as warnings, see WithReport. The root of the tree must still be
+// readable.
+func WithSkipUnreadable() Option {
+	return func(cfg *config) {
+		cfg.skipUnreadable = true
+	}
+}
+
+// WithParseEvents makes the Load functions call the functions in events while they parse
+// the queries, so tools like editors, indexers and custom validators can follow the
+// parsing without parsing the files themselves:
+//
+//	_, err := sqload.LoadQuerySet(fsys, sqload.WithParseEvents(sqload.ParseEvents{
+//		OnQuery: func(file string, line int, q sqload.Query) {
+//			index.Add(q.Name, file, line)
+//		},
+//	}))
+func WithParseEvents(events ParseEvents) Option {
+	return func(cfg *config) {
+		cfg.events = events
+	}
+}
+
+// WithHistory makes a QueryStore retain the n previous QuerySets so they can be
+// restored with Rollback. It has no effect on the Load functions.
+func WithHistory(n int) Option {
+	return func(cfg *config) {
+		cfg.history = n
+	}
+}
+
+// WithRedactedErrors keeps the SQL code of the queries out of the errors returned by the
+// Load functions, for environments where queries are sensitive and must not reach shared
+// logging systems. The package never writes SQL code into its own errors, but the errors
+// of the code it calls can quote it: the templates, the transforms, the preprocessors,
+// the annotation handlers, the validators and the fields that set their own SQL code
+// (see SQLSetter). With this option the messages of those errors are replaced by
+// "details redacted", so the errors only tell the query, the file or the field they come
+// from:
+//
+//	q, err := sqload.LoadFromFS[Queries](fsys, sqload.WithRedactedErrors())
+//	// cannot load queries: query FindUser: details redacted
+func WithRedactedErrors() Option {
+	return func(cfg *config) {
+		cfg.redactErrors = true
+	}
+}
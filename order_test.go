@@ -0,0 +1,73 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAppendOrderBy(t *testing.T) {
+	allowed := []string{"created_at", "name"}
+	testCases := []struct {
+		base      string
+		column    string
+		direction string
+		want      string
+		wantErr   string
+	}{
+		{"SELECT * FROM user", "name", "", "SELECT * FROM user ORDER BY name", ""},
+		{"SELECT * FROM user;\n", "created_at", "desc", "SELECT * FROM user ORDER BY created_at DESC;", ""},
+		{"SELECT * FROM user ORDER BY org;", "name", "Asc", "SELECT * FROM user ORDER BY org, name ASC;", ""},
+		{"SELECT * FROM user -- newest first", "created_at", "desc", "SELECT * FROM user ORDER BY created_at DESC", ""},
+		{"SELECT * FROM user ORDER BY org; -- by org\n", "name", "", "SELECT * FROM user ORDER BY org, name;", ""},
+		{
+			"SELECT * FROM (SELECT * FROM user ORDER BY org) u",
+			"name", "ASC",
+			"SELECT * FROM (SELECT * FROM user ORDER BY org) u ORDER BY name ASC", "",
+		},
+		{"SELECT * FROM user;", "password", "", "", "column password is not orderable"},
+		{"SELECT * FROM user;", "name; DROP TABLE user", "", "", "column name; DROP TABLE user is not orderable"},
+		{"SELECT * FROM user;", "name", "desc; DROP TABLE user", "", "invalid sort direction desc; DROP TABLE user"},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			sql, err := AppendOrderBy(testCase.base, DialectGeneric, testCase.column, testCase.direction, allowed)
+			if testCase.wantErr != "" {
+				if fmt.Sprint(err) != testCase.wantErr {
+					t.Fatalf("got %v, want %s", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if sql != testCase.want {
+				t.Errorf("got %s, want %s", sql, testCase.want)
+			}
+		})
+	}
+}
+
+func TestQuerySetOrderBy(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("-- query: FindUsers\n-- Finds the users of an org.\n-- orderable: created_at, name\nSELECT * FROM user WHERE org = :org;\n\n-- query: FindCats\nSELECT * FROM cat;")},
+	}
+	qs, err := LoadQuerySet(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	sql, err := qs.OrderBy("FindUsers", "name", "asc")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "SELECT * FROM user WHERE org = :org ORDER BY name ASC;"
+	if sql != want {
+		t.Errorf("got %s, want %s", sql, want)
+	}
+	if _, err := qs.OrderBy("FindCats", "name", ""); fmt.Sprint(err) != "column name is not orderable" {
+		t.Errorf("got %v, want %s", err, "column name is not orderable")
+	}
+	if _, err := qs.OrderBy("FindDogs", "name", ""); err == nil {
+		t.Error("err is nil")
+	}
+}
@@ -0,0 +1,197 @@
+package sqload
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParamStyle is a syntax for named parameters in SQL code.
+type ParamStyle int
+
+const (
+	// ParamColon is the :name syntax, used by sqlx, Oracle and SQLite, among others.
+	ParamColon ParamStyle = iota
+	// ParamAt is the @name syntax, used by BigQuery, Spanner and SQL Server.
+	ParamAt
+)
+
+var paramPrefixes = map[ParamStyle]string{
+	ParamColon: ":",
+	ParamAt:    "@",
+}
+
+// param is an occurrence of a named parameter in some SQL code.
+type param struct {
+	name  string
+	start int // index of the prefix
+	end   int
+}
+
+// findParams returns the occurrences of named parameters written with any of the styles
+// in the SQL code sql. Text inside literals and comments, casts like a::int and
+// variables like @@version are ignored. If styles is empty, ParamColon is used.
+func findParams(sql string, d Dialect, styles []ParamStyle) []param {
+	if len(styles) == 0 {
+		styles = []ParamStyle{ParamColon}
+	}
+	prefixes := ""
+	for _, style := range styles {
+		prefixes += paramPrefixes[style]
+	}
+	params := []param{}
+	tokens := tokenize(sql, d)
+	for i := 0; i+1 < len(tokens); i++ {
+		prefix, name := tokens[i], tokens[i+1]
+		if prefix.kind != tokenOther || prefix.end-prefix.start != 1 || !strings.Contains(prefixes, prefix.text(sql)) {
+			continue
+		}
+		if name.kind != tokenWord || name.start != prefix.end {
+			continue
+		}
+		if c := sql[name.start]; c >= '0' && c <= '9' {
+			continue
+		}
+		if i > 0 {
+			previous := tokens[i-1]
+			if previous.end == prefix.start && (previous.kind == tokenWord || previous.text(sql) == prefix.text(sql)) {
+				continue
+			}
+		}
+		params = append(params, param{name: name.text(sql), start: prefix.start, end: name.end})
+		i++
+	}
+	return params
+}
+
+// ExtractParams returns the names of the named parameters in the SQL code sql written in
+// dialect d, in order of first appearance and without repetitions. Only the syntaxes in
+// styles are recognized; if styles is empty, ParamColon is used.
+//
+//	sqload.ExtractParams("SELECT * FROM user WHERE id = :id AND org = :org", sqload.DialectGeneric)
+//	// [id org]
+//	sqload.ExtractParams("SELECT * FROM user WHERE id = @id", sqload.DialectGeneric, sqload.ParamAt)
+//	// [id]
+func ExtractParams(sql string, d Dialect, styles ...ParamStyle) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, p := range findParams(sql, d, styles) {
+		if !seen[p.name] {
+			seen[p.name] = true
+			names = append(names, p.name)
+		}
+	}
+	return names
+}
+
+// BindParams rewrites the named parameters of the SQL code sql, written in dialect d,
+// into the positional placeholders of that dialect and returns the rewritten code along
+// with the values of args in the order the placeholders expect them. The placeholders
+// are $1, $2... for DialectPostgres, @p1, @p2... for DialectTSQL and ? for the other
+// dialects.
+//
+// Only the syntaxes in styles are recognized; if styles is empty, ParamColon is used.
+// If any parameter has no value in args, it will return an error.
+//
+//	sql, args, err := sqload.BindParams(Q.FindUserById, sqload.DialectPostgres, map[string]any{"id": 7})
+//	if err != nil {
+//		return err
+//	}
+//	row := db.QueryRow(sql, args...)
+func BindParams(sql string, d Dialect, args map[string]any, styles ...ParamStyle) (string, []any, error) {
+	var b strings.Builder
+	values := []any{}
+	positions := map[string]int{}
+	last := 0
+	for _, p := range findParams(sql, d, styles) {
+		value, ok := args[p.name]
+		if !ok {
+			return "", nil, fmt.Errorf("missing value for parameter %s", p.name)
+		}
+		b.WriteString(sql[last:p.start])
+		last = p.end
+		switch d {
+		case DialectPostgres, DialectTSQL:
+			position, seen := positions[p.name]
+			if !seen {
+				values = append(values, value)
+				position = len(values)
+				positions[p.name] = position
+			}
+			if d == DialectPostgres {
+				fmt.Fprintf(&b, "$%d", position)
+			} else {
+				fmt.Fprintf(&b, "@p%d", position)
+			}
+		default:
+			values = append(values, value)
+			b.WriteString("?")
+		}
+	}
+	b.WriteString(sql[last:])
+	return b.String(), values, nil
+}
+
+// checkDeclaredParams checks that the parameters declared in the param annotations of
+// the queries, one per annotation and followed by an optional description, are the
+// named parameters of their SQL code, so a stale declaration is found when the queries
+// are loaded:
+//
+//	-- query: FindUser
+//	-- param: id the id of the user
+//	-- param: org the organization the user belongs to
+//	SELECT * FROM user WHERE id = :id AND org = :org;
+//
+// The queries without param annotations are not checked. The queries are checked in
+// order of name, so the first error is always the same.
+func checkDeclaredParams(queries map[string]string, headers map[string]header, cfg *config) error {
+	for _, name := range sortedKeys(headers) {
+		sql, found := queries[name]
+		h := headers[name]
+		if !found || len(h.annotations["param"]) == 0 {
+			continue
+		}
+		declared := []string{}
+		for _, value := range h.annotations["param"] {
+			if fields := strings.Fields(value); len(fields) > 0 {
+				declared = append(declared, strings.TrimLeft(fields[0], ":@$"))
+			}
+		}
+		used := ExtractParams(sql, cfg.dialect, cfg.paramStyles...)
+		var problems []string
+		if undeclared := missingStrings(used, declared); len(undeclared) > 0 {
+			problems = append(problems, pluralize("parameter", undeclared)+" not declared")
+		}
+		if unused := missingStrings(declared, used); len(unused) > 0 {
+			problems = append(problems, "declared "+pluralize("parameter", unused)+" not used")
+		}
+		if len(problems) == 0 {
+			continue
+		}
+		err := errorf(CodeCheckFailed, "%w: query %s: %s", ErrCannotLoadQueries, name, strings.Join(problems, "; "))
+		if h.file != "" {
+			return fmt.Errorf("%w (%s:%d)", err, h.file, h.line)
+		}
+		return err
+	}
+	return nil
+}
+
+// missingStrings returns the strings of list that are not in other, in order.
+func missingStrings(list, other []string) []string {
+	missing := []string{}
+	for _, s := range list {
+		if !containsString(other, s) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// pluralize returns noun followed by the items of items and the verb to be, in singular
+// or plural: parameter id is, or parameters id, org are.
+func pluralize(noun string, items []string) string {
+	if len(items) == 1 {
+		return fmt.Sprintf("%s %s is", noun, items[0])
+	}
+	return fmt.Sprintf("%ss %s are", noun, strings.Join(items, ", "))
+}
@@ -0,0 +1,130 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExtractParams(t *testing.T) {
+	testCases := []struct {
+		sql     string
+		dialect Dialect
+		styles  []ParamStyle
+		want    []string
+	}{
+		{"SELECT * FROM user WHERE id = :id AND org = :org OR id = :id;", DialectGeneric, nil, []string{"id", "org"}},
+		{"SELECT * FROM user WHERE id = @id;", DialectGeneric, nil, []string{}},
+		{"SELECT * FROM user WHERE id = @id AND org = :org;", DialectGeneric, []ParamStyle{ParamAt}, []string{"id"}},
+		{"SELECT * FROM user WHERE id = @id AND org = :org;", DialectGeneric, []ParamStyle{ParamColon, ParamAt}, []string{"id", "org"}},
+		{"SELECT ':nope', \":nope\", a::int, arr[1:n] /* :nope */ FROM t -- :nope\nWHERE x = :yes", DialectPostgres, nil, []string{"yes"}},
+		{"SELECT @@version, @name, :1 FROM t", DialectMySQL, []ParamStyle{ParamColon, ParamAt}, []string{"name"}},
+		{"SELECT $1, ? FROM t", DialectGeneric, nil, []string{}},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			params := ExtractParams(testCase.sql, testCase.dialect, testCase.styles...)
+			if fmt.Sprint(params) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %v", params, testCase.want)
+			}
+		})
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	args := map[string]any{"id": 7, "org": "cats"}
+	testCases := []struct {
+		sql      string
+		dialect  Dialect
+		styles   []ParamStyle
+		wantSql  string
+		wantArgs []any
+		wantErr  error
+	}{
+		{
+			"SELECT * FROM user WHERE id = :id AND org = :org OR id = :id;",
+			DialectPostgres,
+			nil,
+			"SELECT * FROM user WHERE id = $1 AND org = $2 OR id = $1;",
+			[]any{7, "cats"},
+			nil,
+		},
+		{
+			"SELECT * FROM user WHERE id = :id AND org = :org OR id = :id;",
+			DialectMySQL,
+			nil,
+			"SELECT * FROM user WHERE id = ? AND org = ? OR id = ?;",
+			[]any{7, "cats", 7},
+			nil,
+		},
+		{
+			"SELECT * FROM user WHERE id = @id AND org = @org OR id = @id;",
+			DialectTSQL,
+			[]ParamStyle{ParamAt},
+			"SELECT * FROM user WHERE id = @p1 AND org = @p2 OR id = @p1;",
+			[]any{7, "cats"},
+			nil,
+		},
+		{
+			"SELECT * FROM user WHERE id = :id AND name = :name;",
+			DialectGeneric,
+			nil,
+			"",
+			nil,
+			fmt.Errorf("missing value for parameter name"),
+		},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			sql, values, err := BindParams(testCase.sql, testCase.dialect, args, testCase.styles...)
+			if fmt.Sprint(err) != fmt.Sprint(testCase.wantErr) {
+				t.Fatalf("got %v, want %v", err, testCase.wantErr)
+			}
+			if sql != testCase.wantSql {
+				t.Errorf("got %s, want %s", sql, testCase.wantSql)
+			}
+			if fmt.Sprint(values) != fmt.Sprint(testCase.wantArgs) {
+				t.Errorf("got %v, want %v", values, testCase.wantArgs)
+			}
+		})
+	}
+}
+
+func TestCheckDeclaredParams(t *testing.T) {
+	testCases := []struct {
+		sql     string
+		opts    []Option
+		wantErr error
+	}{
+		{
+			sql: "-- query: FindUser\n-- param: id the id of the user\n-- param: :org\nSELECT * FROM user WHERE id = :id AND org = :org;",
+		},
+		{
+			sql: "-- query: FindUser\nSELECT * FROM user WHERE id = :id;",
+		},
+		{
+			sql:     "-- query: Ping\nSELECT 1;\n\n-- query: FindUser\n-- param: id\nSELECT * FROM user WHERE id = :id AND org = :org AND team = :team;",
+			wantErr: fmt.Errorf("%w: query FindUser: parameters org, team are not declared (users.sql:4)", ErrCannotLoadQueries),
+		},
+		{
+			sql:     "-- query: FindUser\n-- param: id\n-- param: email\nSELECT * FROM user WHERE org = :org;",
+			wantErr: fmt.Errorf("%w: query FindUser: parameter org is not declared; declared parameters id, email are not used (users.sql:1)", ErrCannotLoadQueries),
+		},
+		{
+			sql:     "-- query: FindUser\n-- param: id\n-- param: org\nSELECT * FROM user WHERE id = @id;",
+			opts:    []Option{WithParamStyles(ParamAt)},
+			wantErr: fmt.Errorf("%w: query FindUser: declared parameter org is not used (users.sql:1)", ErrCannotLoadQueries),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := LoadQuerySet(fstest.MapFS{"users.sql": {Data: []byte(tc.sql)}}, tc.opts...)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Errorf("got %s, want %s", err, tc.wantErr)
+			}
+			if err != nil && ErrorCode(err) != CodeCheckFailed {
+				t.Errorf("got %s, want %s", ErrorCode(err), CodeCheckFailed)
+			}
+		})
+	}
+}
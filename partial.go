@@ -0,0 +1,122 @@
+package sqload
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+)
+
+// FieldError is the error of a field that could not be bound by a partial load, see
+// LoadPartialFromFS.
+type FieldError struct {
+	// Field is the name of the field, prefixed with the namespace of the struct it
+	// belongs to, like Users.FindUser.
+	Field string
+	// Err is the error that prevented the field from being bound.
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// missingQueryError is the error of a field bound to a query that does not exist.
+type missingQueryError struct {
+	name string // name of the query, prefixed with its namespace
+}
+
+func (e *missingQueryError) Error() string {
+	return ErrCannotLoadQueries.Error() + ": could not find query " + e.name
+}
+
+func (e *missingQueryError) Unwrap() error {
+	return ErrCannotLoadQueries
+}
+
+func (e *missingQueryError) errorCode() Code {
+	return CodeMissingQuery
+}
+
+// missingQueries returns an error listing the queries not found while binding a struct
+// with cfg, sorted by name, or nil if all of them were found.
+func (cfg *config) missingQueries() error {
+	switch names := sortedKeys(cfg.missing); len(names) {
+	case 0:
+		return nil
+	case 1:
+		return &missingQueryError{name: names[0]}
+	default:
+		return errorf(CodeMissingQuery, "%w: could not find queries %s", ErrCannotLoadQueries, strings.Join(names, ", "))
+	}
+}
+
+// fail records the error err of the field field in the report of cfg and reports
+// whether the binding can go on, which it only can in a partial load or, so all of them
+// are reported at once (see missingQueries), if err is a missingQueryError.
+func (cfg *config) fail(field string, err error) bool {
+	if !cfg.partial {
+		var missing *missingQueryError
+		if errors.As(err, &missing) {
+			if cfg.missing == nil {
+				cfg.missing = map[string]bool{}
+			}
+			cfg.missing[missing.name] = true
+			return true
+		}
+		return false
+	}
+	cfg.report.Failures = append(cfg.report.Failures, &FieldError{Field: field, Err: err})
+	return true
+}
+
+// LoadPartialFromFS is like LoadFromFS but, instead of failing, it skips the fields of V
+// that can not be bound and describes them in the Failures of the returned report, so
+// tools like documentation generators can work with as much as can be loaded:
+//
+//	q, report, err := sqload.LoadPartialFromFS[Queries](fsys)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, failure := range report.Failures {
+//		log.Printf("skipped %s: %s", failure.Field, failure.Err)
+//	}
+//
+// The report is the one set with WithReport, if any. It will still return an error if
+// the queries can not be read or parsed at all.
+func LoadPartialFromFS[V Struct](fsys fs.FS, opts ...Option) (*V, *LoadReport, error) {
+	cfg := newPartialConfig(opts)
+	queries, headers, err := extractQueriesFromFS(fsys, cfg)
+	if err != nil {
+		return nil, cfg.report, err
+	}
+	cfg.dialectQueries = dialectSource(fsys, cfg)
+	v, err := loadFromQueryMap[V](queries, headers, cfg)
+	return v, cfg.report, err
+}
+
+// LoadPartialFromString is like LoadFromString but it skips the fields that can not be
+// bound, see LoadPartialFromFS.
+func LoadPartialFromString[V Struct](s string, opts ...Option) (*V, *LoadReport, error) {
+	cfg := newPartialConfig(opts)
+	queries, headers, err := extractQueries(s, cfg)
+	if err != nil {
+		return nil, cfg.report, err
+	}
+	v, err := loadFromQueryMap[V](queries, headers, cfg)
+	return v, cfg.report, err
+}
+
+// newPartialConfig returns the configuration of a partial load with the options in
+// opts, which always has a report.
+func newPartialConfig(opts []Option) *config {
+	cfg := newConfig(opts)
+	cfg.partial = true
+	if cfg.report == nil {
+		cfg.report = &LoadReport{}
+	}
+	return cfg
+}
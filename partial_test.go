@@ -0,0 +1,64 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadPartial(t *testing.T) {
+	type Queries struct {
+		FindUser   string `query:"FindUser"`
+		FindCat    string `query:"FindCat"`
+		FindDogDoc string `querydoc:"FindDog"`
+		Count      int    `query:"CountUsers"`
+		Orders     struct {
+			FindOrder  string `query:"FindOrder"`
+			PlaceOrder string `query:"PlaceOrder"`
+		}
+	}
+	sql := "-- query: FindUser\nSELECT * FROM user;\n-- query: CountUsers\nSELECT count(*) FROM user;\n-- query: Orders.FindOrder\nSELECT * FROM \"order\";\n"
+	wantFailures := []string{
+		"FindCat: cannot load queries: could not find query FindCat",
+		"FindDogDoc: cannot load queries: could not find query FindDog",
+		"Count: cannot load queries: field Count cannot be changed or is not a string",
+		"Orders.PlaceOrder: cannot load queries: could not find query Orders.PlaceOrder",
+	}
+
+	q, report, err := LoadPartialFromString[Queries](sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.FindUser != "SELECT * FROM user;" || q.Orders.FindOrder != `SELECT * FROM "order";` {
+		t.Errorf("got %+v, want the queries that can be bound", *q)
+	}
+	if fmt.Sprint(report.Failures) != fmt.Sprint(wantFailures) {
+		t.Errorf("got %v, want %v", report.Failures, wantFailures)
+	}
+	if !errors.Is(report.Failures[0], ErrCannotLoadQueries) {
+		t.Errorf("the failures must wrap ErrCannotLoadQueries")
+	}
+
+	var shared LoadReport
+	fsys := fstest.MapFS{"q.sql": {Data: []byte(sql)}}
+	q, report, err = LoadPartialFromFS[Queries](fsys, WithReport(&shared))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if report != &shared {
+		t.Error("the report set with WithReport must be returned")
+	}
+	if q.FindUser != "SELECT * FROM user;" || fmt.Sprint(report.Failures) != fmt.Sprint(wantFailures) {
+		t.Errorf("got %+v and %v, want the queries that can be bound and %v", *q, report.Failures, wantFailures)
+	}
+
+	_, _, err = LoadPartialFromFS[Queries](fstest.MapFS{"q.sql": {Data: []byte("-- query: bad-name\nSELECT 1;")}})
+	wantErr := fmt.Errorf("%w: invalid query name bad-name (q.sql:1)", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %v, want %s", err, wantErr)
+	}
+	if _, err := LoadFromString[Queries](sql); err == nil {
+		t.Error("err is nil, LoadFromString must not skip the fields")
+	}
+}
@@ -0,0 +1,197 @@
+package sqload
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule is a rule of a Policy. Check returns a message for every violation of the rule in
+// the query q, written in dialect d, or nothing if q follows the rule.
+type Rule struct {
+	Name  string
+	Check func(q Query, d Dialect) []string
+}
+
+// Violation is a violation of a rule of a Policy by a query.
+type Violation struct {
+	// Query is the name of the query.
+	Query string
+	// Variant is the name of the variant of the query, if any.
+	Variant string
+	// Rule is the name of the violated rule.
+	Rule string
+	// Message describes the violation.
+	Message string
+}
+
+// String returns the violation as "query Name: rule: message".
+func (v Violation) String() string {
+	name := v.Query
+	if v.Variant != "" {
+		name = variantKey(v.Query, v.Variant)
+	}
+	return fmt.Sprintf("query %s: %s: %s", name, v.Rule, v.Message)
+}
+
+// Policy is a set of rules about the constructs the queries are allowed to use. It can be
+// checked on demand, with CheckQuery and Check, or on every load, with WithPolicy.
+//
+//	policy := sqload.Policy{
+//		sqload.DenySelectStar(),
+//		sqload.DenyCrossSchema("public"),
+//		sqload.RequireLimit("list"),
+//	}
+//	for _, v := range policy.Check(qs) {
+//		fmt.Println(v)
+//	}
+type Policy []Rule
+
+// CheckQuery returns the violations of the rules of the policy by the query q, written in
+// dialect d.
+func (p Policy) CheckQuery(q Query, d Dialect) []Violation {
+	violations := []Violation{}
+	for _, rule := range p {
+		for _, message := range rule.Check(q, d) {
+			violations = append(violations, Violation{Query: q.Name, Variant: q.Variant, Rule: rule.Name, Message: message})
+		}
+	}
+	return violations
+}
+
+// Check returns the violations of the rules of the policy by the queries of qs, and
+// their variants, sorted by query name.
+func (p Policy) Check(qs *QuerySet) []Violation {
+	violations := []Violation{}
+	for _, q := range qs.Queries() {
+		violations = append(violations, p.CheckQuery(q, qs.dialect)...)
+		for _, variant := range qs.Variants(q.Name) {
+			violations = append(violations, p.CheckQuery(qs.variants[q.Name][variant], qs.dialect)...)
+		}
+	}
+	return violations
+}
+
+// significantTokens returns the tokens of the SQL code sql that are not trivia.
+func significantTokens(sql string, d Dialect) []token {
+	tokens := []token{}
+	for _, tok := range tokenize(sql, d) {
+		if !tok.isTrivia() {
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens
+}
+
+// DenySelectStar returns a rule, named deny-select-star, that forbids selecting all the
+// columns with * (or table.*), so queries do not break or slow down when columns are
+// added. COUNT(*) is allowed.
+func DenySelectStar() Rule {
+	return Rule{
+		Name: "deny-select-star",
+		Check: func(q Query, d Dialect) []string {
+			messages := []string{}
+			tokens := significantTokens(q.SQL, d)
+			for i := 1; i < len(tokens); i++ {
+				if tokens[i].text(q.SQL) != "*" {
+					continue
+				}
+				switch strings.ToUpper(tokens[i-1].text(q.SQL)) {
+				case "SELECT", "DISTINCT", "ALL", ",", ".":
+					messages = append(messages, "SELECT * is not allowed, list the columns instead")
+				}
+			}
+			return messages
+		},
+	}
+}
+
+// DenyCrossSchema returns a rule, named deny-cross-schema, that forbids referencing
+// tables qualified with a schema other than the ones in allowed. Unqualified tables are
+// always allowed. The schemas are compared without regard to case or quotes.
+//
+//	sqload.DenyCrossSchema("billing")
+//	// allows FROM invoice and FROM billing.invoice, but not FROM auth.user
+func DenyCrossSchema(allowed ...string) Rule {
+	return Rule{
+		Name: "deny-cross-schema",
+		Check: func(q Query, d Dialect) []string {
+			messages := []string{}
+			tokens := significantTokens(q.SQL, d)
+			for i := 0; i < len(tokens); i++ {
+				switch strings.ToUpper(tokens[i].text(q.SQL)) {
+				case "FROM", "JOIN", "INTO", "UPDATE", "TABLE":
+				default:
+					continue
+				}
+				parts := []string{}
+				for j := i + 1; j < len(tokens); j += 2 {
+					if kind := tokens[j].kind; kind != tokenWord && kind != tokenQuotedIdent {
+						break
+					}
+					parts = append(parts, unquoteIdent(tokens[j].text(q.SQL)))
+					if j+1 == len(tokens) || tokens[j+1].text(q.SQL) != "." {
+						break
+					}
+				}
+				if len(parts) < 2 {
+					continue
+				}
+				schema := strings.Join(parts[:len(parts)-1], ".")
+				if !containsFold(allowed, schema) {
+					messages = append(messages, fmt.Sprintf("references to the schema %s are not allowed", schema))
+				}
+			}
+			return messages
+		},
+	}
+}
+
+// RequireLimit returns a rule, named require-limit, that requires the queries tagged
+// with tag (see Query.Tags) to limit the rows they return with LIMIT, FETCH or TOP.
+//
+//	-- query: ListUsers
+//	-- tags: list
+//	SELECT id, name FROM user ORDER BY name LIMIT :limit;
+func RequireLimit(tag string) Rule {
+	return Rule{
+		Name: "require-limit",
+		Check: func(q Query, d Dialect) []string {
+			if !containsFold(q.Tags, tag) {
+				return nil
+			}
+			depth := 0
+			for _, tok := range significantTokens(q.SQL, d) {
+				switch text := strings.ToUpper(tok.text(q.SQL)); {
+				case text == "(":
+					depth++
+				case text == ")":
+					depth--
+				case depth == 0 && (text == "LIMIT" || text == "FETCH" || text == "TOP"):
+					return nil
+				}
+			}
+			return []string{fmt.Sprintf("queries tagged %s must have a LIMIT", tag)}
+		},
+	}
+}
+
+// unquoteIdent removes the quotes around the identifier ident, if any.
+func unquoteIdent(ident string) string {
+	if len(ident) >= 2 {
+		switch ident[0] {
+		case '"', '`', '[':
+			return ident[1 : len(ident)-1]
+		}
+	}
+	return ident
+}
+
+// containsFold reports whether s is one of the strings in list, without regard to case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPolicyRules(t *testing.T) {
+	testCases := []struct {
+		rule Rule
+		sql  string
+		tags string
+		d    Dialect
+		want []string
+	}{
+		{DenySelectStar(), "SELECT * FROM user;", "", DialectGeneric, []string{"SELECT * is not allowed, list the columns instead"}},
+		{DenySelectStar(), "SELECT DISTINCT u.* FROM user u;", "", DialectGeneric, []string{"SELECT * is not allowed, list the columns instead"}},
+		{DenySelectStar(), "SELECT COUNT(*), price * 2 FROM item /* SELECT * */;", "", DialectGeneric, []string{}},
+		{DenySelectStar(), "SELECT '*' FROM user;", "", DialectGeneric, []string{}},
+		{DenyCrossSchema("billing"), "SELECT id FROM invoice JOIN billing.customer c ON true;", "", DialectGeneric, []string{}},
+		{
+			DenyCrossSchema("billing"),
+			"INSERT INTO auth.user (id) SELECT id FROM \"Billing\".invoice JOIN sales.order o ON true;", "", DialectPostgres,
+			[]string{"references to the schema auth are not allowed", "references to the schema sales are not allowed"},
+		},
+		{DenyCrossSchema("dbo"), "UPDATE [dbo].[cat] SET name = 'x' FROM db2.dbo.dog;", "", DialectTSQL, []string{"references to the schema db2.dbo are not allowed"}},
+		{RequireLimit("list"), "SELECT id FROM user;", "", DialectGeneric, nil},
+		{RequireLimit("list"), "SELECT id FROM user;", "reporting, list", DialectGeneric, []string{"queries tagged list must have a LIMIT"}},
+		{RequireLimit("list"), "SELECT id FROM (SELECT id FROM user LIMIT 5) u;", "list", DialectGeneric, []string{"queries tagged list must have a LIMIT"}},
+		{RequireLimit("list"), "SELECT id FROM user LIMIT :limit;", "list", DialectGeneric, nil},
+		{RequireLimit("list"), "SELECT TOP 10 id FROM user;", "List", DialectTSQL, nil},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			q := Query{Name: "Q", SQL: testCase.sql, Tags: Annotations{"tags": {testCase.tags}}.List("tags")}
+			got := testCase.rule.Check(q, testCase.d)
+			if fmt.Sprint(got) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %v", got, testCase.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	fsys := fstest.MapFS{
+		"user.sql": {Data: []byte(`
+-- query: ListUsers
+-- tags: list
+SELECT * FROM user;
+
+-- query: ListUsers variant=fast
+-- tags: list
+SELECT id FROM auth.user LIMIT 10;
+
+-- query: FindUser
+SELECT id FROM user WHERE id = :id;
+`)},
+	}
+	qs, err := LoadQuerySet(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	policy := Policy{DenySelectStar(), DenyCrossSchema(), RequireLimit("list")}
+	want := []string{
+		"query ListUsers: deny-select-star: SELECT * is not allowed, list the columns instead",
+		"query ListUsers: require-limit: queries tagged list must have a LIMIT",
+		"query ListUsers:fast: deny-cross-schema: references to the schema auth are not allowed",
+	}
+	if got := policy.Check(qs); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	_, err = LoadQuerySet(fsys, WithPolicy(policy))
+	wantErr := fmt.Errorf("%w: %s", ErrCannotLoadQueries, want[0])
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %v, want %v", err, wantErr)
+	}
+	if _, err := LoadQuerySet(fsys, WithPolicy(Policy{RequireLimit("batch")})); err != nil {
+		t.Errorf("err must be nil, got %s", err)
+	}
+}
@@ -0,0 +1,84 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLoadWithPrefix(t *testing.T) {
+	sql := `
+-- query: billing.FindInvoice
+SELECT * FROM invoice WHERE id = :id;
+
+-- query: billing.FindInvoice variant=fast
+SELECT * FROM invoice_cache WHERE id = :id;
+
+-- query: shipping.FindInvoice
+SELECT * FROM shipment_invoice WHERE id = :id;
+
+-- query: FindUser
+SELECT * FROM user WHERE id = :id;
+`
+	billing, err := LoadFromString[struct {
+		FindInvoice     string `query:"FindInvoice"`
+		FindInvoiceFast string `query:"FindInvoice:fast"`
+	}](sql, WithPrefix("billing."))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if billing.FindInvoice != "SELECT * FROM invoice WHERE id = :id;" {
+		t.Errorf("got %s, want %s", billing.FindInvoice, "SELECT * FROM invoice WHERE id = :id;")
+	}
+	if billing.FindInvoiceFast != "SELECT * FROM invoice_cache WHERE id = :id;" {
+		t.Errorf("got %s, want %s", billing.FindInvoiceFast, "SELECT * FROM invoice_cache WHERE id = :id;")
+	}
+	shipping, err := LoadFromString[struct {
+		FindInvoice string `query:"FindInvoice"`
+	}](sql, WithPrefix("shipping."))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if shipping.FindInvoice != "SELECT * FROM shipment_invoice WHERE id = :id;" {
+		t.Errorf("got %s, want %s", shipping.FindInvoice, "SELECT * FROM shipment_invoice WHERE id = :id;")
+	}
+	_, err = LoadFromString[struct {
+		FindUser string `query:"FindUser"`
+	}](sql, WithPrefix("billing."))
+	want := fmt.Errorf("%w: could not find query FindUser", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+	all, err := LoadFromString[struct {
+		FindInvoice string `query:"billing.FindInvoice"`
+		FindUser    string `query:"FindUser"`
+	}](sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if all.FindUser != "SELECT * FROM user WHERE id = :id;" {
+		t.Errorf("got %s, want %s", all.FindUser, "SELECT * FROM user WHERE id = :id;")
+	}
+}
+
+func TestNamespacedQueryNames(t *testing.T) {
+	testCases := []struct {
+		name  string
+		valid bool
+	}{
+		{"FindUser", true},
+		{"billing.FindInvoice", true},
+		{"billing.v2.FindInvoice", true},
+		{"billing.", false},
+		{".FindInvoice", false},
+		{"billing..FindInvoice", false},
+		{"billing-FindInvoice", false},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := ExtractQueryMap("-- query: " + testCase.name + "\nSELECT 1;")
+			if (err == nil) != testCase.valid {
+				t.Errorf("got %v, want valid=%t", err, testCase.valid)
+			}
+		})
+	}
+}
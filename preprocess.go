@@ -0,0 +1,22 @@
+package sqload
+
+// Preprocessor is a function applied to the contents of every file at load time, see
+// WithPreprocessor. It receives the path of a file and its contents, and returns the
+// contents that must be parsed, for example after stripping a proprietary header,
+// running a macro processor or decrypting them.
+//
+// If it returns an error, the load fails.
+type Preprocessor func(path string, data []byte) ([]byte, error)
+
+// preprocess applies the preprocessors configured in cfg to data, the contents of the
+// file path.
+func preprocess(path string, data []byte, cfg *config) ([]byte, error) {
+	for _, preprocessor := range cfg.preprocessors {
+		var err error
+		data, err = preprocessor(path, data)
+		if err != nil {
+			return nil, errorf(CodeExpansionFailed, "%w: file %s: %s", ErrCannotLoadQueries, path, cfg.detail(err))
+		}
+	}
+	return data, nil
+}
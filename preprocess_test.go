@@ -0,0 +1,52 @@
+package sqload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadWithPreprocessor(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cat.sql":   {Data: []byte("LICENSED TO ACME\n-- END HEADER\n-- query: FindCat\nSELECT * FROM %TABLE% WHERE id = :id;")},
+		"other.sql": {Data: []byte("-- query: DeleteCat\nDELETE FROM %TABLE% WHERE id = :id;")},
+	}
+	paths := []string{}
+	stripHeader := func(path string, data []byte) ([]byte, error) {
+		paths = append(paths, path)
+		if _, body, found := bytes.Cut(data, []byte("-- END HEADER\n")); found {
+			return body, nil
+		}
+		return data, nil
+	}
+	expandTable := func(path string, data []byte) ([]byte, error) {
+		return bytes.ReplaceAll(data, []byte("%TABLE%"), []byte("cat")), nil
+	}
+	q, err := LoadFromFS[struct {
+		FindCat   string `query:"FindCat"`
+		DeleteCat string `query:"DeleteCat"`
+	}](fsys, WithPreprocessor(stripHeader, expandTable))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := "SELECT * FROM cat WHERE id = :id;"; q.FindCat != want {
+		t.Errorf("got %s, want %s", q.FindCat, want)
+	}
+	if want := "DELETE FROM cat WHERE id = :id;"; q.DeleteCat != want {
+		t.Errorf("got %s, want %s", q.DeleteCat, want)
+	}
+	if fmt.Sprint(paths) != "[cat.sql other.sql]" {
+		t.Errorf("got %v, want %s", paths, "[cat.sql other.sql]")
+	}
+
+	failing := func(path string, data []byte) ([]byte, error) {
+		return nil, errors.New("cannot decrypt")
+	}
+	_, err = LoadFromFS[struct{}](fsys, WithPreprocessor(failing))
+	want := fmt.Errorf("%w: file cat.sql: cannot decrypt", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,388 @@
+package sqload
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query is a named query together with the metadata collected while loading it.
+type Query struct {
+	// Name is the name of the query, as written in its query comment.
+	Name string
+	// Variant is the name of the variant of the query, or an empty string for its
+	// default version, see QuerySet.Select.
+	Variant string
+	// SQL is the SQL code of the query.
+	SQL string
+	// Kind is the kind of the first statement of the query, see Classify, unless it is
+	// written in its kind annotation, for the queries Classify gets wrong:
+	//
+	//	-- query: CreateAuditLog
+	//	-- kind: ddl
+	//	SELECT create_audit_log_partition(now());
+	Kind Kind
+	// Params are the names of the named parameters of the query, see ExtractParams.
+	Params []string
+	// Doc is the doc comment of the query: the -- comment lines written between its query
+	// comment and its SQL code that are not annotations, without the leading --.
+	Doc string
+	// Tags are the labels of the query, written in its tags annotation.
+	Tags []string
+	// Attributes are the key=value attributes written after the name of the query in its
+	// query comment, other than variant, or nil if there are none. Their keys are
+	// declared with WithAttributes:
+	//
+	//	-- query: FindUser timeout=2s cache=false owner=payments
+	Attributes map[string]string
+	// Annotations are the annotations written in the header of the query.
+	Annotations Annotations
+	// Aliases are the other names the query can be found by, written in its alias
+	// annotation, so a renamed query remains reachable by its old names:
+	//
+	//	-- query: FindUserByID
+	//	-- alias: FindUserByIdV1
+	//	SELECT * FROM user WHERE id = :id;
+	Aliases []string
+	// AliasOf is the name of the query this query is an alias of, or an empty string if
+	// it is not an alias. Looking up an alias calls the deprecation handler, see
+	// WithDeprecationHandler.
+	AliasOf string
+	// Data holds the values returned by the annotation handlers for the annotations of
+	// the query, by annotation key, see WithAnnotationHandler.
+	Data map[string]any
+	// ModTime is the modification time of the file the query was read from, or the zero
+	// time if it was not read from a file, so caches and admin tools can tell how old the
+	// query is without reading the file again.
+	ModTime time.Time
+	// Size is the size in bytes of the file the query was read from, or 0 if it was not
+	// read from a file.
+	Size int64
+
+	order int    // position of the definition of the query, or -1 if it was not read
+	file  string // file the query was read from, if any
+	line  int    // line of the query comment in file
+}
+
+// Preparable reports whether the query can be prepared: whether it has no no-prepare
+// annotation. The statements that can not be prepared, like some DDL, SET commands or
+// COPY, are marked with it so the tools that prepare the queries skip them:
+//
+//	-- query: SetSearchPath
+//	-- no-prepare
+//	SET search_path TO tenant, public;
+func (q Query) Preparable() bool {
+	return !q.Annotations.Has("no-prepare")
+}
+
+// QuerySet is an immutable set of named queries. It is safe for concurrent use.
+type QuerySet struct {
+	queries     map[string]Query
+	variants    map[string]map[string]Query // variants by query name and variant name
+	dialect     Dialect
+	paramStyles []ParamStyle
+	deprecated  func(alias, name string) // deprecation handler, see WithDeprecationHandler
+
+	// index holds the keys of the queries by SQL code, built on first use, see NameOf.
+	indexOnce sync.Once
+	index     map[string][]string
+
+	// src and cfg are the source the set was loaded from and its configuration, or nil
+	// if the set can not be updated, see Update.
+	src *source
+	cfg *config
+}
+
+// newQuerySet returns a QuerySet holding queries, whose SQL code is parsed as configured
+// by cfg, with the headers in headers. If any annotation handler, validator or policy
+// fails, it will return an error.
+func newQuerySet(queries map[string]string, headers map[string]header, cfg *config) (*QuerySet, error) {
+	qs := &QuerySet{
+		queries:     make(map[string]Query, len(queries)),
+		variants:    map[string]map[string]Query{},
+		dialect:     cfg.dialect,
+		paramStyles: cfg.paramStyles,
+		deprecated:  cfg.deprecationHandler,
+	}
+	keys := sortedKeys(queries)
+	for _, key := range keys {
+		sql := queries[key]
+		name, variant, isVariant := strings.Cut(key, variantSeparator)
+		q := Query{
+			Name:        name,
+			Variant:     variant,
+			SQL:         sql,
+			Params:      ExtractParams(sql, cfg.dialect, cfg.paramStyles...),
+			Doc:         headers[key].doc,
+			Tags:        headers[key].annotations.List("tags"),
+			Annotations: headers[key].annotations,
+			order:       -1,
+		}
+		var err error
+		if q.Kind, err = queryKind(sql, q.Annotations, cfg.dialect); err != nil {
+			return nil, errorf(CodeInvalidAnnotation, "%w: query %s: %s", ErrCannotLoadQueries, key, err)
+		}
+		if isolation := q.Annotations.Get("isolation"); isolation != "" {
+			if _, err := ParseIsolation(isolation); err != nil {
+				return nil, errorf(CodeInvalidAnnotation, "%w: query %s: %s", ErrCannotLoadQueries, key, err)
+			}
+		}
+		if h, found := headers[key]; found {
+			q.order, q.file, q.line = h.order, h.file, h.line
+			q.ModTime, q.Size = h.modTime, h.size
+			q.AliasOf = h.aliasOf
+			q.Attributes = h.attrs
+		}
+		if q.AliasOf == "" {
+			q.Aliases = q.Annotations.List("alias")
+		}
+		data, err := handleAnnotations(q, cfg)
+		if err != nil {
+			return nil, errorf(CodeExpansionFailed, "%w: query %s: %s", ErrCannotLoadQueries, key, err)
+		}
+		q.Data = data
+		if err := validate(q, cfg); err != nil {
+			return nil, errorf(CodeCheckFailed, "%w: query %s: %s", ErrCannotLoadQueries, key, cfg.detail(err))
+		}
+		for _, p := range cfg.policies {
+			if violations := p.CheckQuery(q, cfg.dialect); len(violations) > 0 {
+				return nil, errorf(CodeCheckFailed, "%w: %s", ErrCannotLoadQueries, violations[0])
+			}
+		}
+		if !isVariant {
+			qs.queries[name] = q
+			continue
+		}
+		if qs.variants[name] == nil {
+			qs.variants[name] = map[string]Query{}
+		}
+		qs.variants[name][variant] = q
+	}
+	return qs, nil
+}
+
+// LoadQuerySet loads the SQL code from all the .sql files in the fsys file system
+// (recursively) and returns a QuerySet containing their queries. Unlike the other Load
+// functions, it keeps the metadata of each query and it does not need a struct.
+//
+// If some query has an invalid name or any .sql file can not be read, it will return a
+// nil pointer and an error.
+//
+//	qs, err := sqload.LoadQuerySet(os.DirFS("sql"), sqload.WithDialect(sqload.DialectPostgres))
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	for _, q := range qs.Queries() {
+//		if q.Kind == sqload.KindDDL {
+//			if _, err := db.Exec(q.SQL); err != nil {
+//				log.Fatal(err)
+//			}
+//		}
+//	}
+func LoadQuerySet(fsys fs.FS, opts ...Option) (*QuerySet, error) {
+	cfg := newConfig(opts)
+	qs, err := loadQuerySet(fsys, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.publish {
+		qs.publish()
+	}
+	return qs, nil
+}
+
+func loadQuerySet(fsys fs.FS, cfg *config) (*QuerySet, error) {
+	src, err := parseSource(fsys, cfg, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return newSourceQuerySet(src, cfg)
+}
+
+// newSourceQuerySet returns a QuerySet holding the queries of the source src, loaded with
+// the configuration cfg. The set keeps src, so it can be updated, see QuerySet.Update.
+func newSourceQuerySet(src *source, cfg *config) (*QuerySet, error) {
+	queries, headers, err := src.merge(cfg)
+	if err != nil {
+		return nil, err
+	}
+	queries, headers, err = resolveQueries(queries, headers, cfg)
+	if err != nil {
+		return nil, err
+	}
+	qs, err := newQuerySet(queries, headers, cfg)
+	if err != nil {
+		return nil, err
+	}
+	qs.src, qs.cfg = src, cfg
+	return qs, nil
+}
+
+// Get returns the SQL code of the query name.
+func (qs *QuerySet) Get(name string) (string, bool) {
+	q, found := qs.lookup(name)
+	return q.SQL, found
+}
+
+// Bind returns the SQL code of the query name with its named parameters rewritten into
+// positional placeholders, along with the values of args in the order the placeholders
+// expect them. The dialect and parameter styles the set was loaded with are used, see
+// BindParams.
+//
+// If the query does not exist or any parameter has no value in args, it will return an
+// error.
+func (qs *QuerySet) Bind(name string, args map[string]any) (string, []any, error) {
+	q, found := qs.lookup(name)
+	if !found {
+		return "", nil, fmt.Errorf("could not find query %s", name)
+	}
+	return BindParams(q.SQL, qs.dialect, args, qs.paramStyles...)
+}
+
+// Filter appends the conditions built from the filter struct filter to the query name
+// (see AppendWhere) and binds the result like Bind does, with the values of the
+// parameters of the base query taken from args, so a dynamic search can be built on top
+// of a loaded base query in one step. If a parameter built from the filter has the name
+// of one in args, it will return an error instead of letting one value replace the other.
+//
+//	sql, args, err := qs.Filter("FindUsers", UserFilter{Email: &email}, map[string]any{"org": org})
+//	if err != nil {
+//		return err
+//	}
+//	rows, err := db.Query(sql, args...)
+func (qs *QuerySet) Filter(name string, filter any, args map[string]any) (string, []any, error) {
+	q, found := qs.lookup(name)
+	if !found {
+		return "", nil, fmt.Errorf("could not find query %s", name)
+	}
+	prefix := ":"
+	if len(qs.paramStyles) > 0 {
+		prefix = paramPrefixes[qs.paramStyles[0]]
+	}
+	sql, filterArgs, err := appendWhere(q.SQL, qs.dialect, filter, prefix)
+	if err != nil {
+		return "", nil, err
+	}
+	for name, value := range args {
+		if _, found := filterArgs[name]; found {
+			return "", nil, fmt.Errorf("parameter %s of the filter is also in args", name)
+		}
+		filterArgs[name] = value
+	}
+	return BindParams(sql, qs.dialect, filterArgs, qs.paramStyles...)
+}
+
+// Query returns the query name with its metadata.
+func (qs *QuerySet) Query(name string) (Query, bool) {
+	return qs.lookup(name)
+}
+
+// lookup returns the query name, calling the deprecation handler of the set if name is
+// an alias.
+func (qs *QuerySet) lookup(name string) (Query, bool) {
+	q, found := qs.queries[name]
+	if found && q.AliasOf != "" && qs.deprecated != nil {
+		qs.deprecated(name, q.AliasOf)
+	}
+	return q, found
+}
+
+// Queries returns all the queries in the set, sorted by name.
+func (qs *QuerySet) Queries() []Query {
+	queries := make([]Query, 0, len(qs.queries))
+	for _, name := range qs.Names() {
+		queries = append(queries, qs.queries[name])
+	}
+	return queries
+}
+
+// InOrder returns all the queries in the set in the order they were defined: sorted by
+// file, in the order the files were read, and by position in the file. The queries that
+// were not read from the source, like the registered ones, go last, sorted by name.
+//
+//	qs, err := sqload.LoadQuerySet(os.DirFS("migrations"))
+//	...
+//	for _, q := range qs.InOrder() {
+//		if _, err := db.Exec(q.SQL); err != nil {
+//			return fmt.Errorf("%s: %w", q.Name, err)
+//		}
+//	}
+func (qs *QuerySet) InOrder() []Query {
+	queries := qs.Queries()
+	sort.SliceStable(queries, func(i, j int) bool {
+		a, b := queries[i].order, queries[j].order
+		if a == -1 || b == -1 {
+			return b == -1 && a != -1
+		}
+		return a < b
+	})
+	return queries
+}
+
+// ByKind returns the queries of the set of any of the kinds kinds, in the order they were
+// defined (see InOrder), so tools can apply the schema statements before anything else
+// without keeping them in a separate tree:
+//
+//	for _, q := range qs.ByKind(sqload.KindDDL, sqload.KindProcedure) {
+//		if _, err := db.Exec(q.SQL); err != nil {
+//			return fmt.Errorf("%s: %w", q.Name, err)
+//		}
+//	}
+//
+// The aliases are left out, so each statement is returned once.
+func (qs *QuerySet) ByKind(kinds ...Kind) []Query {
+	queries := []Query{}
+	for _, q := range qs.InOrder() {
+		if q.AliasOf != "" {
+			continue
+		}
+		for _, kind := range kinds {
+			if q.Kind == kind {
+				queries = append(queries, q)
+				break
+			}
+		}
+	}
+	return queries
+}
+
+// Source returns the file the query name was read from, relative to the root of the file
+// system it was loaded from, and the line of its query comment, so error messages and
+// admin tools can point at its definition. If the query does not exist or it was not
+// read from a file, like the registered queries, it returns an empty string and 0.
+//
+//	if err != nil {
+//		file, line := qs.Source("FindUserById")
+//		return fmt.Errorf("query FindUserById (%s:%d): %w", file, line, err)
+//	}
+func (qs *QuerySet) Source(name string) (string, int) {
+	q, found := qs.queries[name]
+	if !found || q.file == "" {
+		return "", 0
+	}
+	return q.file, q.line
+}
+
+// Len returns the number of queries in the set.
+func (qs *QuerySet) Len() int {
+	return len(qs.queries)
+}
+
+// Dialect returns the dialect the set was loaded with, see WithDialect.
+func (qs *QuerySet) Dialect() Dialect {
+	return qs.dialect
+}
+
+// ParamStyles returns the syntaxes of the named parameters the set was loaded with, see
+// WithParamStyles.
+func (qs *QuerySet) ParamStyles() []ParamStyle {
+	return append([]ParamStyle{}, qs.paramStyles...)
+}
+
+// Names returns the names of the queries in the set, sorted alphabetically.
+func (qs *QuerySet) Names() []string {
+	return sortedKeys(qs.queries)
+}
@@ -0,0 +1,257 @@
+package sqload
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestQuerySet(t *testing.T) {
+	qs, err := newQuerySet(CatTestQueries, nil, &config{})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if qs.Len() != len(CatTestQueries) {
+		t.Fatalf("got %d, want %d", qs.Len(), len(CatTestQueries))
+	}
+	wantedNames := []string{"CreateCatTable", "CreateNormalCat", "CreatePsychoCat", "UpdateColorById"}
+	if fmt.Sprint(qs.Names()) != fmt.Sprint(wantedNames) {
+		t.Errorf("got %v, want %v", qs.Names(), wantedNames)
+	}
+	sql, found := qs.Get("CreatePsychoCat")
+	if !found {
+		t.Fatal("query CreatePsychoCat not found")
+	}
+	if sql != CatTestQueries["CreatePsychoCat"] {
+		t.Errorf("got %s, want %s", sql, CatTestQueries["CreatePsychoCat"])
+	}
+	if _, found := qs.Get("DeleteCatById"); found {
+		t.Error("query DeleteCatById must not be found")
+	}
+	if empty, _ := newQuerySet(nil, nil, &config{}); empty.Len() != 0 {
+		t.Error("a QuerySet created from nil must be empty")
+	}
+}
+
+func TestLoadQuerySet(t *testing.T) {
+	_, err := LoadQuerySet(os.DirFS("testdata/i-dont-exist"))
+	if err == nil {
+		t.Fatal("err is nil")
+	}
+	qs, err := LoadQuerySet(os.DirFS("testdata/test-load-from-fs"))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if qs.Len() != 8 {
+		t.Fatalf("got %d queries, want 8", qs.Len())
+	}
+	wantedKinds := map[string]Kind{
+		"CreateCatTable":      KindDDL,
+		"CreateNormalCat":     KindDML,
+		"CreatePsychoCat":     KindDML,
+		"DeleteUserById":      KindDML,
+		"FindRiders":          KindDML,
+		"FindUserById":        KindDML,
+		"UpdateColorById":     KindDML,
+		"UpdateFirstNameById": KindDML,
+	}
+	for _, q := range qs.Queries() {
+		if q.Kind != wantedKinds[q.Name] {
+			t.Errorf("query %s: got %s, want %s", q.Name, q.Kind, wantedKinds[q.Name])
+		}
+	}
+	q, found := qs.Query("FindRiders")
+	if !found {
+		t.Fatal("query FindRiders not found")
+	}
+	if q.SQL != RiderTestQueries["FindRiders"] {
+		t.Errorf("got %s, want %s", q.SQL, RiderTestQueries["FindRiders"])
+	}
+}
+
+func TestQuerySetBind(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte("-- query: FindUser\nSELECT * FROM user WHERE id = @id AND org = @org;")},
+	}
+	qs, err := LoadQuerySet(fsys, WithDialect(DialectPostgres), WithParamStyles(ParamAt))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	q, _ := qs.Query("FindUser")
+	if fmt.Sprint(q.Params) != "[id org]" {
+		t.Errorf("got %v, want %v", q.Params, "[id org]")
+	}
+	sql, args, err := qs.Bind("FindUser", map[string]any{"id": 1, "org": "cats"})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if sql != "SELECT * FROM user WHERE id = $1 AND org = $2;" {
+		t.Errorf("got %s, want %s", sql, "SELECT * FROM user WHERE id = $1 AND org = $2;")
+	}
+	if fmt.Sprint(args) != "[1 cats]" {
+		t.Errorf("got %v, want %v", args, "[1 cats]")
+	}
+	if _, _, err := qs.Bind("FindCat", nil); err == nil {
+		t.Error("err is nil")
+	}
+}
+
+func TestQuerySetInOrder(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.sql": {Data: []byte("-- query: Zebra\nSELECT 1;\n-- query: Apple\nSELECT 2;")},
+		"b.sql": {Data: []byte("-- query: Mango\nSELECT 3;\n-- query: Banana\nSELECT 4;")},
+	}
+	defer resetRegistry()
+	Register("Cherry", "SELECT 5;")
+	qs, err := LoadQuerySet(fsys, WithRegistered())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	names := []string{}
+	for _, q := range qs.InOrder() {
+		names = append(names, q.Name)
+	}
+	want := "[Zebra Apple Mango Banana Cherry]"
+	if fmt.Sprint(names) != want {
+		t.Errorf("got %v, want %s", names, want)
+	}
+}
+
+func TestQueryAttributes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql": {Data: []byte(`-- query: FindUser timeout=2s cache=false owner=payments
+SELECT * FROM user WHERE id = :id;
+
+-- query: FindUser variant=fast timeout=500ms
+SELECT id FROM user WHERE id = :id;
+
+-- query: DeleteUser
+DELETE FROM user WHERE id = :id;
+`)},
+	}
+	qs, err := LoadQuerySet(fsys, WithAttributes("timeout", "cache"), WithAttributes("owner"))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	q, _ := qs.Query("FindUser")
+	if want := "map[cache:false owner:payments timeout:2s]"; fmt.Sprint(q.Attributes) != want {
+		t.Errorf("got %v, want %s", q.Attributes, want)
+	}
+	fast, _ := qs.SelectQuery("FindUser", Flags{"FindUser": "fast"})
+	if want := "map[timeout:500ms]"; fmt.Sprint(fast.Attributes) != want {
+		t.Errorf("got %v, want %s", fast.Attributes, want)
+	}
+	if q, _ := qs.Query("DeleteUser"); q.Attributes != nil {
+		t.Errorf("got %v, want nil", q.Attributes)
+	}
+}
+
+func TestQuerySetSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql":        {Data: []byte("-- query: Ping\nSELECT 1;\n\n-- query: FindUser\nSELECT * FROM user;\n")},
+		"orders/order.sql": {Data: []byte("\n-- query: FindOrder\nSELECT * FROM \"order\";\n")},
+	}
+	qs, err := LoadQuerySet(fsys, WithDirNamespaces())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		name     string
+		wantFile string
+		wantLine int
+	}{
+		{"Ping", "users.sql", 1},
+		{"FindUser", "users.sql", 4},
+		{"orders.FindOrder", "orders/order.sql", 2},
+		{"FindCat", "", 0},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			file, line := qs.Source(tc.name)
+			if file != tc.wantFile || line != tc.wantLine {
+				t.Errorf("got %s:%d, want %s:%d", file, line, tc.wantFile, tc.wantLine)
+			}
+		})
+	}
+	if file, line := qs.FilterByTag().Source("Ping"); file != "" || line != 0 {
+		t.Errorf("got %s:%d, want no source for a query not in the set", file, line)
+	}
+}
+
+func TestQueryFileInfo(t *testing.T) {
+	modTime := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	data := []byte("-- query: Ping\nSELECT 1;\n\n-- query: FindUser\nSELECT * FROM user;\n")
+	fsys := fstest.MapFS{"users.sql": {Data: data, ModTime: modTime}}
+	qs, err := LoadQuerySet(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	for _, name := range []string{"Ping", "FindUser"} {
+		q, _ := qs.Query(name)
+		if !q.ModTime.Equal(modTime) || q.Size != int64(len(data)) {
+			t.Errorf("got %s %d, want %s %d", q.ModTime, q.Size, modTime, len(data))
+		}
+	}
+	qs, err = newQuerySet(map[string]string{"Ping": "SELECT 1;"}, nil, &config{})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q, _ := qs.Query("Ping"); !q.ModTime.IsZero() || q.Size != 0 {
+		t.Errorf("got %s %d, want no file info for a query not read from a file", q.ModTime, q.Size)
+	}
+}
+
+func TestQuerySetByKind(t *testing.T) {
+	sql := `-- query: FindCat
+SELECT * FROM cat;
+
+-- query: CreateCat
+-- alias: MakeCatTable
+CREATE TABLE cat (id INTEGER);
+
+-- query: CreatePartition
+-- kind: DDL
+SELECT create_partition('cat');
+
+-- query: CreateIndex
+CREATE INDEX cat_id ON cat (id);
+
+-- query: CountCats
+-- kind: dml
+SHOW count;
+`
+	qs, err := LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte(sql)}})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		kinds []Kind
+		want  string
+	}{
+		{[]Kind{KindDDL}, "[CreateCat CreatePartition CreateIndex]"},
+		{[]Kind{KindDML}, "[FindCat CountCats]"},
+		{[]Kind{KindDML, KindDDL}, "[FindCat CreateCat CreatePartition CreateIndex CountCats]"},
+		{[]Kind{KindProcedure}, "[]"},
+		{nil, "[]"},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			names := []string{}
+			for _, q := range qs.ByKind(tc.kinds...) {
+				names = append(names, q.Name)
+			}
+			if fmt.Sprint(names) != tc.want {
+				t.Errorf("got %v, want %s", names, tc.want)
+			}
+		})
+	}
+	_, err = LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte("-- query: FindCat\n-- kind: query\nSELECT 1;")}})
+	if want := "cannot load queries: query FindCat: invalid kind query"; fmt.Sprint(err) != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+	if ErrorCode(err) != CodeInvalidAnnotation {
+		t.Errorf("got %s, want %s", ErrorCode(err), CodeInvalidAnnotation)
+	}
+}
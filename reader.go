@@ -0,0 +1,82 @@
+package sqload
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+// defaultMaxLineSize is the length of the longest line LoadFromReader accepts by
+// default, see WithMaxLineSize.
+const defaultMaxLineSize = 1 << 20
+
+// LoadFromReader reads the SQL code from r, line by line, and binds its queries into a
+// struct, like LoadFromString does. It is meant for SQL code that does not come from a
+// file, like a network stream or a decompressor:
+//
+//	gz, err := gzip.NewReader(resp.Body)
+//	if err != nil {
+//		return err
+//	}
+//	q, err := sqload.LoadFromReader[Queries](gz)
+//
+// The lines are limited to 1 MiB by default, so a pathological input, like many
+// megabytes of minified SQL code on a single line, fails with an error naming the line
+// instead of being buffered whole; the limit can be changed with WithMaxLineSize. If r
+// can not be read or some query is not found, it will return a nil pointer and an error.
+func LoadFromReader[V Struct](r io.Reader, opts ...Option) (*V, error) {
+	cfg := newConfig(opts)
+	sql, err := readLines(r, cfg)
+	if err != nil {
+		return nil, err
+	}
+	queries, headers, err := extractQueries(sql, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return loadFromQueryMap[V](queries, headers, cfg)
+}
+
+// MustLoadFromReader is like LoadFromReader but panics if any error occurs. It
+// simplifies the safe initialization of global variables holding struct pointers
+// containing SQL queries.
+func MustLoadFromReader[V Struct](r io.Reader, opts ...Option) *V {
+	v, err := LoadFromReader[V](r, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// readLines reads the SQL code from r, line by line, with the lines limited to the
+// maximum size configured in cfg.
+func readLines(r io.Reader, cfg *config) (string, error) {
+	maxLineSize := cfg.maxLineSize
+	if maxLineSize <= 0 {
+		maxLineSize = defaultMaxLineSize
+	}
+	tooLong := func(line int) error {
+		return errorf(CodeUnreadable, "%w: line %d is longer than %d bytes, see WithMaxLineSize", ErrCannotLoadQueries, line, maxLineSize)
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize+len("\r\n"))
+	var sql strings.Builder
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) > maxLineSize {
+			return "", tooLong(line)
+		}
+		if line > 1 {
+			sql.WriteByte('\n')
+		}
+		sql.Write(scanner.Bytes())
+	}
+	if err := scanner.Err(); errors.Is(err, bufio.ErrTooLong) {
+		return "", tooLong(line + 1)
+	} else if err != nil {
+		return "", errorf(CodeUnreadable, "%w: %s", ErrCannotLoadQueries, err)
+	}
+	return sql.String(), nil
+}
@@ -0,0 +1,73 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestLoadFromReader(t *testing.T) {
+	type CatQuery struct {
+		CreateCatTable  string `query:"CreateCatTable"`
+		CreatePsychoCat string `query:"CreatePsychoCat"`
+		CreateNormalCat string `query:"CreateNormalCat"`
+		UpdateColorById string `query:"UpdateColorById"`
+	}
+	sql := "-- query: CreateCatTable\r\n" + CatTestQueries["CreateCatTable"] + "\r\n" +
+		"-- query: CreatePsychoCat\n" + CatTestQueries["CreatePsychoCat"] + "\n" +
+		"-- query: CreateNormalCat\n" + CatTestQueries["CreateNormalCat"] + "\n" +
+		"-- query: UpdateColorById\n" + CatTestQueries["UpdateColorById"]
+	q, err := LoadFromReader[CatQuery](strings.NewReader(sql))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.CreateCatTable != CatTestQueries["CreateCatTable"] {
+		t.Errorf("got %s, want %s", q.CreateCatTable, CatTestQueries["CreateCatTable"])
+	}
+	if q.UpdateColorById != CatTestQueries["UpdateColorById"] {
+		t.Errorf("got %s, want %s", q.UpdateColorById, CatTestQueries["UpdateColorById"])
+	}
+
+	testCases := []struct {
+		sql     string
+		opts    []Option
+		wantErr error
+	}{
+		{
+			"-- query: Ping\nSELECT 1;\n-- query: Long\nSELECT '" + strings.Repeat("x", 100) + "';\n",
+			[]Option{WithMaxLineSize(64)},
+			fmt.Errorf("%w: line 4 is longer than 64 bytes, see WithMaxLineSize", ErrCannotLoadQueries),
+		},
+		{
+			"-- query: Ping\nSELECT '" + strings.Repeat("x", 60) + "';",
+			[]Option{WithMaxLineSize(64)},
+			fmt.Errorf("%w: line 2 is longer than 64 bytes, see WithMaxLineSize", ErrCannotLoadQueries),
+		},
+		{
+			"-- query: Ping\nSELECT '" + strings.Repeat("x", defaultMaxLineSize) + "';",
+			nil,
+			fmt.Errorf("%w: line 2 is longer than %d bytes, see WithMaxLineSize", ErrCannotLoadQueries, defaultMaxLineSize),
+		},
+		{
+			"-- query: Ping\nSELECT '" + strings.Repeat("x", 53) + "';\r\n",
+			[]Option{WithMaxLineSize(64)},
+			nil,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := LoadFromReader[map[string]string](strings.NewReader(tc.sql), tc.opts...)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Errorf("got %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+
+	failing := errors.New("connection reset")
+	_, err = LoadFromReader[map[string]string](iotest.ErrReader(failing))
+	if !errors.Is(err, ErrCannotLoadQueries) || err.Error() != "cannot load queries: connection reset" {
+		t.Errorf("got %v, want cannot load queries: connection reset", err)
+	}
+}
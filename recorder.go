@@ -0,0 +1,88 @@
+package sqload
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// NameResolver traces SQL code back to the name of its query. It is implemented by
+// QuerySet and QueryStore, see QuerySet.NameOf.
+type NameResolver interface {
+	NameOf(sql string) (string, bool)
+}
+
+// Execution is a statement run through a driver returned by Recorder.Driver.
+type Execution struct {
+	// Name is the name of the query of the statement, or an empty string if the statement
+	// is not one of the queries. Variants are named Name:variant (see QuerySet.Select).
+	Name string
+	// SQL is the SQL code of the statement.
+	SQL string
+	// Duration is the time the statement took, from the moment it was sent until its
+	// rows were closed, if it returns rows.
+	Duration time.Duration
+	// Rows is the number of rows read, for the statements that return rows, or the number
+	// of rows affected, for the others. It is -1 if it is not known.
+	Rows int64
+	// Err is the error of the statement, if any.
+	Err error
+}
+
+// String returns a description of the execution without its SQL code, so it can be
+// logged even when the queries are sensitive:
+//
+//	query FindUser: 1.2ms, 3 rows
+func (e Execution) String() string {
+	name := "unnamed statement"
+	if e.Name != "" {
+		name = "query " + e.Name
+	}
+	s := fmt.Sprintf("%s: %s", name, e.Duration)
+	if e.Rows >= 0 {
+		s += fmt.Sprintf(", %d rows", e.Rows)
+	}
+	if e.Err != nil {
+		s += ", error: " + e.Err.Error()
+	}
+	return s
+}
+
+// Recorder reports the statements run through a database/sql driver, traced back to the
+// names of their queries, so logs and metrics can tell which query was slow or failed
+// instead of showing raw SQL code:
+//
+//	recorder := &sqload.Recorder{
+//		Queries: store,
+//		Log: func(e sqload.Execution) {
+//			log.Print(e)
+//		},
+//	}
+//	sql.Register("recorded-postgres", recorder.Driver(&pq.Driver{}))
+//	db, err := sql.Open("recorded-postgres", dsn)
+type Recorder struct {
+	// Queries traces the statements back to their queries. If it is nil, the names of
+	// the executions are empty.
+	Queries NameResolver
+	// Log receives every statement run, from the goroutine that ran it. The statements
+	// that return rows are reported when their rows are closed.
+	Log func(Execution)
+}
+
+// Driver returns a driver that runs the statements with the driver d and reports them
+// to Log.
+func (r *Recorder) Driver(d driver.Driver) driver.Driver {
+	return wrapDriver(d, r.record)
+}
+
+// record reports the statement query to Log.
+func (r *Recorder) record(query string, duration time.Duration, rows int64, err error) {
+	if r.Log == nil {
+		return
+	}
+	e := Execution{SQL: query, Duration: duration, Rows: rows, Err: err}
+	if r.Queries != nil {
+		e.Name, _ = r.Queries.NameOf(query)
+	}
+	r.Log(e)
+}
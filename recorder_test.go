@@ -0,0 +1,85 @@
+package sqload
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	store := NewQueryStore(WithDialect(DialectPostgres))
+	err := store.Reload(fstest.MapFS{"q.sql": {Data: []byte("-- query: FindUser\nSELECT * FROM user WHERE id = :id;\n\n-- query: DeleteUser\nDELETE FROM user WHERE id = :id;\n")}})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	var mu sync.Mutex
+	var executions []Execution
+	recorder := &Recorder{
+		Queries: store,
+		Log: func(e Execution) {
+			mu.Lock()
+			defer mu.Unlock()
+			if e.Duration < 0 {
+				t.Errorf("got a negative duration %s", e.Duration)
+			}
+			e.Duration = 0
+			executions = append(executions, e)
+		},
+	}
+	fakeDrivers.Lock()
+	fakeDrivers.n++
+	name := fmt.Sprintf("sqloadrecorded%d", fakeDrivers.n)
+	fakeDrivers.Unlock()
+	sql.Register(name, recorder.Driver(&fakeDriver{}))
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	rows, err := db.QueryContext(ctx, "SELECT * FROM user WHERE id = $1;", 1)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	for rows.Next() {
+	}
+	rows.Close()
+	if _, err := db.ExecContext(ctx, "DELETE FROM user WHERE id = $1;", 1); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if _, err := db.ExecContext(ctx, "FAIL;"); err == nil {
+		t.Fatal("err is nil")
+	}
+
+	want := []Execution{
+		{Name: "FindUser", SQL: "SELECT * FROM user WHERE id = $1;", Rows: 1},
+		{Name: "DeleteUser", SQL: "DELETE FROM user WHERE id = $1;", Rows: 1},
+		{SQL: "FAIL;", Rows: -1, Err: errors.New("statement failed")},
+	}
+	if fmt.Sprint(executions) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", executions, want)
+	}
+	for i := range want {
+		if executions[i].SQL != want[i].SQL {
+			t.Errorf("got %s, want %s", executions[i].SQL, want[i].SQL)
+		}
+	}
+	for i, wantString := range []string{
+		"query FindUser: 0s, 1 rows",
+		"query DeleteUser: 0s, 1 rows",
+		"unnamed statement: 0s, error: statement failed",
+	} {
+		if executions[i].String() != wantString {
+			t.Errorf("got %s, want %s", executions[i], wantString)
+		}
+	}
+	if got := (Execution{Name: "Ping", Duration: time.Millisecond, Rows: 3}).String(); got != "query Ping: 1ms, 3 rows" {
+		t.Errorf("got %s, want query Ping: 1ms, 3 rows", got)
+	}
+}
@@ -0,0 +1,14 @@
+package sqload
+
+// redacted replaces the messages of the errors that may quote SQL code, see
+// WithRedactedErrors.
+const redacted = "details redacted"
+
+// detail returns the message of err, the error of code called by the package that may
+// quote SQL code, or a placeholder if cfg redacts the errors.
+func (cfg *config) detail(err error) string {
+	if cfg.redactErrors {
+		return redacted
+	}
+	return err.Error()
+}
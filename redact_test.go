@@ -0,0 +1,68 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestWithRedactedErrors(t *testing.T) {
+	const secret = "SELECT salary FROM employee;"
+	fsys := fstest.MapFS{
+		"queries.sql": {Data: []byte("-- query: FindSalary\n" + secret + "\n")},
+	}
+	quoteSQL := func(sql string) error {
+		return fmt.Errorf("unexpected code %q", sql)
+	}
+	testCases := []struct {
+		opt  Option
+		want string
+	}{
+		{
+			WithTransform(func(name, sql string) (string, error) { return "", quoteSQL(sql) }),
+			"cannot load queries: query FindSalary: details redacted",
+		},
+		{
+			WithValidator(func(q Query) error { return quoteSQL(q.SQL) }),
+			"cannot load queries: query FindSalary: details redacted",
+		},
+		{
+			WithPreprocessor(func(path string, data []byte) ([]byte, error) { return nil, quoteSQL(string(data)) }),
+			"cannot load queries: file queries.sql: details redacted",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := LoadQuerySet(fsys, tc.opt)
+			if err == nil || !strings.Contains(err.Error(), secret) {
+				t.Fatalf("got %v, want an error quoting the query", err)
+			}
+			_, err = LoadQuerySet(fsys, tc.opt, WithRedactedErrors())
+			if fmt.Sprint(err) != tc.want {
+				t.Errorf("got %v, want %s", err, tc.want)
+			}
+			if !errors.Is(err, ErrCannotLoadQueries) {
+				t.Errorf("got %v, want %s", err, ErrCannotLoadQueries)
+			}
+		})
+	}
+
+	templates := fstest.MapFS{
+		"queries.sql": {Data: []byte("-- query: FindSalary\nSELECT {{.Column}} FROM employee;\n")},
+	}
+	_, err := LoadQuerySet(templates, WithTemplateData(map[string]any{}), WithRedactedErrors())
+	if want := "cannot load queries: query FindSalary: details redacted"; fmt.Sprint(err) != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+
+	type Queries struct {
+		FindSalary testStmt `query:"FindSalary"`
+	}
+	empty := map[string]string{"FindSalary": ""}
+	_, err = LoadFromQueryMap[Queries](empty, WithRedactedErrors())
+	if want := "cannot load queries: field FindSalary: details redacted"; fmt.Sprint(err) != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
@@ -0,0 +1,96 @@
+package sqload
+
+import "sync"
+
+var registry = struct {
+	sync.RWMutex
+	queries map[string]string
+}{queries: map[string]string{}}
+
+// Register adds the query name with the SQL code sql to the process-wide set of
+// registered queries. The Load functions include registered queries when they are
+// called with the WithRegistered option, so a few inline queries can be bound together
+// with the queries from files.
+//
+// If name is not a valid query name, it will return an error. Registering a name twice
+// replaces the previous SQL code.
+//
+//	func init() {
+//		sqload.Register("Ping", "SELECT 1;")
+//	}
+func Register(name, sql string) error {
+	if !validQueryNamePattern.MatchString(name) {
+		return errorf(CodeInvalidName, "%w: invalid query name %s", ErrCannotLoadQueries, name)
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	registry.queries[name] = sql
+	return nil
+}
+
+// RegisterMap is like Register but adds every entry of queries, using the key as the
+// query name and the value as its SQL code. If any name is invalid, nothing is
+// registered and it will return an error.
+func RegisterMap(queries map[string]string) error {
+	for _, name := range sortedKeys(queries) {
+		if !validQueryNamePattern.MatchString(name) {
+			return errorf(CodeInvalidName, "%w: invalid query name %s", ErrCannotLoadQueries, name)
+		}
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	for name, sql := range queries {
+		registry.queries[name] = sql
+	}
+	return nil
+}
+
+// Lookup returns the SQL code of the query name from the process-wide registry. The
+// registry holds the queries added with Register and RegisterMap, and the queries of
+// every Load call that used the WithPublish option. It is useful for frameworks and
+// middleware that only know query names at runtime.
+//
+//	if sql, found := sqload.Lookup("FindUserById"); found {
+//		rows, err := db.Query(sql, id)
+//		...
+//	}
+func Lookup(name string) (string, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	sql, found := registry.queries[name]
+	return sql, found
+}
+
+// publish adds queries to the registry without validating their names, they were
+// already validated during parsing.
+func publish(queries map[string]string) {
+	registry.Lock()
+	defer registry.Unlock()
+	for name, sql := range queries {
+		registry.queries[name] = sql
+	}
+}
+
+// publish adds the queries of qs to the registry.
+func (qs *QuerySet) publish() {
+	registry.Lock()
+	defer registry.Unlock()
+	for name, q := range qs.queries {
+		registry.queries[name] = q.SQL
+	}
+}
+
+// mergeRegistered returns a new map containing the registered queries overridden by
+// queries.
+func mergeRegistered(queries map[string]string) map[string]string {
+	registry.RLock()
+	defer registry.RUnlock()
+	merged := make(map[string]string, len(queries)+len(registry.queries))
+	for name, sql := range registry.queries {
+		merged[name] = sql
+	}
+	for name, sql := range queries {
+		merged[name] = sql
+	}
+	return merged
+}
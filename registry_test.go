@@ -0,0 +1,79 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func resetRegistry() {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.queries = map[string]string{}
+}
+
+func TestRegister(t *testing.T) {
+	defer resetRegistry()
+	err := Register("not-a-valid-name", "SELECT 1;")
+	want := fmt.Errorf("%w: invalid query name not-a-valid-name", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Fatalf("got %s, want %s", err, want)
+	}
+	if err := Register("Ping", "SELECT 1;"); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if err := Register("Ping", "SELECT 2;"); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if registry.queries["Ping"] != "SELECT 2;" {
+		t.Errorf("got %s, want %s", registry.queries["Ping"], "SELECT 2;")
+	}
+}
+
+func TestRegisterMap(t *testing.T) {
+	defer resetRegistry()
+	err := RegisterMap(map[string]string{
+		"Ping":     "SELECT 1;",
+		"bad name": "SELECT 2;",
+	})
+	want := fmt.Errorf("%w: invalid query name bad name", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Fatalf("got %s, want %s", err, want)
+	}
+	if len(registry.queries) != 0 {
+		t.Fatalf("got %v, want an empty registry", registry.queries)
+	}
+	if err := RegisterMap(CatTestQueries); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	for name, sql := range CatTestQueries {
+		if registry.queries[name] != sql {
+			t.Errorf("got %s, want %s", registry.queries[name], sql)
+		}
+	}
+}
+
+func TestLoadQuerySetPublish(t *testing.T) {
+	defer resetRegistry()
+	fsys := fstest.MapFS{"cat.sql": {Data: []byte("-- query: FindCat\nSELECT * FROM cat;")}}
+	if _, err := LoadQuerySet(fsys); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if _, found := Lookup("FindCat"); found {
+		t.Fatal("query FindCat must not be in the registry yet")
+	}
+	qs, err := LoadQuerySet(fsys, WithPublish())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if sql, _ := Lookup("FindCat"); sql != "SELECT * FROM cat;" {
+		t.Errorf("got %s, want %s", sql, "SELECT * FROM cat;")
+	}
+	fsys["cat.sql"] = &fstest.MapFile{Data: []byte("-- query: FindCat\nSELECT id FROM cat;")}
+	if _, err := qs.Update(fsys, []string{"cat.sql"}); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if sql, _ := Lookup("FindCat"); sql != "SELECT id FROM cat;" {
+		t.Errorf("got %s, want %s", sql, "SELECT id FROM cat;")
+	}
+}
@@ -0,0 +1,55 @@
+package sqload
+
+import "sort"
+
+// LoadReport holds the problems found by the Load functions that did not make them fail,
+// see WithReport.
+type LoadReport struct {
+	// Warnings are the problems found, in the order they were found, like the files that
+	// could not be read when skipping them, see WithSkipUnreadable.
+	Warnings []error
+	// Failures are the fields that could not be bound by a partial load, see
+	// LoadPartialFromFS.
+	Failures []*FieldError
+	// Shadowed are the queries defined in more than one file, sorted by name. The
+	// definition of the last file read wins, and the others are silently replaced.
+	Shadowed []ShadowedQuery
+}
+
+// ShadowedQuery is a query defined in more than one file, see LoadReport.
+type ShadowedQuery struct {
+	// Name is the name of the query.
+	Name string
+	// File is the file of the definition that was loaded.
+	File string
+	// Shadowed are the files of the definitions that were replaced, in the order they
+	// were read.
+	Shadowed []string
+}
+
+// addShadowed adds to r the queries of definedIn, which maps the name of each query to
+// the files it is defined in, that are defined in more than one file.
+func (r *LoadReport) addShadowed(definedIn map[string][]string) {
+	names := make([]string, 0, len(definedIn))
+	for name, files := range definedIn {
+		if len(files) > 1 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		files := definedIn[name]
+		r.Shadowed = append(r.Shadowed, ShadowedQuery{Name: name, File: files[len(files)-1], Shadowed: files[:len(files)-1]})
+	}
+}
+
+// warn adds err to the warnings of the report of cfg, if any, and passes it to its
+// OnWarning event.
+func (cfg *config) warn(err error) {
+	if cfg.report != nil {
+		cfg.report.Warnings = append(cfg.report.Warnings, err)
+	}
+	if cfg.events.OnWarning != nil {
+		cfg.events.OnWarning(err)
+	}
+}
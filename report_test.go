@@ -0,0 +1,27 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReportShadowed(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.sql": {Data: []byte("-- query: FindUser\nSELECT 1;\n\n-- query: Ping\nSELECT 1;\n")},
+		"b.sql": {Data: []byte("-- query: FindUser\nSELECT 2;\n\n-- query: FindCat\nSELECT 2;\n")},
+		"c.sql": {Data: []byte("-- query: FindUser\nSELECT 3;\n\n-- query: Ping\nSELECT 3;\n")},
+	}
+	var report LoadReport
+	queries, err := LoadFromFS[map[string]string](fsys, WithReport(&report))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if got, want := (*queries)["FindUser"], "SELECT 3;"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	want := "[{FindUser c.sql [a.sql b.sql]} {Ping c.sql [a.sql]}]"
+	if got := fmt.Sprint(report.Shadowed); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
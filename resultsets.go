@@ -0,0 +1,119 @@
+package sqload
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// rowWords are the words that make a statement return rows when it starts with them.
+var rowWords = map[string]bool{
+	"SELECT": true, "WITH": true, "VALUES": true, "SHOW": true, "TABLE": true,
+	"EXEC": true, "EXECUTE": true, "CALL": true,
+}
+
+// ResultSets are the rows of a query made of several statements that return a result
+// set each, like the reporting procedures of SQL Server, see QueryResultSets. The current
+// result set is read with the methods of the embedded *sql.Rows, and the next one is
+// moved to with NextResultSet.
+type ResultSets struct {
+	*sql.Rows
+	statements []string // statements of the query that return rows, in order
+	index      int
+}
+
+// QueryResultSets binds the query name from the QuerySet qs with args (see
+// QuerySet.Bind) and runs it on db, returning its result sets one after the other, each
+// one mapped back to the statement of the query that returned it:
+//
+//	-- query: SalesReport
+//	SELECT region, sum(total) FROM sale WHERE day = :day GROUP BY region;
+//	SELECT product, sum(total) FROM sale WHERE day = :day GROUP BY product;
+//
+//	sets, err := sqload.QueryResultSets(ctx, db, qs, "SalesReport", map[string]any{"day": day})
+//	if err != nil {
+//		return err
+//	}
+//	defer sets.Close()
+//	for ok := true; ok; ok = sets.NextResultSet() {
+//		log.Printf("result set %d: %s", sets.Index(), sets.Statement())
+//		for sets.Next() {
+//			...
+//		}
+//	}
+//
+// Only the drivers that support several result sets, through the
+// driver.RowsNextResultSet interface, return more than one.
+func QueryResultSets(ctx context.Context, db Queryer, qs *QuerySet, name string, args map[string]any) (*ResultSets, error) {
+	query, queryArgs, err := qs.Bind(name, args)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, query, queryArgs...)
+	if err != nil {
+		return nil, err
+	}
+	q := qs.queries[name]
+	return &ResultSets{Rows: rows, statements: rowStatements(q.SQL, qs.dialect)}, nil
+}
+
+// NextResultSet is like the NextResultSet method of *sql.Rows, but it also moves the
+// statement the result set is mapped to, see Statement.
+func (r *ResultSets) NextResultSet() bool {
+	if !r.Rows.NextResultSet() {
+		return false
+	}
+	r.index++
+	return true
+}
+
+// Index returns the position of the current result set, starting at 0.
+func (r *ResultSets) Index() int {
+	return r.index
+}
+
+// Statement returns the statement of the query, as written in its SQL code, that returned
+// the current result set. The result sets are mapped to the statements that return rows
+// in order; the result sets left once all of them are mapped, like the ones of a
+// procedure returning several result sets, are mapped to the last one. If the query has
+// no statement that returns rows, it returns an empty string.
+func (r *ResultSets) Statement() string {
+	if len(r.statements) == 0 {
+		return ""
+	}
+	if r.index >= len(r.statements) {
+		return r.statements[len(r.statements)-1]
+	}
+	return r.statements[r.index]
+}
+
+// rowStatements returns the statements of the SQL code sql, written in dialect d, that
+// return rows: the ones starting with a word of rowWords, or containing a RETURNING or
+// OUTPUT clause. Unlike SplitStatements, the T-SQL code is split at the semicolons too.
+func rowStatements(sql string, d Dialect) []string {
+	statements := []string{}
+	start := -1
+	returnsRows := false
+	add := func(end int) {
+		if start != -1 && returnsRows {
+			statements = append(statements, strings.TrimSpace(sql[start:end]))
+		}
+		start, returnsRows = -1, false
+	}
+	for _, tok := range tokenize(sql, d) {
+		switch {
+		case tok.kind == tokenSemicolon:
+			add(tok.end)
+		case tok.isTrivia():
+		case start == -1:
+			start = tok.start
+			returnsRows = tok.kind == tokenWord && rowWords[strings.ToUpper(tok.text(sql))]
+		case tok.kind == tokenWord:
+			if word := strings.ToUpper(tok.text(sql)); word == "RETURNING" || word == "OUTPUT" {
+				returnsRows = true
+			}
+		}
+	}
+	add(len(sql))
+	return statements
+}
@@ -0,0 +1,110 @@
+package sqload
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestQueryResultSets(t *testing.T) {
+	qs, err := LoadQuerySet(fstest.MapFS{"reports.sql": {Data: []byte(`
+-- query: SalesReport
+SET NOCOUNT ON;
+SELECT region, sum(total) FROM sale WHERE day = :day GROUP BY region;
+-- The totals by product
+SELECT product, sum(total) FROM sale WHERE day = :day GROUP BY product;
+
+-- query: ArchiveReport
+EXEC archive_sales @day = :day;
+`)}}, WithDialect(DialectTSQL))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	_, db := openFakeDB(t)
+	testCases := []struct {
+		name     string
+		args     map[string]any
+		wantSets []string
+		wantErr  error
+	}{
+		{
+			name: "SalesReport",
+			args: map[string]any{"day": "2024-01-01"},
+			wantSets: []string{
+				"0: SELECT region, sum(total) FROM sale WHERE day = :day GROUP BY region;",
+				"1: SELECT product, sum(total) FROM sale WHERE day = :day GROUP BY product;",
+				"2: SELECT product, sum(total) FROM sale WHERE day = :day GROUP BY product;",
+			},
+		},
+		{
+			name:     "ArchiveReport",
+			args:     map[string]any{"day": "2024-01-01"},
+			wantSets: []string{"0: EXEC archive_sales @day = :day;"},
+		},
+		{
+			name:    "SalesReport",
+			args:    map[string]any{},
+			wantErr: fmt.Errorf("missing value for parameter day"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			sets, err := QueryResultSets(context.Background(), db, qs, tc.name, tc.args)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer sets.Close()
+			gotSets := []string{}
+			for ok := true; ok; ok = sets.NextResultSet() {
+				for sets.Next() {
+				}
+				gotSets = append(gotSets, fmt.Sprintf("%d: %s", sets.Index(), sets.Statement()))
+			}
+			if strings.Join(gotSets, "\n") != strings.Join(tc.wantSets, "\n") {
+				t.Errorf("got %q, want %q", gotSets, tc.wantSets)
+			}
+		})
+	}
+}
+
+func TestRowStatements(t *testing.T) {
+	testCases := []struct {
+		sql  string
+		d    Dialect
+		want []string
+	}{
+		{
+			"SELECT 1; SELECT ';'",
+			DialectGeneric,
+			[]string{"SELECT 1;", "SELECT ';'"},
+		},
+		{
+			"INSERT INTO cat (name) VALUES ('Tom'); UPDATE cat SET name = 'Felix' RETURNING id;",
+			DialectPostgres,
+			[]string{"UPDATE cat SET name = 'Felix' RETURNING id;"},
+		},
+		{
+			"-- the cats\nWITH c AS (SELECT 1) SELECT * FROM c",
+			DialectGeneric,
+			[]string{"WITH c AS (SELECT 1) SELECT * FROM c"},
+		},
+		{
+			"DELETE FROM cat;",
+			DialectGeneric,
+			[]string{},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			got := rowStatements(tc.sql, tc.d)
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package sqload
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Execer is the interface used to execute statements. It is implemented by *sql.DB,
+// *sql.Conn and *sql.Tx.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// ExecScript splits the SQL code script into statements with SplitStatements and
+// executes them in order, stopping at the first one that fails. It is meant to run seed
+// data and setup blocks, like the PRAGMA and ATTACH statements of SQLite, that are
+// loaded as a single query.
+//
+// When db is a *sql.DB, all the statements are executed on the same connection, so the
+// settings applied by statements that only affect the current connection (PRAGMA,
+// ATTACH, SET...) are seen by the statements that follow them.
+//
+//	-- query: SetupAnalytics
+//	PRAGMA foreign_keys = ON;
+//	ATTACH DATABASE 'analytics.db' AS analytics;
+//	CREATE TABLE IF NOT EXISTS analytics.visit (cat_id INTEGER REFERENCES cat (id));
+//
+//	err := sqload.ExecScript(ctx, db, Q.SetupAnalytics, sqload.DialectSQLite)
+func ExecScript(ctx context.Context, db Execer, script string, d Dialect) error {
+	if pool, ok := db.(*sql.DB); ok {
+		conn, err := pool.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		db = conn
+	}
+	for i, statement := range SplitStatements(script, d) {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package sqload
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExecScript(t *testing.T) {
+	d, db := openFakeDB(t)
+	// Keep another connection busy so the pool would hand out a different one
+	busy, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	defer busy.Close()
+	q := MustLoadFromString[struct {
+		SetupAnalytics string `query:"SetupAnalytics"`
+	}](strings.TrimSpace(`
+-- query: SetupAnalytics
+PRAGMA foreign_keys = ON;
+ATTACH DATABASE 'analytics.db' AS analytics;
+CREATE TRIGGER log_visit AFTER INSERT ON cat
+BEGIN
+    INSERT INTO analytics.visit (cat_id) VALUES (NEW.id);
+    UPDATE cat SET visits = CASE WHEN visits IS NULL THEN 1 ELSE visits + 1 END WHERE id = NEW.id;
+END;
+`))
+	if err := ExecScript(context.Background(), db, q.SetupAnalytics, DialectSQLite); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedLog := []string{
+		"2: PRAGMA foreign_keys = ON;",
+		"2: ATTACH DATABASE 'analytics.db' AS analytics;",
+		"2: CREATE TRIGGER log_visit AFTER INSERT ON cat\nBEGIN\n    INSERT INTO analytics.visit (cat_id) VALUES (NEW.id);\n    UPDATE cat SET visits = CASE WHEN visits IS NULL THEN 1 ELSE visits + 1 END WHERE id = NEW.id;\nEND;",
+	}
+	if fmt.Sprintf("%q", d.Log()) != fmt.Sprintf("%q", wantedLog) {
+		t.Errorf("got %q, want %q", d.Log(), wantedLog)
+	}
+	// Execution stops at the first statement that fails
+	err = ExecScript(context.Background(), busy, "SELECT 1; SELECT FAIL; SELECT 3;", DialectSQLite)
+	if err == nil || err.Error() != "statement 2: statement failed" {
+		t.Fatalf("got %v, want %s", err, "statement 2: statement failed")
+	}
+	if log := d.Log(); log[len(log)-1] != "1: SELECT FAIL;" {
+		t.Errorf("got %q, want the failed statement last", log)
+	}
+}
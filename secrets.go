@@ -0,0 +1,191 @@
+package sqload
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// secretPatterns match the secrets recognized by their format alone, wherever they are
+// written in a query, with a description of each.
+var secretPatterns = []struct {
+	pattern *regexp.Regexp
+	what    string
+}{
+	{regexp.MustCompile(`(?i)\b[a-z][a-z0-9+.-]*://[^\s:/@'"]+:[^\s/@'"]+@`), "a connection string with a password"},
+	{regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`), "a private key"},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "an AWS access key"},
+	{regexp.MustCompile(`\bgh[oprsu]_[A-Za-z0-9]{36}\b`), "a GitHub token"},
+	{regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`), "a Slack token"},
+	{regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{16,}`), "a Stripe key"},
+}
+
+// connStringPasswordPattern matches the password of a key=value connection string, like
+// Server=db;User Id=sa;Password=hunter2.
+var connStringPasswordPattern = regexp.MustCompile(`(?i)(?:^|[;\s])(?:password|pwd)\s*=\s*[^;\s]`)
+
+// secretNameWords are the words that make a column or a setting hold a secret when its
+// name ends with them, like user_password or access_token, unless it also contains hash,
+// like password_hash. The names that only start with them, like token_type, are not
+// secrets.
+var secretNameWords = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "private_key"}
+
+// DenySecrets returns a rule, named deny-secrets, that forbids writing credentials in the
+// SQL code of the queries, so test passwords committed in seed queries are caught before
+// they leak:
+//
+//   - string literals assigned or inserted into columns named like secrets, like
+//     password = 'hunter2' or the password of
+//     INSERT INTO user (email, password) VALUES ('a@b.c', 'hunter2');
+//   - the passwords of CREATE USER ... PASSWORD 'hunter2' and IDENTIFIED BY 'hunter2';
+//   - connection strings with a password, like postgres://app:hunter2@db/app or
+//     'Server=db;Password=hunter2';
+//   - private keys and the tokens of well-known services, like AWS access keys.
+//
+// The messages never include the secrets. The values passed as parameters are allowed.
+func DenySecrets() Rule {
+	return Rule{
+		Name: "deny-secrets",
+		Check: func(q Query, d Dialect) []string {
+			messages := []string{}
+			add := func(message string) {
+				if !containsString(messages, message) {
+					messages = append(messages, message)
+				}
+			}
+			for _, p := range secretPatterns {
+				if p.pattern.MatchString(q.SQL) {
+					add(fmt.Sprintf("the query holds %s", p.what))
+				}
+			}
+			tokens := significantTokens(q.SQL, d)
+			for i, tok := range tokens {
+				if tok.kind != tokenString {
+					continue
+				}
+				if connStringPasswordPattern.MatchString(unquoteString(tok.text(q.SQL))) {
+					add("the query holds a connection string with a password")
+				}
+				if name := secretTarget(q.SQL, tokens, i); name != "" && unquoteString(tok.text(q.SQL)) != "" {
+					add(fmt.Sprintf("%s is set to a string literal, pass it as a parameter instead", name))
+				}
+			}
+			for _, name := range insertedSecrets(q.SQL, tokens) {
+				add(fmt.Sprintf("%s is set to a string literal, pass it as a parameter instead", name))
+			}
+			return messages
+		},
+	}
+}
+
+// secretTarget returns the name of the column or setting named like a secret that the
+// string literal tokens[i] is assigned to, as in password = 'x', PASSWORD 'x' or
+// IDENTIFIED BY 'x', or an empty string if there is none.
+func secretTarget(sql string, tokens []token, i int) string {
+	if i < 1 {
+		return ""
+	}
+	prev := strings.ToUpper(tokens[i-1].text(sql))
+	switch {
+	case prev == "PASSWORD":
+		return "the password"
+	case prev == "BY" && i >= 2 && strings.ToUpper(tokens[i-2].text(sql)) == "IDENTIFIED":
+		return "the password"
+	case prev == "=" && i >= 2 && isSecretName(identName(tokens[i-2], sql)):
+		return identName(tokens[i-2], sql)
+	case prev == "=" && i >= 3 && tokens[i-2].text(sql) == ":" && isSecretName(identName(tokens[i-3], sql)):
+		return identName(tokens[i-3], sql)
+	}
+	return ""
+}
+
+// insertedSecrets returns the names of the columns named like secrets that the INSERT
+// statements of sql, made of the significant tokens tokens, set to string literals.
+func insertedSecrets(sql string, tokens []token) []string {
+	names := []string{}
+	for i := 0; i < len(tokens); i++ {
+		if strings.ToUpper(tokens[i].text(sql)) != "INSERT" {
+			continue
+		}
+		// Find the column list, which follows the table name
+		for i < len(tokens) && tokens[i].text(sql) != "(" && tokens[i].kind != tokenSemicolon {
+			i++
+		}
+		columns := []string{}
+		for i++; i < len(tokens) && tokens[i].text(sql) != ")"; i++ {
+			if text := tokens[i].text(sql); text != "," && text != "." {
+				if len(columns) == 0 || tokens[i-1].text(sql) == "," {
+					columns = append(columns, "")
+				}
+				columns[len(columns)-1] = identName(tokens[i], sql)
+			}
+		}
+		if i+1 >= len(tokens) || strings.ToUpper(tokens[i+1].text(sql)) != "VALUES" {
+			continue
+		}
+		// Match the values of every row with the columns
+		depth, column, values := 0, 0, 0
+		for i += 2; i < len(tokens) && tokens[i].kind != tokenSemicolon; i++ {
+			switch text := tokens[i].text(sql); {
+			case text == "(":
+				depth++
+				if depth == 1 {
+					column, values = 0, 0
+				}
+			case text == ")":
+				depth--
+			case text == "," && depth == 1:
+				column, values = column+1, 0
+			case depth == 0 && text != ",":
+				i = len(tokens)
+			case depth >= 1:
+				values++
+				if depth == 1 && values == 1 && tokens[i].kind == tokenString && column < len(columns) &&
+					isSecretName(columns[column]) && unquoteString(text) != "" &&
+					(i+1 == len(tokens) || tokens[i+1].text(sql) == "," || tokens[i+1].text(sql) == ")") {
+					if !containsString(names, columns[column]) {
+						names = append(names, columns[column])
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// identName returns the name of the identifier tok of sql, without quotes, or an empty
+// string if tok is not an identifier.
+func identName(tok token, sql string) string {
+	if tok.kind != tokenWord && tok.kind != tokenQuotedIdent {
+		return ""
+	}
+	return unquoteIdent(tok.text(sql))
+}
+
+// isSecretName reports whether the column or setting name is named like a secret, see
+// secretNameWords.
+func isSecretName(name string) bool {
+	lower := strings.ToLower(name)
+	if lower == "pwd" || lower == "pass" {
+		return true
+	}
+	if strings.Contains(lower, "hash") {
+		return false
+	}
+	for _, word := range secretNameWords {
+		if strings.HasSuffix(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// unquoteString returns the contents of the string literal lit, without its quotes and
+// its prefix, like the E of E'...'.
+func unquoteString(lit string) string {
+	start := strings.IndexAny(lit, `'"`)
+	if start == -1 || len(lit)-start < 2 {
+		return lit
+	}
+	return lit[start+1 : len(lit)-1]
+}
@@ -0,0 +1,175 @@
+package sqload
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// Queryer is the interface used to run queries. It is implemented by *sql.DB, *sql.Conn
+// and *sql.Tx.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// ShadowReport compares a query with the variant run as its shadow.
+type ShadowReport struct {
+	// Name is the name of the query.
+	Name string
+	// Variant is the name of the variant run as the shadow.
+	Variant string
+	// PrimaryRows and ShadowRows are the number of rows returned by each query. The rows
+	// of the primary query are counted as they are read, so they only match its result if
+	// it is read to the end.
+	PrimaryRows, ShadowRows int
+	// PrimaryDuration and ShadowDuration are the time each query took, from the moment it
+	// was sent until its last row was read.
+	PrimaryDuration, ShadowDuration time.Duration
+	// PrimaryErr and ShadowErr are the errors returned by each query, if any.
+	PrimaryErr, ShadowErr error
+}
+
+// Differs reports whether the queries returned a different number of rows or only one
+// of them failed.
+func (r ShadowReport) Differs() bool {
+	return r.PrimaryRows != r.ShadowRows || (r.PrimaryErr == nil) != (r.ShadowErr == nil)
+}
+
+// Shadow runs queries together with a variant of them, the shadow, to validate a
+// rewrite of a query with real traffic before switching to it. The caller only ever sees
+// the rows of the primary query; the shadow runs in its own goroutine, its rows are
+// discarded and the comparison of both queries is sent to Report.
+//
+//	shadow := &sqload.Shadow{
+//		DB:    db,
+//		Flags: sqload.Flags{"FindUser": "fast"},
+//		Report: func(r sqload.ShadowReport) {
+//			if r.Differs() {
+//				log.Printf("shadow %s of %s differs: %+v", r.Variant, r.Name, r)
+//			}
+//		},
+//	}
+//	rows, err := shadow.Query(ctx, store.QuerySet(), "FindUser", map[string]any{"email": email})
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//
+// Only use it with queries that do not modify data, since both queries are run.
+type Shadow struct {
+	// DB runs the queries.
+	DB Queryer
+	// Flags chooses the variant run as the shadow of each query. Queries without a
+	// variant in Flags are run alone.
+	Flags Flags
+	// Timeout limits the time the shadow can take. The shadow is not canceled with the
+	// context of the primary query, so it can outlive the request; if Timeout is 0 it is
+	// not limited.
+	Timeout time.Duration
+	// Report receives the comparison of every shadowed query, from another goroutine.
+	Report func(ShadowReport)
+}
+
+// ShadowRows are the rows of the primary query run by Shadow. They must be closed, like
+// any *sql.Rows, for the comparison to be reported.
+type ShadowRows struct {
+	*sql.Rows
+	report   ShadowReport
+	start    time.Time
+	shadow   chan ShadowReport // nil if there is no shadow
+	callback func(ShadowReport)
+	once     sync.Once
+}
+
+// Query binds the query name from the QuerySet qs with args (see QuerySet.Bind) and runs
+// it, along with the variant chosen by Flags as its shadow. The error and rows of the
+// primary query are returned as if the shadow did not exist.
+func (s *Shadow) Query(ctx context.Context, qs *QuerySet, name string, args map[string]any) (*ShadowRows, error) {
+	primary, primaryArgs, err := qs.Bind(name, args)
+	if err != nil {
+		return nil, err
+	}
+	r := &ShadowRows{report: ShadowReport{Name: name}, callback: s.Report}
+	if variant, found := qs.variants[name][s.Flags[name]]; found {
+		r.report.Variant = variant.Variant
+		r.shadow = make(chan ShadowReport, 1)
+		go s.run(r.shadow, qs, variant, args)
+	}
+	r.start = time.Now()
+	r.Rows, err = s.DB.QueryContext(ctx, primary, primaryArgs...)
+	if err != nil {
+		r.report.PrimaryErr = err
+		r.finish()
+		return nil, err
+	}
+	return r, nil
+}
+
+// run runs the shadow q and sends its results to results.
+func (s *Shadow) run(results chan<- ShadowReport, qs *QuerySet, q Query, args map[string]any) {
+	report := ShadowReport{}
+	defer func() { results <- report }()
+	ctx := context.Background()
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	shadow, shadowArgs, err := BindParams(q.SQL, qs.dialect, args, qs.paramStyles...)
+	if err != nil {
+		report.ShadowErr = err
+		return
+	}
+	start := time.Now()
+	rows, err := s.DB.QueryContext(ctx, shadow, shadowArgs...)
+	if err != nil {
+		report.ShadowErr = err
+		report.ShadowDuration = time.Since(start)
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		report.ShadowRows++
+	}
+	report.ShadowErr = rows.Err()
+	report.ShadowDuration = time.Since(start)
+}
+
+// Next is like the Next method of *sql.Rows, but it counts the rows read.
+func (r *ShadowRows) Next() bool {
+	if r.Rows.Next() {
+		r.report.PrimaryRows++
+		return true
+	}
+	r.finish()
+	return false
+}
+
+// Close is like the Close method of *sql.Rows, but it also reports the comparison of the
+// primary query and its shadow once the shadow finishes.
+func (r *ShadowRows) Close() error {
+	r.finish()
+	return r.Rows.Close()
+}
+
+// finish records the results of the primary query and reports them, without waiting
+// for the shadow. It only has an effect the first time it is called.
+func (r *ShadowRows) finish() {
+	r.once.Do(func() {
+		r.report.PrimaryDuration = time.Since(r.start)
+		if r.Rows != nil && r.report.PrimaryErr == nil {
+			r.report.PrimaryErr = r.Rows.Err()
+		}
+		if r.shadow == nil || r.callback == nil {
+			return
+		}
+		go func(report ShadowReport) {
+			shadow := <-r.shadow
+			report.ShadowRows = shadow.ShadowRows
+			report.ShadowDuration = shadow.ShadowDuration
+			report.ShadowErr = shadow.ShadowErr
+			r.callback(report)
+		}(r.report)
+	})
+}
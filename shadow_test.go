@@ -0,0 +1,105 @@
+package sqload
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestShadowQuery(t *testing.T) {
+	fsys := fstest.MapFS{
+		"user.sql": {Data: []byte(`
+-- query: FindUser
+SELECT * FROM user WHERE email = :email;
+
+-- query: FindUser variant=fast
+SELECT * FROM user_by_email WHERE email = :email;
+
+-- query: FindUser variant=broken
+SELECT * FROM FAIL WHERE email = :email;
+`)},
+	}
+	qs, err := LoadQuerySet(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		flags       Flags
+		wantReport  bool
+		wantDiffers bool
+	}{
+		{Flags{"FindUser": "fast"}, true, false},
+		{Flags{"FindUser": "broken"}, true, true},
+		{Flags{"FindUser": "missing"}, false, false},
+		{nil, false, false},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			driver, db := openFakeDB(t)
+			reports := make(chan ShadowReport, 1)
+			shadow := &Shadow{DB: db, Flags: testCase.flags, Report: func(r ShadowReport) { reports <- r }}
+			rows, err := shadow.Query(context.Background(), qs, "FindUser", map[string]any{"email": "neto@example.com"})
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			n := 0
+			for rows.Next() {
+				n++
+			}
+			if err := rows.Close(); err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if n != 1 {
+				t.Errorf("got %d rows, want 1", n)
+			}
+			if !testCase.wantReport {
+				if log := driver.Log(); len(log) != 1 {
+					t.Errorf("got %v, want only the primary query", log)
+				}
+				return
+			}
+			r := <-reports
+			if r.Name != "FindUser" || r.Variant != testCase.flags["FindUser"] || r.PrimaryRows != 1 || r.PrimaryErr != nil {
+				t.Errorf("got %+v, want the report of FindUser", r)
+			}
+			if r.Differs() != testCase.wantDiffers {
+				t.Errorf("got %t, want %t", r.Differs(), testCase.wantDiffers)
+			}
+		})
+	}
+}
+
+func TestShadowQueryErrors(t *testing.T) {
+	qs, err := LoadQuerySet(fstest.MapFS{"user.sql": {Data: []byte("-- query: FindUser\nSELECT * FROM FAIL WHERE email = :email;")}})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	_, db := openFakeDB(t)
+	shadow := &Shadow{DB: db}
+	if _, err := shadow.Query(context.Background(), qs, "FindUser", nil); fmt.Sprint(err) != "missing value for parameter email" {
+		t.Errorf("got %v, want %s", err, "missing value for parameter email")
+	}
+	if _, err := shadow.Query(context.Background(), qs, "FindUser", map[string]any{"email": ""}); fmt.Sprint(err) != "statement failed" {
+		t.Errorf("got %v, want %s", err, "statement failed")
+	}
+}
+
+func TestShadowReportDiffers(t *testing.T) {
+	testCases := []struct {
+		report ShadowReport
+		want   bool
+	}{
+		{ShadowReport{PrimaryRows: 3, ShadowRows: 3}, false},
+		{ShadowReport{PrimaryRows: 3, ShadowRows: 2}, true},
+		{ShadowReport{ShadowErr: fmt.Errorf("timeout")}, true},
+		{ShadowReport{PrimaryErr: fmt.Errorf("timeout"), ShadowErr: fmt.Errorf("timeout")}, false},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if got := testCase.report.Differs(); got != testCase.want {
+				t.Errorf("got %t, want %t", got, testCase.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package sqload
+
+import (
+	"io/fs"
+	"sync"
+)
+
+// sharedSet is a QuerySet loaded by LoadShared, nil until a load succeeds.
+type sharedSet struct {
+	sync.Mutex
+	qs *QuerySet
+}
+
+var shared = struct {
+	sync.Mutex
+	sets map[string]*sharedSet
+}{sets: map[string]*sharedSet{}}
+
+// LoadShared is like LoadQuerySet but it loads the files of fsys only once per process
+// for each key: every call with the same key returns the same QuerySet as the first one
+// that succeeds. The errors are not kept, so a call after a failed one loads the files
+// again. Since a QuerySet is immutable, independent packages of a program that embed
+// the same files can share it without paying for the parse and the memory more than
+// once.
+//
+//	//go:embed sql
+//	var fsys embed.FS
+//
+//	qs, err := sqload.LoadShared("github.com/acme/billing/sql", fsys)
+//
+// The key identifies the files and the options: the options of the calls after the
+// first successful one are ignored, so callers loading the same files with different
+// options must use different keys. Concurrent calls with the same key wait for the one
+// loading the files to finish.
+func LoadShared(key string, fsys fs.FS, opts ...Option) (*QuerySet, error) {
+	shared.Lock()
+	set, found := shared.sets[key]
+	if !found {
+		set = &sharedSet{}
+		shared.sets[key] = set
+	}
+	shared.Unlock()
+	set.Lock()
+	defer set.Unlock()
+	if set.qs == nil {
+		qs, err := LoadQuerySet(fsys, opts...)
+		if err != nil {
+			return nil, err
+		}
+		set.qs = qs
+	}
+	return set.qs, nil
+}
@@ -0,0 +1,53 @@
+package sqload
+
+import (
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadShared(t *testing.T) {
+	fsys := fstest.MapFS{"cat.sql": {Data: []byte("-- query: FindCat\nSELECT * FROM cat;")}}
+	sets := make([]*QuerySet, 8)
+	var wg sync.WaitGroup
+	for i := range sets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			qs, err := LoadShared("TestLoadShared", fsys)
+			if err != nil {
+				t.Errorf("err must be nil, got %s", err)
+			}
+			sets[i] = qs
+		}(i)
+	}
+	wg.Wait()
+	for _, qs := range sets[1:] {
+		if qs != sets[0] {
+			t.Fatal("all the calls must return the same QuerySet")
+		}
+	}
+	if sql, _ := sets[0].Get("FindCat"); sql != "SELECT * FROM cat;" {
+		t.Errorf("got %s, want %s", sql, "SELECT * FROM cat;")
+	}
+	// The files and options of the calls after the first one are ignored
+	other, err := LoadShared("TestLoadShared", fstest.MapFS{}, WithTags("none"))
+	if err != nil || other != sets[0] {
+		t.Errorf("got %p and %v, want %p and nil", other, err, sets[0])
+	}
+	if other, _ := LoadShared("TestLoadShared/other", fsys, WithTags("none")); other.Len() != 0 {
+		t.Errorf("got %d, want %d", other.Len(), 0)
+	}
+	bad := fstest.MapFS{"bad.sql": {Data: []byte("-- query: bad-name\nSELECT 1;")}}
+	if _, err := LoadShared("TestLoadShared/bad", bad); err == nil {
+		t.Fatal("err is nil, want the error of bad-name")
+	}
+	// The errors are not kept, so the next call loads the files again
+	qs, err := LoadShared("TestLoadShared/bad", fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if again, _ := LoadShared("TestLoadShared/bad", bad); again != qs {
+		t.Errorf("got %p, want %p", again, qs)
+	}
+}
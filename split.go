@@ -0,0 +1,94 @@
+package sqload
+
+import (
+	"regexp"
+	"strings"
+)
+
+var batchSeparatorTailPattern = regexp.MustCompile(`^[ \t\r\f\v]*([0-9]+[ \t\r\f\v]*)?(--.*)?$`)
+
+// SplitStatements splits the SQL code sql into the statements it contains, following
+// the rules of dialect d. Statements are separated by semicolons, but semicolons inside
+// string literals, quoted identifiers, comments and dollar-quoted bodies do not end a
+// statement.
+//
+// Each statement keeps its terminating semicolon and the comments that precede it, and
+// it is trimmed of surrounding whitespace. Pieces containing only whitespace and
+// comments, or just a semicolon, are dropped.
+//
+// With DialectSQLite the semicolons between the BEGIN and END of a CREATE TRIGGER
+// statement do not end it.
+//
+// With DialectTSQL the code is split into batches instead: the separators are the GO
+// lines, like sqlcmd and SQL Server Management Studio do, and the GO lines are not part
+// of any batch. A count after GO (GO 5) is ignored.
+//
+//	stmts := sqload.SplitStatements(`
+//	INSERT INTO cat (name) VALUES ('Puca; the psycho cat');
+//	CREATE FUNCTION one() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;
+//	`, sqload.DialectPostgres)
+//	// stmts[0]: INSERT INTO cat (name) VALUES ('Puca; the psycho cat');
+//	// stmts[1]: CREATE FUNCTION one() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;
+func SplitStatements(sql string, d Dialect) []string {
+	statements := []string{}
+	start := 0
+	code := false
+	depth := 0 // nesting of the BEGIN...END blocks of a SQLite trigger
+	add := func(end, next int) {
+		if code {
+			statements = append(statements, strings.TrimSpace(sql[start:end]))
+		}
+		start = next
+		code = false
+		depth = 0
+	}
+	tokens := tokenize(sql, d)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case d == DialectTSQL:
+			if isBatchSeparator(sql, tok) {
+				end := lineEnd(sql, tok.start)
+				add(tok.start, end)
+				for i+1 < len(tokens) && tokens[i+1].start < end {
+					i++
+				}
+			} else if !tok.isTrivia() {
+				code = true
+			}
+		case tok.kind == tokenSemicolon:
+			if depth == 0 {
+				add(tok.end, tok.end)
+			}
+		case d == DialectSQLite && tok.kind == tokenWord:
+			switch strings.ToUpper(tok.text(sql)) {
+			case "BEGIN", "CASE":
+				if Classify(sql[start:tok.start], d) == KindProcedure {
+					depth++
+				}
+			case "END":
+				if depth > 0 {
+					depth--
+				}
+			}
+			code = true
+		case !tok.isTrivia():
+			code = true
+		}
+	}
+	add(len(sql), len(sql))
+	return statements
+}
+
+// isBatchSeparator reports whether tok is the GO of a line containing only a T-SQL batch
+// separator.
+func isBatchSeparator(sql string, tok token) bool {
+	if tok.kind != tokenWord || !strings.EqualFold(tok.text(sql), "GO") {
+		return false
+	}
+	lineStart := strings.LastIndexByte(sql[:tok.start], '\n') + 1
+	if strings.TrimSpace(sql[lineStart:tok.start]) != "" {
+		return false
+	}
+	return batchSeparatorTailPattern.MatchString(sql[tok.end:lineEnd(sql, tok.start)])
+}
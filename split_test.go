@@ -0,0 +1,128 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	testCases := []struct {
+		sql     string
+		dialect Dialect
+		want    []string
+	}{
+		{
+			"SELECT 1; SELECT 2;",
+			DialectGeneric,
+			[]string{"SELECT 1;", "SELECT 2;"},
+		},
+		{
+			"SELECT 1;\n\nSELECT 2",
+			DialectGeneric,
+			[]string{"SELECT 1;", "SELECT 2"},
+		},
+		{
+			"",
+			DialectGeneric,
+			[]string{},
+		},
+		{
+			" ;; -- nothing here\n /* nor here; */ ",
+			DialectGeneric,
+			[]string{},
+		},
+		{
+			"INSERT INTO cat (name) VALUES ('Puca; the psycho cat'); SELECT 'it''s; fine';",
+			DialectGeneric,
+			[]string{"INSERT INTO cat (name) VALUES ('Puca; the psycho cat');", "SELECT 'it''s; fine';"},
+		},
+		{
+			"-- first; statement\nSELECT 1;\n/* second; statement */\nSELECT 2;",
+			DialectGeneric,
+			[]string{"-- first; statement\nSELECT 1;", "/* second; statement */\nSELECT 2;"},
+		},
+		{
+			`SELECT "weird;column" FROM t; SELECT 2;`,
+			DialectGeneric,
+			[]string{`SELECT "weird;column" FROM t;`, "SELECT 2;"},
+		},
+		{
+			"CREATE FUNCTION one() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql; SELECT one();",
+			DialectPostgres,
+			[]string{"CREATE FUNCTION one() RETURNS int AS $$ SELECT 1; $$ LANGUAGE sql;", "SELECT one();"},
+		},
+		{
+			"DO $body$ BEGIN RAISE NOTICE '$$;'; END $body$; SELECT $1;",
+			DialectPostgres,
+			[]string{"DO $body$ BEGIN RAISE NOTICE '$$;'; END $body$;", "SELECT $1;"},
+		},
+		{
+			`SELECT E'\'; still a string'; SELECT 2;`,
+			DialectPostgres,
+			[]string{`SELECT E'\'; still a string';`, "SELECT 2;"},
+		},
+		{
+			"/* outer /* inner; */ still; a comment */ SELECT 1;",
+			DialectPostgres,
+			[]string{"/* outer /* inner; */ still; a comment */ SELECT 1;"},
+		},
+		{
+			`SELECT 'it\'s; fine', "a;b" FROM ` + "`t;1`" + `; # comment; here` + "\nSELECT 2;",
+			DialectMySQL,
+			[]string{`SELECT 'it\'s; fine', "a;b" FROM ` + "`t;1`" + `;`, "# comment; here\nSELECT 2;"},
+		},
+		{
+			"SELECT [a;b] FROM t; SELECT 2;",
+			DialectSQLite,
+			[]string{"SELECT [a;b] FROM t;", "SELECT 2;"},
+		},
+		{
+			"CREATE TABLE cat (id INT);\nGO\nCREATE PROCEDURE meow AS\nBEGIN\n  SELECT 'GO';\n  SELECT 1;\nEND\n  go 2 -- twice\nSELECT [go]\nGO",
+			DialectTSQL,
+			[]string{"CREATE TABLE cat (id INT);", "CREATE PROCEDURE meow AS\nBEGIN\n  SELECT 'GO';\n  SELECT 1;\nEND", "SELECT [go]"},
+		},
+		{
+			"GO\n/* nothing */\nGO\nSELECT 1; GO\nSELECT gopher FROM go;",
+			DialectTSQL,
+			[]string{"SELECT 1; GO\nSELECT gopher FROM go;"},
+		},
+		{
+			"SELECT 'unterminated; string",
+			DialectGeneric,
+			[]string{"SELECT 'unterminated; string"},
+		},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			statements := SplitStatements(testCase.sql, testCase.dialect)
+			if len(statements) != len(testCase.want) {
+				t.Fatalf("got %q, want %q", statements, testCase.want)
+			}
+			for j := range statements {
+				if statements[j] != testCase.want[j] {
+					t.Fatalf("got %q, want %q", statements, testCase.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitSQLiteTriggers(t *testing.T) {
+	sql := `BEGIN TRANSACTION;
+CREATE TEMP TRIGGER t AFTER INSERT ON cat BEGIN
+  SELECT CASE WHEN NEW.name = 'end' THEN RAISE(ABORT, 'no; way') END;
+  INSERT INTO log VALUES (NEW.id);
+END;
+SELECT CASE WHEN 1 THEN 2 END;
+COMMIT;`
+	want := []string{
+		"BEGIN TRANSACTION;",
+		"CREATE TEMP TRIGGER t AFTER INSERT ON cat BEGIN\n  SELECT CASE WHEN NEW.name = 'end' THEN RAISE(ABORT, 'no; way') END;\n  INSERT INTO log VALUES (NEW.id);\nEND;",
+		"SELECT CASE WHEN 1 THEN 2 END;",
+		"COMMIT;",
+	}
+	statements := SplitStatements(sql, DialectSQLite)
+	if fmt.Sprintf("%q", statements) != fmt.Sprintf("%q", want) {
+		t.Errorf("got %q, want %q", statements, want)
+	}
+}
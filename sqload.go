@@ -61,6 +61,24 @@
 //
 //	-- query: NameOfYourQuery
 //
+// Query names are made of letters, digits and underscores, and can be split into
+// namespaces with dots, like billing.FindInvoice (see WithPrefix).
+//
+// The attributes written after the name in a query comment, whose keys are declared with
+// WithAttributes, can be bound too, with the queryattr tag, to fields of type string,
+// bool, int or time.Duration:
+//
+//	-- query: FindUser timeout=2s
+//
+//	`queryattr:"FindUser.timeout"`
+//
+// The functions that read the files of the operating system, LoadFromFile, LoadFromDir,
+// ExtractQueryMapFromFile, CheckEmbed and their Must versions, are left out of the builds
+// with the sqload_noos tag, for the targets like WASM and TinyGo that have no file system
+// to read them from; these load their queries from an embed.FS with LoadFromFS:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -tags sqload_noos
+//
 // To handle errors that are specific to this package you can use:
 //
 //	`if errors.Is(err, sqload.ErrCannotLoadQueries) { ... }`
@@ -68,14 +86,17 @@
 package sqload
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"io/fs"
-	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
 	"strings"
+	"unicode"
 )
 
 // Struct is an empty interface used to give the developer a hint that the type must be
@@ -84,23 +105,73 @@ type Struct interface{}
 
 var ErrCannotLoadQueries = errors.New("cannot load queries")
 
-var queryNamePattern = regexp.MustCompile(`[ \t\n\r\f\v]*-- query:`)
-var validQueryNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
-var queryCommentPattern = regexp.MustCompile(`[ \t\n\r\f\v]*--[ \t\n\r\f\v]*(.*)$`)
-var newLinePattern = regexp.MustCompile("\r?\n")
+// queryComment is the prefix of the query comments, which start every query.
+const queryComment = "-- query:"
+
+// fixtureComment is the prefix of the comments that start the fixture blocks: the test
+// data written next to the queries, which are not queries themselves. A fixture block
+// ends the query before it and lasts until the next query comment; see the sqloadtest
+// package.
+const fixtureComment = "-- fixture:"
+
+var validQueryNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+(\.[a-zA-Z0-9_]+)*$`)
+var validNamespacePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+var attributeKeyPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
 
-func extractSql(lines []string) string {
-	sqlLines := []string{}
-	for _, line := range lines {
-		if !queryCommentPattern.MatchString(line) {
-			sqlLines = append(sqlLines, line)
+// extractSql returns the SQL code of a query, sql, written in dialect d, without its
+// comment lines. Only lines starting with a -- comment are dropped: block comments, like
+// optimizer hints, and lines that are part of a string literal or a dollar-quoted body
+// (like the body of a PL/pgSQL function) are always kept, even if they look like
+// comments. If no line is dropped, sql itself is returned, so no copy is made.
+func extractSql(sql string, d Dialect) string {
+	// Only the code up to the last line that looks like a comment has to be tokenized
+	last := -1
+	for start := 0; start <= len(sql); start = lineEnd(sql, start) + 1 {
+		if strings.HasPrefix(strings.TrimLeft(sql[start:lineEnd(sql, start)], " \t\r\f\v"), "--") {
+			last = lineEnd(sql, start)
+		}
+	}
+	if last == -1 {
+		return sql
+	}
+	// The tokens are read one at a time, as the lines are, instead of being collected
+	code := sql[:last]
+	var tok token
+	var b strings.Builder
+	dropped := false // whether any line was dropped, so b holds the lines kept
+	empty := true    // whether no line was kept yet
+	for start := 0; start <= len(sql); {
+		end := lineEnd(sql, start)
+		lineCode := start + len(sql[start:end]) - len(strings.TrimLeft(sql[start:end], " \t\r\f\v"))
+		for tok.end <= lineCode && tok.end < len(code) {
+			tok = nextToken(code, tok.end, d)
+		}
+		switch isComment := lineCode != end && tok.end > lineCode && tok.kind == tokenLineComment && tok.start == lineCode; {
+		case isComment && !dropped:
+			dropped = true
+			b.Grow(len(sql))
+			if start > 0 {
+				b.WriteString(sql[:start-1])
+				empty = false
+			}
+		case !isComment && dropped:
+			if !empty {
+				b.WriteByte('\n')
+			}
+			b.WriteString(sql[start:end])
+			empty = false
 		}
+		start = end + 1
+	}
+	if !dropped {
+		return sql
 	}
-	return strings.Join(sqlLines, "\n")
+	return b.String()
 }
 
 // ExtractQueryMap extracts the SQL code from the string and returns a map containing the queries.
-// The query name is the key in each map entry, and the SQL code is its value.
+// The query name is the key in each map entry, and the SQL code is its value. The
+// variants of the queries, like FindUser variant=fast, are left out, see Flags.
 //
 //	package main
 //
@@ -137,30 +208,232 @@ func extractSql(lines []string) string {
 //	        }
 //	}
 func ExtractQueryMap(sql string) (map[string]string, error) {
-	queries := make(map[string]string)
-	rawQueries := queryNamePattern.Split(sql, -1)
-	if len(rawQueries) <= 1 {
-		return queries, nil
+	queries, err := extractQueryMap(sql, &config{})
+	return withoutVariants(queries), err
+}
+
+func extractQueryMap(sql string, cfg *config) (map[string]string, error) {
+	queries, _, err := extractQueries(sql, cfg)
+	return queries, err
+}
+
+// extractQueries is like extractQueryMap but it also returns the header of each query,
+// with its annotations and doc comment.
+func extractQueries(sql string, cfg *config) (map[string]string, map[string]header, error) {
+	queries, headers, err := parseQueries(sql, "", cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkVariants(queries); err != nil {
+		return nil, nil, err
+	}
+	return queries, headers, nil
+}
+
+// parseQueries parses the queries of the SQL code sql and their headers. If filename is
+// not empty, sql is the contents of that file, and the errors point to the line of the
+// file they were found in.
+func parseQueries(sql, filename string, cfg *config) (map[string]string, map[string]header, error) {
+	if cfg.allFiles && filename != "" && !strings.Contains(sql, queryComment) && strings.TrimSpace(sql) != "" {
+		// The file holds a single query named after it, see WithAllFiles
+		base := path.Base(filename)
+		sql = queryComment + " " + strings.TrimSuffix(base, path.Ext(base)) + "\n" + sql
+	}
+	n := strings.Count(sql, queryComment)
+	queries := make(map[string]string, n)
+	headers := make(map[string]header, n)
+	// The query comments are found by index scanning and the queries are sliced out of
+	// sql, so only the SQL code that has to be changed is copied
+	next := strings.Index(sql, queryComment)
+	var blocks []int
+	if strings.Contains(sql, fixtureComment) || strings.Contains(sql, txComment) {
+		blocks = BlockComments(sql, cfg.dialect)
+	}
+	line, counted := 1, 0
+	var names []string // names of the current query, reused for every query
+	for i := 0; next != -1; i++ {
+		start := next + len(queryComment)
+		end := len(sql)
+		if next = strings.Index(sql[start:], queryComment); next != -1 {
+			next += start
+			end = next
+		}
+		for len(blocks) > 0 && blocks[0] < start {
+			blocks = blocks[1:]
+		}
+		if len(blocks) > 0 && blocks[0] < end {
+			end = blocks[0]
+		}
+		line += strings.Count(sql[counted:start], "\n")
+		counted = start
+		chunk := strings.TrimSpace(sql[start:end])
+		nameLine, body, _ := strings.Cut(chunk, "\n")
+		queryNames, attrs, err := parseQueryNames(strings.TrimSuffix(nameLine, "\r"), cfg.attributes, names[:0])
+		if err != nil {
+			if filename != "" {
+				return nil, nil, fmt.Errorf("%w (%s:%d)", err, filename, line)
+			}
+			return nil, nil, err
+		}
+		names = queryNames
+		if strings.Contains(body, "\r\n") {
+			body = strings.ReplaceAll(body, "\r\n", "\n")
+		}
+		querySql := body
+		if !cfg.keepComments {
+			querySql = extractSql(body, cfg.dialect)
+		}
+		h := parseHeader(body)
+		h.file, h.line, h.order = filename, line, i
+		h.attrs = attrs
+		for _, queryName := range queryNames {
+			queries[queryName] = querySql
+			headers[queryName] = h
+		}
+	}
+	cfg.emitQueries(queries, headers)
+	return queries, headers, nil
+}
+
+// BlockComments returns the positions, in order, of the fixture and transaction
+// comments of the SQL code sql, written in dialect d, that start a block and so end the
+// query written before it. Only the comments written at the start of a line count; the
+// ones inside string literals, dollar-quoted bodies or other comments, or after some SQL
+// code on the same line, are part of the query:
+//
+//	-- query: FindPending
+//	SELECT id, '-- tx: pending' AS label FROM job;
+//
+//	-- fixture: SeedJobs
+//	INSERT INTO job (id) VALUES (1);
+//
+// It is exported so the tools that read .sql files, like the sqloadtest package, split
+// them as the Load functions do.
+func BlockComments(sql string, d Dialect) []int {
+	var blocks []int
+	for _, tok := range tokenize(sql, d) {
+		if tok.kind != tokenLineComment {
+			continue
+		}
+		text := tok.text(sql)
+		if !strings.HasPrefix(text, fixtureComment) && !strings.HasPrefix(text, txComment) {
+			continue
+		}
+		if lineStart := strings.LastIndexByte(sql[:tok.start], '\n') + 1; strings.TrimLeft(sql[lineStart:tok.start], " \t") == "" {
+			blocks = append(blocks, tok.start)
+		}
+	}
+	return blocks
+}
+
+// sortedKeys returns the keys of m sorted alphabetically, so the maps of queries can be
+// checked in the same order on every run and report always the same error first.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// checkVariants checks that every query with variants has a default version.
+func checkVariants(queries map[string]string) error {
+	for _, name := range sortedKeys(queries) {
+		if base, _, isVariant := strings.Cut(name, variantSeparator); isVariant {
+			if _, found := queries[base]; !found {
+				return errorf(CodeNoDefaultVariant, "%w: query %s has variants but no default version", ErrCannotLoadQueries, base)
+			}
+		}
+	}
+	return nil
+}
+
+// parseQueryNames parses the line of a query comment that follows "-- query:", made of
+// the comma-separated names of the query and, optionally, its attributes written as
+// key=value. It appends the keys of the query in the query map, one per name, to names,
+// so the same SQL code can be bound to several names, and returns them along with the
+// attributes of the query other than variant, or nil if there are none. The keys of the
+// attributes other than variant must be in known, see WithAttributes:
+//
+//	-- query: DeleteUser, RemoveUser
+//	-- query: FindUser timeout=2s cache=false owner=payments
+func parseQueryNames(line string, known []string, names []string) ([]string, map[string]string, error) {
+	// The attributes start at the first field with an =; the line is scanned in place
+	// since it is parsed for every query
+	attrStart := len(line)
+	if eq := strings.IndexByte(line, '='); eq != -1 {
+		attrStart = strings.LastIndexFunc(line[:eq], unicode.IsSpace) + 1
 	}
-	for _, q := range rawQueries[1:] {
-		lines := newLinePattern.Split(strings.TrimSpace(q), -1)
-		queryName := lines[0]
-		if !validQueryNamePattern.MatchString(queryName) {
-			return nil, fmt.Errorf("%w: invalid query name %s", ErrCannotLoadQueries, queryName)
+	for rest, more := line[:attrStart], true; more; {
+		var name string
+		name, rest, more = strings.Cut(rest, ",")
+		name = strings.TrimSpace(name)
+		if !validQueryNamePattern.MatchString(name) {
+			return nil, nil, errorf(CodeInvalidName, "%w: invalid query name %s", ErrCannotLoadQueries, line)
 		}
-		querySql := extractSql(lines[1:])
-		queries[queryName] = querySql
+		names = append(names, name)
 	}
-	return queries, nil
+	var attrs map[string]string
+	for _, attr := range strings.Fields(line[attrStart:]) {
+		key, value, _ := strings.Cut(attr, "=")
+		switch {
+		case key == "variant" && validQueryNamePattern.MatchString(value):
+			for j, name := range names {
+				names[j] = variantKey(name, value)
+			}
+		case key != "variant" && attributeKeyPattern.MatchString(key) && !containsString(known, key):
+			return nil, nil, errorf(CodeInvalidName, "%w: unknown query attribute %s of query %s, see WithAttributes", ErrCannotLoadQueries, attr, names[0])
+		case key != "variant" && attributeKeyPattern.MatchString(key):
+			if _, found := attrs[key]; found {
+				return nil, nil, errorf(CodeConflict, "%w: duplicate query attribute %s of query %s", ErrCannotLoadQueries, key, names[0])
+			}
+			if attrs == nil {
+				attrs = map[string]string{}
+			}
+			attrs[key] = value
+		default:
+			return nil, nil, errorf(CodeInvalidName, "%w: invalid query attribute %s of query %s", ErrCannotLoadQueries, attr, names[0])
+		}
+	}
+	return names, attrs, nil
 }
 
 func findFilesWithExt(fsys fs.FS, ext string) ([]string, error) {
+	return findFiles(fsys, ext, &config{})
+}
+
+// findFiles returns the paths of the files of the fsys file system with the extension
+// ext, or of all its files but the hidden ones if cfg reads all the files (see
+// WithAllFiles), leaving out the ones the file filters of cfg reject (see
+// WithFileFilter). If cfg skips the unreadable files (see WithSkipUnreadable), the
+// directories below the root that can not be read are reported as warnings and skipped.
+func findFiles(fsys fs.FS, ext string, cfg *config) ([]string, error) {
 	files := []string{}
 	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil && cfg.skipUnreadable && path != "." {
+			cfg.warn(err)
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
 		if err != nil {
-			return fmt.Errorf("%w: %s", ErrCannotLoadQueries, err)
+			return errorf(CodeUnreadable, "%w: %s", ErrCannotLoadQueries, err)
 		}
-		if !d.IsDir() && strings.ToLower(filepath.Ext(path)) == ext {
+		if cfg.allFiles && path != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != "." && !cfg.acceptFile(path, d) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if (cfg.allFiles || strings.ToLower(filepath.Ext(path)) == ext) && cfg.acceptFile(path, d) {
 			files = append(files, path)
 		}
 		return nil
@@ -171,50 +444,479 @@ func findFilesWithExt(fsys fs.FS, ext string) ([]string, error) {
 	return files, nil
 }
 
-func loadQueriesIntoStruct(queries map[string]string, v Struct) error {
+// acceptFile reports whether all the file filters of cfg accept the file or directory
+// path, see WithFileFilter.
+func (cfg *config) acceptFile(path string, d fs.DirEntry) bool {
+	for _, filter := range cfg.fileFilters {
+		if !filter(path, d) {
+			return false
+		}
+	}
+	return true
+}
+
+// loadQueriesIntoStruct sets the fields of the struct pointed by v tagged with a query
+// name to the SQL code of that query. String fields get the whole SQL code, and []string
+// fields get the statements of the query as split by SplitStatements. Untagged struct
+// fields are bound the same way to the queries of a namespace, see bindNamespace. Fields
+// tagged with queryattr get an attribute of a query, see bindAttrField, and fields tagged
+// with querydoc get its doc comment, see bindDocField.
+//
+// v can also point to a map[string]string, which gets all the queries, or to a
+// map[string]map[string]string, which gets them grouped by namespace. The maps only get
+// the default versions of the queries, not their variants.
+func loadQueriesIntoStruct(queries map[string]string, v Struct, cfg *config) error {
 	value := reflect.ValueOf(v)
 	if value.Kind() != reflect.Pointer {
-		return fmt.Errorf("%w: v is not a pointer to a struct", ErrCannotLoadQueries)
+		return errorf(CodeBadTarget, "%w: v is not a pointer to a struct", ErrCannotLoadQueries)
 	}
 	if value.IsNil() {
-		return fmt.Errorf("%w: v is nil", ErrCannotLoadQueries)
+		return errorf(CodeBadTarget, "%w: v is nil", ErrCannotLoadQueries)
 	}
 	elem := value.Elem()
+	switch elem.Type() {
+	case reflect.TypeOf(map[string]string{}):
+		elem.Set(reflect.ValueOf(withoutVariants(queries)))
+		return nil
+	case reflect.TypeOf(map[string]map[string]string{}):
+		elem.Set(reflect.ValueOf(groupByNamespace(withoutVariants(queries))))
+		return nil
+	}
 	if elem.Kind() != reflect.Struct {
-		return fmt.Errorf("%w: v is not a pointer to a struct", ErrCannotLoadQueries)
+		return errorf(CodeBadTarget, "%w: v is not a pointer to a struct", ErrCannotLoadQueries)
+	}
+	cfg.missing = nil
+	if err := bindStruct(queries, elem, "", cfg); err != nil {
+		return err
 	}
-	queriesAndFields := map[string]int{}
+	return cfg.missingQueries()
+}
+
+// copyQueries returns a copy of queries.
+func copyQueries(queries map[string]string) map[string]string {
+	copied := make(map[string]string, len(queries))
+	for name, sql := range queries {
+		copied[name] = sql
+	}
+	return copied
+}
+
+// groupByNamespace returns the queries grouped by namespace: the part of their names
+// before the last dot, or an empty string for the queries without namespace. The
+// queries are keyed by the rest of their names in each group.
+func groupByNamespace(queries map[string]string) map[string]map[string]string {
+	namespaces := map[string]map[string]string{}
+	for name, sql := range queries {
+		namespace := ""
+		if i := strings.LastIndex(name, "."); i != -1 {
+			namespace, name = name[:i], name[i+1:]
+		}
+		if namespaces[namespace] == nil {
+			namespaces[namespace] = map[string]string{}
+		}
+		namespaces[namespace][name] = sql
+	}
+	return namespaces
+}
+
+// bindStruct binds the queries to the fields of the struct elem, see
+// loadQueriesIntoStruct. The names of the queries are relative to the namespace
+// namespace.
+func bindStruct(queries map[string]string, elem reflect.Value, namespace string, cfg *config) error {
 	for i := 0; i < elem.NumField(); i++ {
-		queryTag := elem.Type().Field(i).Tag.Get("query")
+		field := elem.Type().Field(i)
+		queryTag := field.Tag.Get("query")
 		if queryTag != "" {
-			queriesAndFields[queryTag] = i
+			if err := bindField(queries, elem, i, queryTag, namespace, cfg); err != nil && !cfg.fail(namespace+field.Name, err) {
+				return err
+			}
+			continue
 		}
+		if docTag := field.Tag.Get("querydoc"); docTag != "" {
+			if err := bindDocField(queries, elem, i, docTag, namespace, cfg); err != nil && !cfg.fail(namespace+field.Name, err) {
+				return err
+			}
+			continue
+		}
+		if attrTag := field.Tag.Get("queryattr"); attrTag != "" {
+			if err := bindAttrField(queries, elem, i, attrTag, namespace, cfg); err != nil && !cfg.fail(namespace+field.Name, err) {
+				return err
+			}
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.IsExported() && !field.Anonymous {
+			if err := bindNamespace(queries, elem.Field(i), field, namespace, cfg); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bindField binds the query named by the query tag queryTag to the field i of the struct
+// elem. The tag can override the dialect of the query with the dialect option, like
+// query:"FindUser,dialect=mysql"; the query is then taken from the files for that
+// dialect, see LoadFromFS. The Load functions that do not read files can not override
+// the dialect and return an error instead.
+func bindField(queries map[string]string, elem reflect.Value, i int, queryTag string, namespace string, cfg *config) error {
+	fieldName := elem.Type().Field(i).Name
+	queryName, d, err := parseQueryTag(queryTag, cfg.dialect)
+	if err != nil {
+		return errorf(CodeBadField, "%w: field %s: %s", ErrCannotLoadQueries, fieldName, err)
+	}
+	if d != cfg.dialect && cfg.dialectQueries == nil {
+		return errorf(CodeBadField, "%w: field %s: the dialect of query tag %s can only be overridden when loading files, see LoadFromFS", ErrCannotLoadQueries, fieldName, queryTag)
 	}
-	for queryName, fieldIndex := range queriesAndFields {
-		sql, ok := queries[queryName]
-		if !ok {
-			return fmt.Errorf("%w: could not find query %s", ErrCannotLoadQueries, queryName)
+	if d != cfg.dialect {
+		dialectQueries, err := cfg.dialectQueries(d)
+		if err != nil {
+			return err
 		}
-		field := elem.Field(fieldIndex)
-		if !field.CanSet() || field.Kind() != reflect.String {
-			return fmt.Errorf("%w: field %s cannot be changed or is not a string", ErrCannotLoadQueries, elem.Type().Field(fieldIndex).Name)
+		queries = withPrefix(dialectQueries, cfg.prefix+namespace)
+	}
+	sql, ok := queries[queryName]
+	if !ok {
+		return &missingQueryError{name: namespace + queryName}
+	}
+	warnAlias(cfg.prefix+namespace+queryName, cfg)
+	field := elem.Field(i)
+	if set, ok := customSetter(field); ok {
+		if err := set(namespace+queryName, sql); err != nil {
+			return errorf(CodeBadField, "%w: field %s: %s", ErrCannotLoadQueries, fieldName, cfg.detail(err))
 		}
+		return nil
+	}
+	switch {
+	case !field.CanSet():
+	case field.Kind() == reflect.String:
 		field.SetString(sql)
+		return nil
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		statements := reflect.ValueOf(SplitStatements(sql, d))
+		field.Set(statements.Convert(field.Type()))
+		return nil
 	}
+	return errorf(CodeBadField, "%w: field %s cannot be changed or is not a string", ErrCannotLoadQueries, fieldName)
+}
+
+// SQLSetter is implemented by the types of the fields that take the SQL code of their
+// queries themselves, like a query wrapper that validates the SQL code or registers
+// metrics for the query. The Load functions call SetSQL with the name of the query and
+// its SQL code instead of setting the field; if it returns an error, the load fails.
+// The fields whose types implement encoding.TextUnmarshaler instead, like the query
+// types of other packages, get the SQL code through UnmarshalText.
+//
+//	type Stmt struct {
+//		Name, SQL string
+//	}
+//
+//	func (s *Stmt) SetSQL(name, sql string) error {
+//		s.Name, s.SQL = name, sql
+//		return nil
+//	}
+//
+//	q, err := sqload.LoadFromFS[struct {
+//		FindUser Stmt `query:"FindUser"`
+//	}](fsys)
+type SQLSetter interface {
+	SetSQL(name, sql string) error
+}
+
+var (
+	sqlSetterType       = reflect.TypeOf((*SQLSetter)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// customSetter returns the function that sets the field field to the SQL code of a
+// query if its type, or a pointer to it, implements SQLSetter or, otherwise,
+// encoding.TextUnmarshaler, which gets the SQL code as its text. A nil pointer field is
+// set to a new value first.
+func customSetter(field reflect.Value) (func(name, sql string) error, bool) {
+	if !field.CanSet() {
+		return nil, false
+	}
+	for _, setterType := range []reflect.Type{sqlSetterType, textUnmarshalerType} {
+		var setter any
+		switch {
+		case field.Kind() == reflect.Pointer && field.Type().Implements(setterType):
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			setter = field.Interface()
+		case reflect.PointerTo(field.Type()).Implements(setterType):
+			setter = field.Addr().Interface()
+		default:
+			continue
+		}
+		if s, ok := setter.(SQLSetter); ok {
+			return s.SetSQL, true
+		}
+		u := setter.(encoding.TextUnmarshaler)
+		return func(name, sql string) error { return u.UnmarshalText([]byte(sql)) }, true
+	}
+	return nil, false
+}
+
+// bindDocField sets the field i of the struct elem, which must be a string, to the doc
+// comment of the query named by the querydoc tag docTag (see Query.Doc), so the SQL code
+// and the description of a query can be bound side by side:
+//
+//	FindUser    string `query:"FindUser"`
+//	FindUserDoc string `querydoc:"FindUser"`
+//
+// The name of the query is relative to the namespace namespace.
+func bindDocField(queries map[string]string, elem reflect.Value, i int, docTag string, namespace string, cfg *config) error {
+	fieldName := elem.Type().Field(i).Name
+	if _, found := queries[docTag]; !found {
+		return &missingQueryError{name: namespace + docTag}
+	}
+	field := elem.Field(i)
+	if !field.CanSet() || field.Kind() != reflect.String {
+		return errorf(CodeBadField, "%w: field %s cannot be changed or is not a string", ErrCannotLoadQueries, fieldName)
+	}
+	field.SetString(cfg.headers[cfg.prefix+namespace+docTag].doc)
 	return nil
 }
 
-func cat(fsys fs.FS, filenames []string) (string, error) {
-	lines := []string{}
-	for _, filename := range filenames {
-		data, err := fs.ReadFile(fsys, filename)
+// parseQueryTag parses the query tag queryTag, made of a query name optionally followed
+// by comma-separated options, and returns the query name and the dialect of the query,
+// which is d unless the tag overrides it.
+func parseQueryTag(queryTag string, d Dialect) (string, Dialect, error) {
+	name, options, _ := strings.Cut(queryTag, ",")
+	for _, option := range strings.Split(options, ",") {
+		if option == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(option, "=")
+		switch {
+		case key == "dialect" && value == "generic":
+			d = DialectGeneric
+		case key == "dialect" && engineDialects[value]:
+			d = Dialect(value)
+		default:
+			return "", "", fmt.Errorf("invalid option %s of query tag %s", option, queryTag)
+		}
+	}
+	return name, d, nil
+}
+
+// bindNamespace binds the queries of a namespace to the nested struct value, the value
+// of the field field. The namespace is the one named by the namespace tag of the field
+// or, if it has none, the one whose name matches the name of the field without regard
+// to case.
+func bindNamespace(queries map[string]string, value reflect.Value, field reflect.StructField, namespace string, cfg *config) error {
+	name, tagged := field.Tag.Lookup("namespace")
+	if !tagged {
+		name = field.Name
+		for _, queryName := range sortedKeys(queries) {
+			if ns, _, found := strings.Cut(queryName, "."); found && strings.EqualFold(ns, field.Name) {
+				name = ns
+				break
+			}
+		}
+	}
+	return bindStruct(withPrefix(queries, name+"."), value, namespace+name+".", cfg)
+}
+
+// resolveQueries applies the configuration cfg to the queries extracted from a source,
+// whose headers are in headers. It returns the queries and their headers, which include
+// the aliases, without changing queries and headers.
+func resolveQueries(queries map[string]string, headers map[string]header, cfg *config) (map[string]string, map[string]header, error) {
+	if len(cfg.includeTags) > 0 || len(cfg.excludeTags) > 0 {
+		queries = filterByTags(queries, headers, cfg)
+	}
+	if cfg.registered {
+		queries = mergeRegistered(queries)
+	}
+	if len(cfg.models) > 0 {
+		generated, err := generateModelQueries(cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, sql := range queries {
+			generated[name] = sql
+		}
+		queries = generated
+	}
+	if cfg.data != nil {
+		substituted, err := substituteData(queries, cfg)
 		if err != nil {
-			return "", fmt.Errorf("%w: %s", ErrCannotLoadQueries, err)
+			return nil, nil, err
 		}
-		lines = append(lines, string(data))
+		queries = substituted
+	}
+	if cfg.templates {
+		expanded, err := expandTemplates(queries, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		queries = expanded
+	}
+	queries, err := expandUpserts(queries, headers, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	queries, headers, err = expandAliases(queries, headers)
+	if err != nil {
+		return nil, nil, err
+	}
+	queries, err = applyTransforms(queries, cfg)
+	if err != nil {
+		return nil, nil, err
 	}
-	txt := strings.Join(lines, "\n")
-	return txt, nil
+	if err := checkAssertions(queries, headers, cfg); err != nil {
+		return nil, nil, err
+	}
+	if err := checkDeclaredParams(queries, headers, cfg); err != nil {
+		return nil, nil, err
+	}
+	if err := checkQuerySizes(queries, headers, cfg); err != nil {
+		return nil, nil, err
+	}
+	return queries, headers, nil
+}
+
+func loadFromQueryMap[V Struct](queries map[string]string, headers map[string]header, cfg *config) (*V, error) {
+	var v V
+	queries, headers, err := resolveQueries(queries, headers, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.annotationHandlers) > 0 || len(cfg.validators) > 0 || len(cfg.policies) > 0 {
+		// The handlers, validators and policies can reject queries, so they run even if
+		// the metadata is not kept
+		if _, err := newQuerySet(queries, headers, cfg); err != nil {
+			return nil, err
+		}
+	}
+	cfg.headers = headers
+	err = loadQueriesIntoStruct(withPrefix(queries, cfg.prefix), &v, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.publish {
+		publish(queries)
+	}
+	return &v, nil
+}
+
+// withPrefix returns the queries whose names start with prefix, with the prefix removed
+// from their names. If prefix is empty, it returns queries.
+func withPrefix(queries map[string]string, prefix string) map[string]string {
+	if prefix == "" {
+		return queries
+	}
+	view := map[string]string{}
+	for name, sql := range queries {
+		if strings.HasPrefix(name, prefix) {
+			view[strings.TrimPrefix(name, prefix)] = sql
+		}
+	}
+	return view
+}
+
+// readFile returns the contents of the file filename of the fsys file system, after
+// applying the preprocessors configured in cfg.
+func readFile(fsys fs.FS, filename string, cfg *config) (string, error) {
+	data, err := fs.ReadFile(fsys, filename)
+	if err != nil && cfg.skipUnreadable {
+		cfg.warn(err)
+		return "", nil
+	}
+	if err != nil {
+		return "", errorf(CodeUnreadable, "%w: %s", ErrCannotLoadQueries, err)
+	}
+	data, err = preprocess(filename, data, cfg)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ExtractQueryMapFromFS is like ExtractQueryMap but it extracts the queries from all the
+// .sql files in the fsys file system (recursively), without binding them into a struct.
+// The errors found while parsing a file point to the file and line they were found in.
+//
+//	queries, err := sqload.ExtractQueryMapFromFS(os.DirFS("migrations"))
+//	// cannot load queries: invalid query name add-users (0002_users.sql:1)
+//
+// Only the options in opts that affect the parsing, like WithPreprocessor,
+// WithKeepComments and WithDirNamespaces, have an effect.
+func ExtractQueryMapFromFS(fsys fs.FS, opts ...Option) (map[string]string, error) {
+	queries, _, err := extractQueriesFromFS(fsys, newConfig(opts))
+	return withoutVariants(queries), err
+}
+
+// extractQueriesFromFS extracts the queries of all the .sql files in the fsys file
+// system (recursively), along with their headers. Each file is parsed on its own, so a
+// query always ends with the file it starts in.
+func extractQueriesFromFS(fsys fs.FS, cfg *config) (map[string]string, map[string]header, error) {
+	src, err := parseSource(fsys, cfg, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return src.merge(cfg)
+}
+
+// parseFile reads and parses the file filename of the fsys file system, returning its
+// queries and their headers, named with the namespace of the file.
+func parseFile(fsys fs.FS, filename string, cfg *config) (map[string]string, map[string]header, error) {
+	cfg.emitFile(filename)
+	var queries map[string]string
+	var headers map[string]header
+	if cfg.mmap && cfg.dir != "" {
+		var err error
+		queries, headers, err = parseMappedFile(filepath.Join(cfg.dir, filepath.FromSlash(filename)), filename, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		sql, err := readFile(fsys, filename, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+		queries, headers, err = parseQueries(sql, filename, cfg)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if info, err := fs.Stat(fsys, filename); err == nil {
+		setFileInfo(headers, info)
+	}
+	namespace, err := fileNamespace(filename, cfg)
+	if err != nil || namespace == "" {
+		return queries, headers, err
+	}
+	namespaced := make(map[string]string, len(queries))
+	namespacedHeaders := make(map[string]header, len(headers))
+	for name, sql := range queries {
+		namespaced[namespace+name] = sql
+		namespacedHeaders[namespace+name] = headers[name]
+	}
+	return namespaced, namespacedHeaders, nil
+}
+
+// setFileInfo records the modification time and the size of the file described by info
+// in headers, the headers of the queries read from it.
+func setFileInfo(headers map[string]header, info fs.FileInfo) {
+	for name, h := range headers {
+		h.modTime, h.size = info.ModTime(), info.Size()
+		headers[name] = h
+	}
+}
+
+// fileNamespace returns the prefix added to the names of the queries of the file
+// filename: the name of its top-level directory followed by a dot if cfg enables the
+// directory namespaces (see WithDirNamespaces), or an empty string otherwise.
+func fileNamespace(filename string, cfg *config) (string, error) {
+	dir, _, found := strings.Cut(filename, "/")
+	if !cfg.dirNamespaces || !found {
+		return "", nil
+	}
+	if !validNamespacePattern.MatchString(dir) {
+		return "", errorf(CodeInvalidName, "%w: directory %s cannot be used as a namespace", ErrCannotLoadQueries, dir)
+	}
+	return dir + ".", nil
 }
 
 // LoadFromString loads the SQL code from the string and returns a pointer to a struct.
@@ -223,6 +925,9 @@ func cat(fsys fs.FS, filenames []string) (string, error) {
 // If some query has an invalid name in the string or is not found in the string, it
 // will return a nil pointer and an error.
 //
+// The options in opts customize how the queries are loaded; they are accepted by all the
+// Load functions.
+//
 //	package main
 //
 //	import (
@@ -263,174 +968,58 @@ func cat(fsys fs.FS, filenames []string) (string, error) {
 //		fmt.Printf("- UpdateFirstNameById\n%s\n\n", q.UpdateFirstNameById)
 //		fmt.Printf("- DeleteUserById\n%s\n\n", q.DeleteUserById)
 //	}
-func LoadFromString[V Struct](s string) (*V, error) {
-	var v V
-	queries, err := ExtractQueryMap(s)
+func LoadFromString[V Struct](s string, opts ...Option) (*V, error) {
+	cfg := newConfig(opts)
+	queries, headers, err := extractQueries(s, cfg)
 	if err != nil {
 		return nil, err
 	}
-	err = loadQueriesIntoStruct(queries, &v)
-	if err != nil {
-		return nil, err
-	}
-	return &v, nil
+	return loadFromQueryMap[V](queries, headers, cfg)
 }
 
 // MustLoadFromString is like LoadFromString but panics if any error occurs. It
 // simplifies the safe initialization of global variables holding struct pointers
 // containing SQL queries.
-func MustLoadFromString[V Struct](s string) *V {
-	v, err := LoadFromString[V](s)
+func MustLoadFromString[V Struct](s string, opts ...Option) *V {
+	v, err := LoadFromString[V](s, opts...)
 	if err != nil {
 		panic(err)
 	}
 	return v
 }
 
-// LoadFromFile loads the SQL code from the file filename and returns a pointer to a
-// struct. Each struct field will contain the SQL query code it was tagged with.
-//
-// If some query has an invalid name in the string or is not found in the string, it
-// will return a nil pointer and an error.
-//
-// If the file can not be read or does not exist, it will return a nil pointer and an
-// error.
-//
-// File queries.sql:
-//
-//	-- query: FindUserById
-//	SELECT first_name,
-//	       last_name,
-//	       dob,
-//	       email
-//	  FROM user
-//	 WHERE id = :id;
-//
-//	-- query: UpdateFirstNameById
-//	UPDATE user
-//	   SET first_name = 'Ernesto'
-//	 WHERE id = :id;
-//
-//	-- query: DeleteUserById
-//	DELETE FROM user
-//	      WHERE id = :id;
-//
-// File main.go:
-//
-//	package main
-//
-//	import (
-//		"fmt"
-//		"os"
-//
-//		"github.com/midir99/sqload"
-//	)
-//
-//	func main() {
-//		q, err := sqload.LoadFromFile[struct {
-//			FindUserById        string `query:"FindUserById"`
-//			UpdateFirstNameById string `query:"UpdateFirstNameById"`
-//			DeleteUserById      string `query:"DeleteUserById"`
-//		}]("queries.sql")
-//		if err != nil {
-//			fmt.Printf("Unable to load SQL queries: %s\n", err)
-//			os.Exit(1)
-//		}
-//		fmt.Printf("- FindUserById\n%s\n\n", q.FindUserById)
-//		fmt.Printf("- UpdateFirstNameById\n%s\n\n", q.UpdateFirstNameById)
-//		fmt.Printf("- DeleteUserById\n%s\n\n", q.DeleteUserById)
-//	}
-func LoadFromFile[V Struct](filename string) (*V, error) {
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return nil, fmt.Errorf("%w: %s", ErrCannotLoadQueries, err)
-	}
-	return LoadFromString[V](string(data))
-}
-
-// MustLoadFromFile is like LoadFromFile but panics if any error occurs. It simplifies
-// the safe initialization of global variables holding struct pointers containing SQL
-// queries.
-func MustLoadFromFile[V Struct](filename string) *V {
-	v, err := LoadFromFile[V](filename)
-	if err != nil {
-		panic(err)
-	}
-	return v
-}
-
-// LoadFromDir loads the SQL code from all the .sql files in the directory dirname
-// (recursively) and returns a pointer to a struct. Each struct field will contain the
-// SQL query code it was tagged with.
-//
-// If some query has an invalid name in the string or is not found in the string, it
-// will return a nil pointer and an error.
-//
-// If the directory can not be read or does not exist, it will return a nil pointer and
-// an error.
-//
-// If any .sql file can not be read, it will return a nil pointer and an error.
-//
-// Project directory:
-//
-//	.
-//	├── go.mod
-//	├── main.go
-//	└── sql
-//	    ├── cats.sql
-//	    └── users.sql
-//
-// File sql/cats.sql:
-//
-//	-- query: CreatePsychoCat
-//	INSERT INTO Cat (name, color) VALUES ('Puca', 'Orange');
-//
-// File sql/users.sql:
-//
-//	-- query: DeleteUserById
-//	DELETE FROM user WHERE id = :id;
-//
-// File main.go:
-//
-//	package main
-//
-//	import (
-//		"fmt"
-//		"os"
-//
-//		"github.com/midir99/sqload"
-//	)
-//
-//	func main() {
-//		q, err := sqload.LoadFromDir[struct {
-//			CreatePsychoCat string `query:"CreatePsychoCat"`
-//			DeleteUserById  string `query:"DeleteUserById"`
-//		}]("sql")
-//		if err != nil {
-//			fmt.Printf("Unable to load SQL queries: %s\n", err)
-//			os.Exit(1)
-//		}
-//		fmt.Printf("- CreatePsychoCat\n%s\n\n", q.CreatePsychoCat)
-//		fmt.Printf("- DeleteUserById\n%s\n\n", q.DeleteUserById)
-//	}
-func LoadFromDir[V Struct](dirname string) (*V, error) {
-	fsys := os.DirFS(dirname)
-	files, err := findFilesWithExt(fsys, ".sql")
-	if err != nil {
-		return nil, err
+// LoadFromQueryMap binds the queries in the map queries, whose keys are the query names,
+// into a struct and returns a pointer to it, like the other Load functions do with the
+// queries they read. It is meant for programs that get their queries from somewhere
+// else, like a configuration service or generated code.
+//
+// If some query has an invalid name in the map or is not found in the map, it will
+// return a nil pointer and an error.
+//
+//	q, err := sqload.LoadFromQueryMap[struct {
+//		FindUserById string `query:"FindUserById"`
+//	}](map[string]string{
+//		"FindUserById": "SELECT * FROM user WHERE id = :id;",
+//	})
+func LoadFromQueryMap[V Struct](queries map[string]string, opts ...Option) (*V, error) {
+	copied := make(map[string]string, len(queries))
+	for _, name := range sortedKeys(queries) {
+		sql := queries[name]
+		base, variant, isVariant := strings.Cut(name, variantSeparator)
+		if !validQueryNamePattern.MatchString(base) || (isVariant && !validQueryNamePattern.MatchString(variant)) {
+			return nil, errorf(CodeInvalidName, "%w: invalid query name %s", ErrCannotLoadQueries, name)
+		}
+		copied[name] = sql
 	}
-	sql, err := cat(fsys, files)
-	if err != nil {
+	if err := checkVariants(copied); err != nil {
 		return nil, err
 	}
-	return LoadFromString[V](sql)
+	return loadFromQueryMap[V](copied, nil, newConfig(opts))
 }
 
-// MustLoadFromDir is like LoadFromDir but panics if any error occurs. It simplifies the
-// safe initialization of global variables holding struct pointers containing SQL
-// queries.
-func MustLoadFromDir[V Struct](dirname string) *V {
-	v, err := LoadFromDir[V](dirname)
+// MustLoadFromQueryMap is like LoadFromQueryMap but panics if any error occurs.
+func MustLoadFromQueryMap[V Struct](queries map[string]string, opts ...Option) *V {
+	v, err := LoadFromQueryMap[V](queries, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -495,23 +1084,56 @@ func MustLoadFromDir[V Struct](dirname string) *V {
 //		fmt.Printf("- CreatePsychoCat\n%s\n\n", q.CreatePsychoCat)
 //		fmt.Printf("- DeleteUserById\n%s\n\n", q.DeleteUserById)
 //	}
-func LoadFromFS[V Struct](fsys fs.FS) (*V, error) {
-	files, err := findFilesWithExt(fsys, ".sql")
-	if err != nil {
-		return nil, err
-	}
-	sql, err := cat(fsys, files)
+//
+// Untagged fields holding a struct are bound to the queries of a namespace, like
+// users.FindUser: by default the namespace whose name matches the name of the field
+// without regard to case, or the one set with a namespace tag. Together with
+// WithDirNamespaces, each subdirectory of fsys can be bound to its own nested struct:
+//
+//	q, err := sqload.LoadFromFS[struct {
+//		Users struct {
+//			FindUser string `query:"FindUser"`
+//		}
+//		Orders struct {
+//			FindOrder string `query:"FindOrder"`
+//		} `namespace:"orders_v2"`
+//	}](fsys, sqload.WithDirNamespaces())
+//
+// To get the whole hierarchy dynamically, V can also be a map[string]map[string]string,
+// which gets the queries by namespace and name (the queries without namespace are under
+// the empty string), or a map[string]string, which gets the queries by full name:
+//
+//	namespaces, err := sqload.LoadFromFS[map[string]map[string]string](fsys, sqload.WithDirNamespaces())
+//	// (*namespaces)["users"]["FindUser"]
+//
+// When a dialect is set with WithDialect, or engines with WithEngines, files named after
+// an engine, like users.postgres.sql and users.mysql.sql, are only loaded when it
+// matches, replacing the file without the suffix, users.sql, if there is one. If none of
+// them matches and there is no file without the suffix, it will return an error. Without
+// them, all the files are loaded.
+//
+// A field can override the dialect to take its query from the files for another
+// engine, for structs that deliberately mix queries against two databases:
+//
+//	q, err := sqload.LoadFromFS[struct {
+//		FindUser       string `query:"FindUser"`
+//		FindLegacyUser string `query:"FindUser,dialect=mysql"`
+//	}](fsys, sqload.WithDialect(sqload.DialectPostgres))
+func LoadFromFS[V Struct](fsys fs.FS, opts ...Option) (*V, error) {
+	cfg := newConfig(opts)
+	queries, headers, err := extractQueriesFromFS(fsys, cfg)
 	if err != nil {
 		return nil, err
 	}
-	return LoadFromString[V](sql)
+	cfg.dialectQueries = dialectSource(fsys, cfg)
+	return loadFromQueryMap[V](queries, headers, cfg)
 }
 
 // MustLoadFromFS is like LoadFromFS but panics if any error occurs. It simplifies the
 // safe initialization of global variables holding struct pointers containing SQL
 // queries.
-func MustLoadFromFS[V Struct](fsys fs.FS) *V {
-	v, err := LoadFromFS[V](fsys)
+func MustLoadFromFS[V Struct](fsys fs.FS, opts ...Option) *V {
+	v, err := LoadFromFS[V](fsys, opts...)
 	if err != nil {
 		panic(err)
 	}
@@ -8,6 +8,7 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 var CatTestQueries map[string]string = map[string]string{
@@ -106,10 +107,42 @@ func TestExtractSql(t *testing.T) {
 			},
 			"\n\n\n",
 		},
+		{
+			[]string{
+				"SELECT first_name -- the name of the user",
+				"  -- a comment line",
+				"  FROM user;",
+			},
+			"SELECT first_name -- the name of the user\n  FROM user;",
+		},
+		{
+			[]string{
+				"CREATE FUNCTION inc(i integer) RETURNS integer AS $$",
+				"BEGIN",
+				"    -- Increments i by one.",
+				"    RETURN i + 1;",
+				"END;",
+				"$$ LANGUAGE plpgsql;",
+			},
+			"CREATE FUNCTION inc(i integer) RETURNS integer AS $$\nBEGIN\n    -- Increments i by one.\n    RETURN i + 1;\nEND;\n$$ LANGUAGE plpgsql;",
+		},
+		{
+			[]string{
+				"-- Logs a message.",
+				"DO $do$",
+				"BEGIN",
+				"-- not a comment for sqload",
+				"RAISE NOTICE 'multi",
+				"-- line string';",
+				"END",
+				"$do$;",
+			},
+			"DO $do$\nBEGIN\n-- not a comment for sqload\nRAISE NOTICE 'multi\n-- line string';\nEND\n$do$;",
+		},
 	}
 	for i, testCase := range testCases {
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
-			sql := extractSql(testCase.lines)
+			sql := extractSql(strings.Join(testCase.lines, "\n"), DialectGeneric)
 			if sql != testCase.wantedSql {
 				t.Errorf("got %s, want %s", sql, testCase.wantedSql)
 				return
@@ -118,6 +151,24 @@ func TestExtractSql(t *testing.T) {
 	}
 }
 
+func TestExtractSqlDialect(t *testing.T) {
+	// In MySQL a backslash escapes the quote, so the string does not end at 'it\'
+	sql := "SELECT 'it\\'s;' AS a,\n-- The answer.\n42 AS b;"
+	want := "SELECT 'it\\'s;' AS a,\n42 AS b;"
+	if got := extractSql(sql, DialectMySQL); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+	q, err := LoadFromString[struct {
+		Find string `query:"Find"`
+	}]("-- query: Find\n"+sql, WithDialect(DialectMySQL))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.Find != want {
+		t.Errorf("got %s, want %s", q.Find, want)
+	}
+}
+
 func TestExtractQueryMap(t *testing.T) {
 	type Want struct {
 		queries map[string]string
@@ -200,6 +251,40 @@ func TestExtractQueryMap(t *testing.T) {
 				nil,
 			},
 		},
+		{
+			"-- query: DeleteUser, RemoveUser\nDELETE FROM user WHERE id = :id;",
+			Want{
+				map[string]string{
+					"DeleteUser": "DELETE FROM user WHERE id = :id;",
+					"RemoveUser": "DELETE FROM user WHERE id = :id;",
+				},
+				nil,
+			},
+		},
+		{
+			"-- query: DeleteUser,RemoveUser variant=soft\nUPDATE user SET deleted = TRUE;\n-- query: DeleteUser,RemoveUser\nDELETE FROM user;",
+			Want{
+				map[string]string{
+					"DeleteUser": "DELETE FROM user;",
+					"RemoveUser": "DELETE FROM user;",
+				},
+				nil,
+			},
+		},
+		{
+			"-- query: DeleteUser,\nSELECT 1;",
+			Want{
+				map[string]string{},
+				fmt.Errorf("%w: invalid query name DeleteUser,", ErrCannotLoadQueries),
+			},
+		},
+		{
+			"-- query: Delete User\nSELECT 1;",
+			Want{
+				map[string]string{},
+				fmt.Errorf("%w: invalid query name Delete User", ErrCannotLoadQueries),
+			},
+		},
 	}
 	for i, testCase := range testCases {
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
@@ -343,7 +428,7 @@ func TestLoadQueriesIntoStruct(t *testing.T) {
 	}
 	for i, testCase := range testCases {
 		t.Run(fmt.Sprintf("%d (v=%v)", i, testCase.v), func(t *testing.T) {
-			err := loadQueriesIntoStruct(map[string]string{}, testCase.v)
+			err := loadQueriesIntoStruct(map[string]string{}, testCase.v, &config{})
 			if fmt.Sprint(err) != fmt.Sprint(testCase.err) {
 				t.Errorf("got %s, want %s", err, testCase.err)
 				return
@@ -355,7 +440,7 @@ func TestLoadQueriesIntoStruct(t *testing.T) {
 		CreateCatTable int `query:"CreateCatTable"`
 	}
 	invalidCatQuery := InvalidCatQuery{}
-	err := loadQueriesIntoStruct(CatTestQueries, &invalidCatQuery)
+	err := loadQueriesIntoStruct(CatTestQueries, &invalidCatQuery, &config{})
 	wantedErr := fmt.Errorf("%w: field %s cannot be changed or is not a string", ErrCannotLoadQueries, "CreateCatTable")
 	if fmt.Sprint(err) != fmt.Sprint(wantedErr) {
 		t.Errorf("got %s, want %s", err, wantedErr)
@@ -365,7 +450,7 @@ func TestLoadQueriesIntoStruct(t *testing.T) {
 		DeleteCatById int `query:"DeleteCatById"`
 	}
 	missingCatQueries := MissingCatQueries{}
-	err = loadQueriesIntoStruct(CatTestQueries, &missingCatQueries)
+	err = loadQueriesIntoStruct(CatTestQueries, &missingCatQueries, &config{})
 	wantedErr = fmt.Errorf("%w: could not find query %s", ErrCannotLoadQueries, "DeleteCatById")
 	if fmt.Sprint(err) != fmt.Sprint(wantedErr) {
 		t.Errorf("got %s, want %s", err, wantedErr)
@@ -378,7 +463,7 @@ func TestLoadQueriesIntoStruct(t *testing.T) {
 		UpdateColorById string `query:"UpdateColorById"`
 	}
 	catQuery := CatQuery{}
-	err = loadQueriesIntoStruct(CatTestQueries, &catQuery)
+	err = loadQueriesIntoStruct(CatTestQueries, &catQuery, &config{})
 	if err != nil {
 		t.Fatalf("err must be nil, got %s", err)
 	}
@@ -396,21 +481,23 @@ func TestLoadQueriesIntoStruct(t *testing.T) {
 	}
 }
 
-func TestCat(t *testing.T) {
+func TestReadFile(t *testing.T) {
 	fsys := os.DirFS("testdata/test-cat")
-	txt, err := cat(fsys, []string{"file1.txt", "file2.txt"})
-	if err != nil {
-		t.Fatalf("err must be nil, got %s", err)
+	wantedTxts := map[string]string{
+		"file1.txt": "Some text around here...\n",
+		"file2.txt": "Even more text around there...\n",
 	}
-	wantedTxt := `Some text around here...
-
-Even more text around there...
-`
-	if txt != wantedTxt {
-		t.Fatalf("got %s, want %s", txt, wantedTxt)
+	for filename, wantedTxt := range wantedTxts {
+		txt, err := readFile(fsys, filename, &config{})
+		if err != nil {
+			t.Fatalf("err must be nil, got %s", err)
+		}
+		if txt != wantedTxt {
+			t.Fatalf("got %q, want %q", txt, wantedTxt)
+		}
 	}
 	fsys = os.DirFS("testdata/i-dont-exist")
-	_, err = cat(fsys, []string{"i-dont-exist.sql"})
+	_, err := readFile(fsys, "i-dont-exist.sql", &config{})
 	if err == nil {
 		t.Fatalf("err must not be nil")
 	}
@@ -473,68 +560,7 @@ func TestMustLoadFromString(t *testing.T) {
 	MustLoadFromString[struct{}](sql)
 }
 
-func TestFromFile(t *testing.T) {
-	type CatQuery struct {
-		CreateCatTable  string `query:"CreateCatTable"`
-		CreatePsychoCat string `query:"CreatePsychoCat"`
-		CreateNormalCat string `query:"CreateNormalCat"`
-		UpdateColorById string `query:"UpdateColorById"`
-	}
-	_, err := LoadFromFile[CatQuery]("testdata/i-dont-exist.sql")
-	if err == nil {
-		t.Fatalf("file testdata/i-dont-exist.sql must not exists so this test can fail")
-	}
-	// test using LF line endings
-	catQuery, err := LoadFromFile[CatQuery]("testdata/cat-queries.sql")
-	if err != nil {
-		t.Fatalf("error loading testdata/cat-queries.sql: %s", err)
-	}
-	if catQuery.CreateCatTable != CatTestQueries["CreateCatTable"] {
-		t.Errorf("got %s, want %s", catQuery.CreateCatTable, CatTestQueries["CreateCatTable"])
-	}
-	if catQuery.CreatePsychoCat != CatTestQueries["CreatePsychoCat"] {
-		t.Errorf("got %s, want %s", catQuery.CreatePsychoCat, CatTestQueries["CreatePsychoCat"])
-	}
-	if catQuery.CreateNormalCat != CatTestQueries["CreateNormalCat"] {
-		t.Errorf("got %s, want %s", catQuery.CreateNormalCat, CatTestQueries["CreateNormalCat"])
-	}
-	if catQuery.UpdateColorById != CatTestQueries["UpdateColorById"] {
-		t.Errorf("got %s, want %s", catQuery.UpdateColorById, CatTestQueries["UpdateColorById"])
-	}
-	// test using CRLF line endings
-	catQuery, err = LoadFromFile[CatQuery]("testdata/cat-queries.crlf.sql")
-	if err != nil {
-		t.Fatalf("error loading testdata/cat-queries.sql: %s", err)
-	}
-	if catQuery.CreateCatTable != CatTestQueries["CreateCatTable"] {
-		t.Errorf("got %s, want %s", catQuery.CreateCatTable, CatTestQueries["CreateCatTable"])
-	}
-	if catQuery.CreatePsychoCat != CatTestQueries["CreatePsychoCat"] {
-		t.Errorf("got %s, want %s", catQuery.CreatePsychoCat, CatTestQueries["CreatePsychoCat"])
-	}
-	if catQuery.CreateNormalCat != CatTestQueries["CreateNormalCat"] {
-		t.Errorf("got %s, want %s", catQuery.CreateNormalCat, CatTestQueries["CreateNormalCat"])
-	}
-	if catQuery.UpdateColorById != CatTestQueries["UpdateColorById"] {
-		t.Errorf("got %s, want %s", catQuery.UpdateColorById, CatTestQueries["UpdateColorById"])
-	}
-}
-
-func TestMustLoadFromFile(t *testing.T) {
-	// Test that the function panics if any error occurs
-	func() {
-		defer func() {
-			if r := recover(); r == nil {
-				t.Error("function did not panic")
-			}
-		}()
-		MustLoadFromFile[struct{}]("testdata/i-dont-exist.sql")
-	}()
-	// Test that the function does not panic if no errors occur
-	MustLoadFromFile[struct{}]("testdata/cat-queries.sql")
-}
-
-func TestLoadFromDir(t *testing.T) {
+func TestLoadFromFS(t *testing.T) {
 	type RandomQuery struct {
 		CreateCatTable      string `query:"CreateCatTable"`
 		CreatePsychoCat     string `query:"CreatePsychoCat"`
@@ -546,15 +572,15 @@ func TestLoadFromDir(t *testing.T) {
 		FindRiders          string `query:"FindRiders"`
 	}
 	// Test that the function fails when the directory does not exist
-	_, err := LoadFromDir[RandomQuery]("testdata/i-dont-exist")
+	fsys := os.DirFS("testdata/i-dont-exist")
+	_, err := LoadFromFS[RandomQuery](fsys)
 	if err == nil {
 		t.Fatalf("dir testdata/i-dont-exist must not exists so this test can fail")
 	}
-
 	// Permission-based tests do not work on Windows
 	if runtime.GOOS != "windows" {
 		// Test that the function fails when it can not read some .sql file
-		unreadableFilename := "testdata/test-load-from-dir/unreadable-file.sql"
+		unreadableFilename := "testdata/test-load-from-fs/unreadable-file.sql"
 		unreadableFile, err := os.Create(unreadableFilename)
 		if err != nil {
 			t.Fatalf("unable to create %s: %s", unreadableFilename, err)
@@ -564,7 +590,8 @@ func TestLoadFromDir(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unable to set the permissions of %s to 0222: %s", unreadableFilename, err)
 		}
-		_, err = LoadFromDir[RandomQuery]("testdata/test-load-from-dir")
+		fsys = os.DirFS("testdata/test-load-from-fs")
+		_, err = LoadFromFS[RandomQuery](fsys)
 		if err == nil {
 			t.Fatal("error is nil")
 		}
@@ -574,9 +601,10 @@ func TestLoadFromDir(t *testing.T) {
 		}
 	}
 	// Test that the function succeeds when using the happy path
-	queries, err := LoadFromDir[RandomQuery]("testdata/test-load-from-dir")
+	fsys = os.DirFS("testdata/test-load-from-fs")
+	queries, err := LoadFromFS[RandomQuery](fsys)
 	if err != nil {
-		t.Fatalf("error loading testdata/test-load-from-dir: %s", err)
+		t.Fatalf("error loading testdata/test-load-from-fs: %s", err)
 	}
 	if queries.CreateCatTable != CatTestQueries["CreateCatTable"] {
 		t.Errorf("got %s, want %s", queries.CreateCatTable, CatTestQueries["CreateCatTable"])
@@ -604,7 +632,7 @@ func TestLoadFromDir(t *testing.T) {
 	}
 }
 
-func TestMustLoadFromDir(t *testing.T) {
+func TestMustLoadFromFS(t *testing.T) {
 	// Test that the function panics if any error occurs
 	func() {
 		defer func() {
@@ -612,85 +640,573 @@ func TestMustLoadFromDir(t *testing.T) {
 				t.Error("function did not panic")
 			}
 		}()
-		MustLoadFromDir[struct{}]("testdata/i-dont-exist")
+		fsys := os.DirFS("testdata/i-dont-exist")
+		MustLoadFromFS[struct{}](fsys)
 	}()
 	// Test that the function does not panic if no errors occur
-	MustLoadFromDir[struct{}]("testdata/test-load-from-dir")
+	fsys := os.DirFS("testdata/test-load-from-fs")
+	MustLoadFromFS[struct{}](fsys)
 }
 
-func TestLoadFromFS(t *testing.T) {
-	type RandomQuery struct {
-		CreateCatTable      string `query:"CreateCatTable"`
-		CreatePsychoCat     string `query:"CreatePsychoCat"`
-		CreateNormalCat     string `query:"CreateNormalCat"`
-		UpdateColorById     string `query:"UpdateColorById"`
-		FindUserById        string `query:"FindUserById"`
-		UpdateFirstNameById string `query:"UpdateFirstNameById"`
-		DeleteUserById      string `query:"DeleteUserById"`
-		FindRiders          string `query:"FindRiders"`
+func TestLoadHintComments(t *testing.T) {
+	type HintQuery struct {
+		FindUser  string `query:"FindUser"`
+		CountCats string `query:"CountCats"`
+	}
+	sql := strings.TrimSpace(`
+-- query: FindUser
+-- Finds a user using the email index.
+/*+ INDEX(u user_email_idx) */
+SELECT u.id -- the id of the user
+  FROM user u
+ WHERE u.email = :email;
+
+-- query: CountCats
+SELECT /*+ MAX_EXECUTION_TIME(1000) */ COUNT(*)
+/* count every cat,
+-- even the orange ones */
+  FROM cat;
+`)
+	testCases := []struct {
+		opts          []Option
+		wantFindUser  string
+		wantCountCats string
+	}{
+		{
+			nil,
+			"/*+ INDEX(u user_email_idx) */\nSELECT u.id -- the id of the user\n  FROM user u\n WHERE u.email = :email;",
+			"SELECT /*+ MAX_EXECUTION_TIME(1000) */ COUNT(*)\n/* count every cat,\n-- even the orange ones */\n  FROM cat;",
+		},
+		{
+			[]Option{WithKeepComments()},
+			"-- Finds a user using the email index.\n/*+ INDEX(u user_email_idx) */\nSELECT u.id -- the id of the user\n  FROM user u\n WHERE u.email = :email;",
+			"SELECT /*+ MAX_EXECUTION_TIME(1000) */ COUNT(*)\n/* count every cat,\n-- even the orange ones */\n  FROM cat;",
+		},
 	}
-	// Test that the function fails when the directory does not exist
-	fsys := os.DirFS("testdata/i-dont-exist")
-	_, err := LoadFromFS[RandomQuery](fsys)
-	if err == nil {
-		t.Fatalf("dir testdata/i-dont-exist must not exists so this test can fail")
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			q, err := LoadFromString[HintQuery](sql, testCase.opts...)
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if q.FindUser != testCase.wantFindUser {
+				t.Errorf("got %s, want %s", q.FindUser, testCase.wantFindUser)
+			}
+			if q.CountCats != testCase.wantCountCats {
+				t.Errorf("got %s, want %s", q.CountCats, testCase.wantCountCats)
+			}
+		})
 	}
-	// Permission-based tests do not work on Windows
-	if runtime.GOOS != "windows" {
-		// Test that the function fails when it can not read some .sql file
-		unreadableFilename := "testdata/test-load-from-fs/unreadable-file.sql"
-		unreadableFile, err := os.Create(unreadableFilename)
-		if err != nil {
-			t.Fatalf("unable to create %s: %s", unreadableFilename, err)
+}
+
+func TestLoadStatementsIntoSlices(t *testing.T) {
+	type Batches []string
+	sql := strings.TrimSpace(`
+-- query: CreateCats
+CREATE TABLE cat (id INT, name VARCHAR(50));
+GO
+CREATE PROCEDURE add_cat @name VARCHAR(50) AS
+BEGIN
+    INSERT INTO cat (name) VALUES (@name);
+END
+GO
+
+-- query: SeedCats
+INSERT INTO cat (name) VALUES ('Puca; the psycho cat');
+INSERT INTO cat (name) VALUES ('Topo');
+`)
+	q, err := LoadFromString[struct {
+		CreateCats Batches  `query:"CreateCats"`
+		SeedCats   []string `query:"SeedCats"`
+	}](sql, WithDialect(DialectTSQL))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedCreateCats := Batches{
+		"CREATE TABLE cat (id INT, name VARCHAR(50));",
+		"CREATE PROCEDURE add_cat @name VARCHAR(50) AS\nBEGIN\n    INSERT INTO cat (name) VALUES (@name);\nEND",
+	}
+	if fmt.Sprintf("%q", q.CreateCats) != fmt.Sprintf("%q", wantedCreateCats) {
+		t.Errorf("got %q, want %q", q.CreateCats, wantedCreateCats)
+	}
+	wantedSeedCats := []string{"INSERT INTO cat (name) VALUES ('Puca; the psycho cat');\nINSERT INTO cat (name) VALUES ('Topo');"}
+	if fmt.Sprintf("%q", q.SeedCats) != fmt.Sprintf("%q", wantedSeedCats) {
+		t.Errorf("got %q, want %q", q.SeedCats, wantedSeedCats)
+	}
+	// Without the T-SQL dialect the statements are split by semicolons
+	p, err := LoadFromString[struct {
+		SeedCats []string `query:"SeedCats"`
+	}](sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedSeedCats = []string{"INSERT INTO cat (name) VALUES ('Puca; the psycho cat');", "INSERT INTO cat (name) VALUES ('Topo');"}
+	if fmt.Sprintf("%q", p.SeedCats) != fmt.Sprintf("%q", wantedSeedCats) {
+		t.Errorf("got %q, want %q", p.SeedCats, wantedSeedCats)
+	}
+}
+
+func TestLoadNestedStructs(t *testing.T) {
+	q, err := LoadFromFS[struct {
+		Ping  string `query:"Ping"`
+		Users struct {
+			FindUser string `query:"FindUser"`
 		}
-		defer unreadableFile.Close()
-		err = os.Chmod(unreadableFilename, 0222)
-		if err != nil {
-			t.Fatalf("unable to set the permissions of %s to 0222: %s", unreadableFilename, err)
+		Orders struct {
+			FindOrder string `query:"FindOrder"`
+		} `namespace:"orders_v2"`
+	}](os.DirFS("testdata/namespaces"), WithDirNamespaces())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.Ping != "SELECT 1;" {
+		t.Errorf("got %s, want %s", q.Ping, "SELECT 1;")
+	}
+	if q.Users.FindUser != "SELECT * FROM user WHERE id = :id;" {
+		t.Errorf("got %s, want %s", q.Users.FindUser, "SELECT * FROM user WHERE id = :id;")
+	}
+	if q.Orders.FindOrder != `SELECT * FROM "order" WHERE id = :id;` {
+		t.Errorf("got %s, want %s", q.Orders.FindOrder, `SELECT * FROM "order" WHERE id = :id;`)
+	}
+	_, err = LoadFromFS[struct {
+		Users struct {
+			FindUser string `query:"FindUser"`
 		}
-		fsys = os.DirFS("testdata/test-load-from-fs")
-		_, err = LoadFromFS[RandomQuery](fsys)
-		if err == nil {
-			t.Fatal("error is nil")
+	}](os.DirFS("testdata/namespaces"))
+	want := fmt.Errorf("%w: could not find query Users.FindUser", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+	nested, err := LoadFromString[struct {
+		Billing struct {
+			Invoices struct {
+				FindInvoice string `query:"FindInvoice"`
+			}
 		}
-		err = os.Remove(unreadableFilename)
-		if err != nil {
-			t.Fatalf("unable to remove %s: %s", unreadableFilename, err)
+		CreatedAt struct{ Seconds int }
+	}]("-- query: billing.invoices.FindInvoice\nSELECT * FROM invoice;")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if nested.Billing.Invoices.FindInvoice != "SELECT * FROM invoice;" {
+		t.Errorf("got %s, want %s", nested.Billing.Invoices.FindInvoice, "SELECT * FROM invoice;")
+	}
+}
+
+func TestLoadWithDirNamespaces(t *testing.T) {
+	qs, err := LoadQuerySet(os.DirFS("testdata/namespaces"), WithDirNamespaces())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedNames := []string{"Ping", "orders_v2.FindOrder", "users.FindUser"}
+	if fmt.Sprint(qs.Names()) != fmt.Sprint(wantedNames) {
+		t.Errorf("got %v, want %v", qs.Names(), wantedNames)
+	}
+	fsys := fstest.MapFS{"user-queries/find.sql": {Data: []byte("-- query: FindUser\nSELECT 1;")}}
+	_, err = LoadQuerySet(fsys, WithDirNamespaces())
+	want := fmt.Errorf("%w: directory user-queries cannot be used as a namespace", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+}
+
+func TestLoadWithAllFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"FindUser":              {Data: []byte("SELECT * FROM user WHERE id = :id;\n")},
+		"users/DeleteUser":      {Data: []byte("-- Deletes a user.\nDELETE FROM user WHERE id = :id;")},
+		"orders.sql":            {Data: []byte("-- query: FindOrder\nSELECT * FROM \"order\";")},
+		".gitkeep":              {Data: []byte("")},
+		".git/config":           {Data: []byte("[core]\n")},
+		"users/.DeleteUser.swp": {Data: []byte("binary")},
+	}
+	qs, err := LoadQuerySet(fsys, WithAllFiles(), WithDirNamespaces())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedNames := []string{"FindOrder", "FindUser", "users.DeleteUser"}
+	if fmt.Sprint(qs.Names()) != fmt.Sprint(wantedNames) {
+		t.Errorf("got %v, want %v", qs.Names(), wantedNames)
+	}
+	if sql := qs.queries["users.DeleteUser"].SQL; sql != "DELETE FROM user WHERE id = :id;" {
+		t.Errorf("got %s, want %s", sql, "DELETE FROM user WHERE id = :id;")
+	}
+	_, err = LoadQuerySet(fstest.MapFS{"find-user": {Data: []byte("SELECT 1;")}}, WithAllFiles())
+	want := fmt.Errorf("%w: invalid query name find-user (find-user:1)", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+}
+
+func TestLoadWithFileFilter(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql":          {Data: []byte("-- query: FindUser\nSELECT 1;")},
+		"users_draft.sql":    {Data: []byte("-- query: FindUser\nSELECT 2;")},
+		"drafts/orders.sql":  {Data: []byte("-- query: FindOrder\nSELECT 3;")},
+		"reports/sales.sql":  {Data: []byte("-- query: SalesReport\nSELECT 4;")},
+		"reports/README.txt": {Data: []byte("Reports.")},
+	}
+	visited := []string{}
+	qs, err := LoadQuerySet(fsys,
+		WithFileFilter(func(path string, d fs.DirEntry) bool {
+			visited = append(visited, path)
+			return !d.IsDir() || d.Name() != "drafts"
+		}),
+		WithFileFilter(func(path string, d fs.DirEntry) bool {
+			return !strings.HasSuffix(path, "_draft.sql")
+		}),
+	)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedNames := []string{"FindUser", "SalesReport"}
+	if fmt.Sprint(qs.Names()) != fmt.Sprint(wantedNames) {
+		t.Errorf("got %v, want %v", qs.Names(), wantedNames)
+	}
+	wantedVisited := []string{"drafts", "reports", "reports/sales.sql", "users.sql", "users_draft.sql"}
+	if fmt.Sprint(visited) != fmt.Sprint(wantedVisited) {
+		t.Errorf("got %v, want %v", visited, wantedVisited)
+	}
+}
+
+func TestLoadQueryDocs(t *testing.T) {
+	sql := `-- query: FindUser
+-- Finds a user by its id.
+SELECT * FROM user WHERE id = :id;
+
+-- query: DeleteUser
+DELETE FROM user WHERE id = :id;`
+	q, err := LoadFromString[struct {
+		FindUser      string `query:"FindUser"`
+		FindUserDoc   string `querydoc:"FindUser"`
+		DeleteUserDoc string `querydoc:"DeleteUser"`
+	}](sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.FindUser != "SELECT * FROM user WHERE id = :id;" {
+		t.Errorf("got %s, want %s", q.FindUser, "SELECT * FROM user WHERE id = :id;")
+	}
+	if q.FindUserDoc != "Finds a user by its id." {
+		t.Errorf("got %s, want %s", q.FindUserDoc, "Finds a user by its id.")
+	}
+	if q.DeleteUserDoc != "" {
+		t.Errorf("got %s, want an empty string", q.DeleteUserDoc)
+	}
+	_, err = LoadFromString[struct {
+		FindUserDoc int `querydoc:"FindUser"`
+	}](sql)
+	want := fmt.Errorf("%w: field FindUserDoc cannot be changed or is not a string", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+	_, err = LoadFromString[struct {
+		FindCatDoc string `querydoc:"FindCat"`
+	}](sql)
+	want = fmt.Errorf("%w: could not find query FindCat", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+}
+
+type testStmt struct {
+	Name, SQL string
+}
+
+func (s *testStmt) SetSQL(name, sql string) error {
+	if sql == "" {
+		return errors.New("empty query")
+	}
+	s.Name, s.SQL = name, sql
+	return nil
+}
+
+type testText struct {
+	text string
+}
+
+func (t *testText) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		return errors.New("empty text")
+	}
+	t.text = string(text)
+	return nil
+}
+
+// testBoth implements both SQLSetter and encoding.TextUnmarshaler.
+type testBoth struct {
+	testStmt
+	testText
+}
+
+func TestLoadSQLSetters(t *testing.T) {
+	sql := "-- query: FindUser\nSELECT * FROM user;\n-- query: Orders.FindOrder\nSELECT * FROM \"order\";\n-- query: Empty\n"
+	q, err := LoadFromString[struct {
+		FindUser    testStmt  `query:"FindUser"`
+		FindUserPtr *testStmt `query:"FindUser"`
+		Orders      struct {
+			FindOrder testStmt `query:"FindOrder"`
 		}
+	}](sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
 	}
-	// Test that the function succeeds when using the happy path
-	fsys = os.DirFS("testdata/test-load-from-fs")
-	queries, err := LoadFromFS[RandomQuery](fsys)
+	if want := (testStmt{"FindUser", "SELECT * FROM user;"}); q.FindUser != want || q.FindUserPtr == nil || *q.FindUserPtr != want {
+		t.Errorf("got %v and %v, want %v", q.FindUser, q.FindUserPtr, want)
+	}
+	if want := (testStmt{"Orders.FindOrder", `SELECT * FROM "order";`}); q.Orders.FindOrder != want {
+		t.Errorf("got %v, want %v", q.Orders.FindOrder, want)
+	}
+	_, err = LoadFromString[struct {
+		Empty testStmt `query:"Empty"`
+	}](sql)
+	want := fmt.Errorf("%w: field Empty: empty query", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+
+	texts, err := LoadFromString[struct {
+		FindUser    testText  `query:"FindUser"`
+		FindUserPtr *testText `query:"FindUser"`
+		Both        testBoth  `query:"FindUser"`
+	}](sql)
 	if err != nil {
-		t.Fatalf("error loading testdata/test-load-from-fs: %s", err)
+		t.Fatalf("err must be nil, got %s", err)
 	}
-	if queries.CreateCatTable != CatTestQueries["CreateCatTable"] {
-		t.Errorf("got %s, want %s", queries.CreateCatTable, CatTestQueries["CreateCatTable"])
+	if texts.FindUser.text != "SELECT * FROM user;" || texts.FindUserPtr == nil || texts.FindUserPtr.text != "SELECT * FROM user;" {
+		t.Errorf("got %v and %v, want SELECT * FROM user;", texts.FindUser, texts.FindUserPtr)
 	}
-	if queries.CreatePsychoCat != CatTestQueries["CreatePsychoCat"] {
-		t.Errorf("got %s, want %s", queries.CreatePsychoCat, CatTestQueries["CreatePsychoCat"])
+	if texts.Both.SQL != "SELECT * FROM user;" || texts.Both.text != "" {
+		t.Errorf("got %v, want SetSQL to be preferred over UnmarshalText", texts.Both)
 	}
-	if queries.CreateNormalCat != CatTestQueries["CreateNormalCat"] {
-		t.Errorf("got %s, want %s", queries.CreateNormalCat, CatTestQueries["CreateNormalCat"])
+	_, err = LoadFromString[struct {
+		Empty testText `query:"Empty"`
+	}](sql)
+	want = fmt.Errorf("%w: field Empty: empty text", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
 	}
-	if queries.UpdateColorById != CatTestQueries["UpdateColorById"] {
-		t.Errorf("got %s, want %s", queries.UpdateColorById, CatTestQueries["UpdateColorById"])
+}
+
+func TestLoadFromFSParsesEachFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.sql": {Data: []byte("-- query: FindCat\nSELECT * FROM cat;")},
+		"b.sql": {Data: []byte("SET search_path = pets;\n\n-- query: FindDog\nSELECT * FROM dog;")},
+	}
+	q, err := LoadFromFS[struct {
+		FindCat string `query:"FindCat"`
+	}](fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
 	}
-	if queries.FindUserById != UserTestQueries["FindUserById"] {
-		t.Errorf("got %s, want %s", queries.FindUserById, UserTestQueries["FindUserById"])
+	if q.FindCat != "SELECT * FROM cat;" {
+		t.Errorf("got %s, want %s", q.FindCat, "SELECT * FROM cat;")
 	}
-	if queries.UpdateFirstNameById != UserTestQueries["UpdateFirstNameById"] {
-		t.Errorf("got %s, want %s", queries.UpdateFirstNameById, UserTestQueries["UpdateFirstNameById"])
+}
+
+func TestLoadIntoMaps(t *testing.T) {
+	namespaces, err := LoadFromFS[map[string]map[string]string](os.DirFS("testdata/namespaces"), WithDirNamespaces())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
 	}
-	if queries.DeleteUserById != UserTestQueries["DeleteUserById"] {
-		t.Errorf("got %s, want %s", queries.DeleteUserById, UserTestQueries["DeleteUserById"])
+	want := map[string]map[string]string{
+		"":          {"Ping": "SELECT 1;"},
+		"orders_v2": {"FindOrder": `SELECT * FROM "order" WHERE id = :id;`},
+		"users":     {"FindUser": "SELECT * FROM user WHERE id = :id;"},
 	}
-	if queries.FindRiders != RiderTestQueries["FindRiders"] {
-		t.Errorf("got %s, want %s", queries.FindRiders, RiderTestQueries["FindRiders"])
+	if fmt.Sprint(*namespaces) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", *namespaces, want)
+	}
+	nested, err := LoadFromString[map[string]map[string]string]("-- query: billing.v2.FindInvoice\nSELECT 1;\n-- query: billing.v2.FindInvoice variant=fast\nSELECT 2;")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	// The maps only get the default versions of the queries
+	wantNested := map[string]map[string]string{"billing.v2": {"FindInvoice": "SELECT 1;"}}
+	if fmt.Sprint(*nested) != fmt.Sprint(wantNested) {
+		t.Errorf("got %v, want %v", *nested, wantNested)
+	}
+	variants, err := LoadFromString[map[string]string]("-- query: FindInvoice\nSELECT 1;\n-- query: FindInvoice variant=fast\nSELECT 2;")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := "map[FindInvoice:SELECT 1;]"; fmt.Sprint(*variants) != want {
+		t.Errorf("got %v, want %s", *variants, want)
+	}
+	flat, err := LoadFromFS[map[string]string](os.DirFS("testdata/namespaces"), WithDirNamespaces(), WithPrefix("users."))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantFlat := map[string]string{"FindUser": "SELECT * FROM user WHERE id = :id;"}
+	if fmt.Sprint(*flat) != fmt.Sprint(wantFlat) {
+		t.Errorf("got %v, want %v", *flat, wantFlat)
+	}
+	_, err = LoadFromString[map[string]int]("-- query: Ping\nSELECT 1;")
+	wantErr := fmt.Errorf("%w: v is not a pointer to a struct", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %s, want %s", err, wantErr)
 	}
 }
 
-func TestMustLoadFromFS(t *testing.T) {
+func TestLoadFromQueryMap(t *testing.T) {
+	queries := map[string]string{
+		"FindCat":      "SELECT * FROM cat WHERE id = :id;",
+		"FindCat:fast": "SELECT * FROM cat_cache WHERE id = :id;",
+		"dogs.FindDog": "SELECT * FROM dog;",
+	}
+	q, err := LoadFromQueryMap[struct {
+		FindCat     string `query:"FindCat"`
+		FindCatFast string `query:"FindCat:fast"`
+		Dogs        struct {
+			FindDog string `query:"FindDog"`
+		}
+	}](queries, WithTransform(func(name, sql string) (string, error) {
+		return strings.TrimSuffix(sql, ";"), nil
+	}))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.FindCat != "SELECT * FROM cat WHERE id = :id" || q.FindCatFast != "SELECT * FROM cat_cache WHERE id = :id" {
+		t.Errorf("got %+v, want FindCat and its fast variant", q)
+	}
+	if q.Dogs.FindDog != "SELECT * FROM dog" {
+		t.Errorf("got %s, want %s", q.Dogs.FindDog, "SELECT * FROM dog")
+	}
+	if queries["FindCat"] != "SELECT * FROM cat WHERE id = :id;" {
+		t.Error("the map must not be modified")
+	}
+	testCases := []struct {
+		queries map[string]string
+		want    error
+	}{
+		{map[string]string{"find-cat": "SELECT 1;"}, fmt.Errorf("%w: invalid query name find-cat", ErrCannotLoadQueries)},
+		{map[string]string{"FindCat:": "SELECT 1;"}, fmt.Errorf("%w: invalid query name FindCat:", ErrCannotLoadQueries)},
+		{map[string]string{"FindCat:fast": "SELECT 1;"}, fmt.Errorf("%w: query FindCat has variants but no default version", ErrCannotLoadQueries)},
+		{map[string]string{"FindDog": "SELECT 1;"}, fmt.Errorf("%w: could not find query FindCat", ErrCannotLoadQueries)},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := LoadFromQueryMap[struct {
+				FindCat string `query:"FindCat"`
+			}](testCase.queries)
+			if fmt.Sprint(err) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %s", err, testCase.want)
+			}
+		})
+	}
+}
+
+func TestParseFixtureBlocks(t *testing.T) {
+	sql := `-- fixture: SeedCats
+INSERT INTO cat (id, name) VALUES (1, 'Tom');
+
+-- query: FindCat
+SELECT * FROM cat WHERE id = :id;
+
+-- fixture: SeedMoreCats
+INSERT INTO cat (id, name) VALUES (2, 'Felix');
+-- query: CountCats
+SELECT count(*) FROM cat;
+`
+	queries, err := ExtractQueryMap(sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "map[CountCats:SELECT count(*) FROM cat; FindCat:SELECT * FROM cat WHERE id = :id;]"
+	if fmt.Sprint(queries) != want {
+		t.Errorf("got %v, want %s", queries, want)
+	}
+}
+
+func TestBlockComments(t *testing.T) {
+	testCases := []struct {
+		sql  string
+		d    Dialect
+		want map[string]string
+	}{
+		{
+			sql:  "-- query: Label\nSELECT '-- fixture: a', 2;",
+			want: map[string]string{"Label": "SELECT '-- fixture: a', 2;"},
+		},
+		{
+			sql:  "-- query: Label\nSELECT 'multi\n-- fixture: line', 2;\n\n-- fixture: Seed\nINSERT INTO cat (id) VALUES (1);",
+			want: map[string]string{"Label": "SELECT 'multi\n-- fixture: line', 2;"},
+		},
+		{
+			sql:  "-- query: Inc\nCREATE FUNCTION inc(i integer) RETURNS integer AS $$\n-- fixture: body\nSELECT i + 1;\n$$ LANGUAGE sql;",
+			d:    DialectPostgres,
+			want: map[string]string{"Inc": "CREATE FUNCTION inc(i integer) RETURNS integer AS $$\n-- fixture: body\nSELECT i + 1;\n$$ LANGUAGE sql;"},
+		},
+		{
+			sql:  "-- query: Ping\nSELECT 1 /*\n-- fixture: commented out\n*/, 2; -- fixture: not at the start of a line",
+			want: map[string]string{"Ping": "SELECT 1 /*\n-- fixture: commented out\n*/, 2; -- fixture: not at the start of a line"},
+		},
+		{
+			sql:  "-- query: Ping\nSELECT 1;\n  -- fixture: Seed\nINSERT INTO cat (id) VALUES (1);",
+			want: map[string]string{"Ping": "SELECT 1;"},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			queries, err := extractQueryMap(tc.sql, newConfig([]Option{WithDialect(tc.d)}))
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if fmt.Sprintf("%q", queries) != fmt.Sprintf("%q", tc.want) {
+				t.Errorf("got %q, want %q", queries, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadSortedErrors(t *testing.T) {
+	type Queries struct {
+		FindUser     string `query:"FindUser"`
+		FindDog      string `query:"FindDog"`
+		FindDogDoc   string `querydoc:"FindDog"`
+		FindCatLimit int    `queryattr:"FindCat.limit"`
+		Orders       struct {
+			PlaceOrder string `query:"PlaceOrder"`
+		}
+	}
+	testCases := []struct {
+		queries map[string]string
+		want    error
+	}{
+		{
+			map[string]string{"Ping": "SELECT 1;"},
+			fmt.Errorf("%w: could not find queries FindCat, FindDog, FindUser, Orders.PlaceOrder", ErrCannotLoadQueries),
+		},
+		{
+			map[string]string{"FindUser": "SELECT 1;", "FindDog": "SELECT 1;", "FindCat": "SELECT 1;", "Orders.PlaceOrder": "SELECT 1;"},
+			nil,
+		},
+		{
+			map[string]string{"FindUser": "SELECT 1;", "FindDog": "SELECT 1;", "FindCat": "SELECT 1;"},
+			fmt.Errorf("%w: could not find query Orders.PlaceOrder", ErrCannotLoadQueries),
+		},
+		{
+			map[string]string{"Ping": "SELECT 1;", "Find:b": "SELECT 1;", "Find:a": "SELECT 1;", "Delete:a": "SELECT 1;", "Count:a": "SELECT 1;"},
+			fmt.Errorf("%w: query Count has variants but no default version", ErrCannotLoadQueries),
+		},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			// Map iteration order changes between runs, the error must not
+			for j := 0; j < 20; j++ {
+				_, err := LoadFromQueryMap[Queries](testCase.queries)
+				if fmt.Sprint(err) != fmt.Sprint(testCase.want) {
+					t.Fatalf("got %v, want %v", err, testCase.want)
+				}
+			}
+		})
+	}
+	_, err := LoadFromQueryMap[Queries](map[string]string{})
+	if !errors.Is(err, ErrCannotLoadQueries) {
+		t.Errorf("got %v, want %s", err, ErrCannotLoadQueries)
+	}
+}
+
+func TestMustLoadFromQueryMap(t *testing.T) {
 	// Test that the function panics if any error occurs
 	func() {
 		defer func() {
@@ -698,10 +1214,45 @@ func TestMustLoadFromFS(t *testing.T) {
 				t.Error("function did not panic")
 			}
 		}()
-		fsys := os.DirFS("testdata/i-dont-exist")
-		MustLoadFromFS[struct{}](fsys)
+		MustLoadFromQueryMap[struct{}](map[string]string{"invalid-name": ""})
 	}()
 	// Test that the function does not panic if no errors occur
-	fsys := os.DirFS("testdata/test-load-from-fs")
-	MustLoadFromFS[struct{}](fsys)
+	MustLoadFromQueryMap[struct{}](map[string]string{})
+}
+
+func TestExtractQueryMapFromFS(t *testing.T) {
+	queries, err := ExtractQueryMapFromFS(os.DirFS("testdata/namespaces"), WithDirNamespaces())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := map[string]string{
+		"Ping":                "SELECT 1;",
+		"orders_v2.FindOrder": `SELECT * FROM "order" WHERE id = :id;`,
+		"users.FindUser":      "SELECT * FROM user WHERE id = :id;",
+	}
+	if fmt.Sprint(queries) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", queries, want)
+	}
+	fsys := fstest.MapFS{
+		"0001_cats.sql":  {Data: []byte("-- query: AddCats\nCREATE TABLE cat (id INT);")},
+		"0002_users.sql": {Data: []byte("-- A migration\n-- query: add-users\nCREATE TABLE user (id INT);")},
+	}
+	_, err = ExtractQueryMapFromFS(fsys)
+	wantErr := fmt.Errorf("%w: invalid query name add-users (0002_users.sql:2)", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %v, want %s", err, wantErr)
+	}
+}
+
+func BenchmarkParseQueries(b *testing.B) {
+	var sql strings.Builder
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sql, "-- query: FindUser%d\n-- Finds a user.\n-- tags: users\nSELECT *\n  FROM user\n WHERE id = :id;\n\n", i)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := parseQueries(sql.String(), "users.sql", &config{}); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
@@ -0,0 +1,183 @@
+package sqloadtest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDriver is a database/sql driver with a single table, user, whose rows are users.
+// It understands the few statements used by the tests and logs every statement it runs.
+type fakeDriver struct {
+	mu     sync.Mutex
+	users  [][]driver.Value // id, name
+	tables []string         // tables created, other than user
+	log    []string
+}
+
+// fakeValuesPattern matches the rows of the INSERT statements, like (1, 'Tom').
+var fakeValuesPattern = regexp.MustCompile(`\((\d+), '([^']*)'\)`)
+
+// fakeTablePattern matches the tables used by a statement.
+var fakeTablePattern = regexp.MustCompile(`(?:FROM|INTO|JOIN) (\w+)`)
+
+var fakeDrivers = struct {
+	sync.Mutex
+	n int
+}{}
+
+// openFakeDB registers a new fakeDriver with the users users and returns it with a
+// *sql.DB using it.
+func openFakeDB(t *testing.T, users ...[]driver.Value) (*fakeDriver, *sql.DB) {
+	fakeDrivers.Lock()
+	fakeDrivers.n++
+	name := fmt.Sprintf("sqloadtestfake%d", fakeDrivers.n)
+	fakeDrivers.Unlock()
+	d := &fakeDriver{users: users}
+	sql.Register(name, d)
+	db, err := sql.Open(name, "")
+	if err != nil {
+		t.Fatalf("unable to open the fake database: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return d, db
+}
+
+// Log returns the statements run so far.
+func (d *fakeDriver) Log() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string{}, d.log...)
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{driver: d}, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+// Prepare checks that the tables used by query exist, but the returned statements can
+// not be run.
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	d := c.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, "PREPARE "+query)
+	for _, m := range fakeTablePattern.FindAllStringSubmatch(query, -1) {
+		if m[1] != "user" && !containsString(d.tables, m[1]) {
+			return nil, fmt.Errorf("no such table: %s", m[1])
+		}
+	}
+	return &fakeStmt{}, nil
+}
+
+// containsString reports whether s is one of the strings in list.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+type fakeStmt struct{}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("prepared statements can not be run")
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return nil, fmt.Errorf("prepared statements can not be run")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	d := c.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, query)
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO user"):
+		values := fakeValuesPattern.FindAllStringSubmatch(query, -1)
+		for _, v := range values {
+			id, _ := strconv.ParseInt(v[1], 10, 64)
+			d.users = append(d.users, []driver.Value{id, v[2]})
+		}
+		return driver.RowsAffected(len(values)), nil
+	case strings.HasPrefix(query, "CREATE TABLE "):
+		d.tables = append(d.tables, strings.Fields(query)[2])
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, "DELETE FROM user"):
+		n := len(d.users)
+		d.users = nil
+		return driver.RowsAffected(n), nil
+	}
+	return nil, fmt.Errorf("unknown statement %s", query)
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	d := c.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, query)
+	rows := &fakeRows{}
+	switch {
+	case strings.HasPrefix(query, "SELECT id, name FROM user WHERE id = ?"):
+		for _, user := range d.users {
+			if user[0] == args[0].Value {
+				rows.values = append(rows.values, user)
+			}
+		}
+	case strings.HasPrefix(query, "SELECT id, name FROM user"):
+		rows.values = append(rows.values, d.users...)
+	default:
+		return nil, fmt.Errorf("unknown statement %s", query)
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "name"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
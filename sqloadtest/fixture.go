@@ -0,0 +1,110 @@
+package sqloadtest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/midir99/sqload"
+)
+
+// fixtureComment is the prefix of the comments that start the fixture blocks.
+const fixtureComment = "-- fixture:"
+
+// queryComment is the prefix of the query comments, which end the fixture blocks.
+const queryComment = "-- query:"
+
+// validFixtureNamePattern matches the valid fixture names, which follow the rules of the
+// query names.
+var validFixtureNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_]+(\.[a-zA-Z0-9_]+)*$`)
+
+// LoadFixtures returns the fixture blocks of the .sql files of the fsys file system, by
+// name. A fixture block is the test data of the queries, written next to them in a block
+// that starts with a fixture comment and lasts until the next fixture, query or
+// transaction comment. The Load functions of the sqload package skip them:
+//
+//	-- fixture: SeedCats
+//	INSERT INTO cat (id, name) VALUES (1, 'Tom');
+//	INSERT INTO cat (id, name) VALUES (2, 'Felix');
+//
+//	-- query: FindCat
+//	SELECT id, name FROM cat WHERE id = :id;
+//
+// If a fixture name is invalid or is used twice, it will return an error.
+func LoadFixtures(fsys fs.FS) (map[string]string, error) {
+	fixtures := map[string]string{}
+	defined := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.ToLower(path.Ext(p)) != ".sql" {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		return parseFixtures(string(data), p, fixtures, defined)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+// parseFixtures adds the fixture blocks of the SQL code sql, the contents of the file
+// filename, to fixtures. The positions of the fixtures already added are in defined. The
+// blocks start where sqload.BlockComments finds them, so a fixture comment written inside
+// a string literal is part of the SQL code, as for the Load functions.
+func parseFixtures(sql, filename string, fixtures, defined map[string]string) error {
+	sql = strings.ReplaceAll(sql, "\r\n", "\n")
+	blocks := sqload.BlockComments(sql, sqload.DialectGeneric)
+	for i, start := range blocks {
+		if !strings.HasPrefix(sql[start:], fixtureComment) {
+			continue
+		}
+		end := len(sql)
+		if i+1 < len(blocks) {
+			end = blocks[i+1]
+		}
+		nameLine, body, _ := strings.Cut(sql[start:end], "\n")
+		if j := strings.Index(body, queryComment); j != -1 {
+			body = body[:j]
+		}
+		name := strings.TrimSpace(strings.TrimPrefix(nameLine, fixtureComment))
+		position := fmt.Sprintf("%s:%d", filename, strings.Count(sql[:start], "\n")+1)
+		if !validFixtureNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid fixture name %s (%s)", name, position)
+		}
+		if previous, found := defined[name]; found {
+			return fmt.Errorf("fixture %s is defined twice (%s and %s)", name, previous, position)
+		}
+		defined[name] = position
+		fixtures[name] = strings.TrimSpace(body)
+	}
+	return nil
+}
+
+// DB is a test database: it runs queries and statements. It is implemented by *sql.DB,
+// *sql.Conn and *sql.Tx.
+type DB interface {
+	sqload.Queryer
+	sqload.Execer
+}
+
+// RunFixtures runs the fixtures of fixtures named names on the database db, in order,
+// splitting them into statements written in dialect d (see sqload.ExecScript). If a
+// fixture does not exist or fails, it will return an error.
+func RunFixtures(ctx context.Context, db sqload.Execer, fixtures map[string]string, d sqload.Dialect, names ...string) error {
+	for _, name := range names {
+		fixture, found := fixtures[name]
+		if !found {
+			return fmt.Errorf("could not find fixture %s", name)
+		}
+		if err := sqload.ExecScript(ctx, db, fixture, d); err != nil {
+			return fmt.Errorf("fixture %s: %w", name, err)
+		}
+	}
+	return nil
+}
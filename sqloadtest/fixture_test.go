@@ -0,0 +1,94 @@
+package sqloadtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/midir99/sqload"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cats.sql": {Data: []byte(`-- fixture: SeedCats
+INSERT INTO cat VALUES (1, 'Tom');
+INSERT INTO cat VALUES (2, 'Felix');
+
+-- query: FindCat
+SELECT * FROM cat WHERE id = :id;
+
+-- fixture: EmptyCats
+DELETE FROM cat;
+`)},
+		"users/users.sql": {Data: []byte("-- query: FindUser\r\nSELECT * FROM user;\r\n-- fixture: users.SeedUsers\r\nINSERT INTO user VALUES (1, 'Tom');\r\n")},
+		"labels.sql":      {Data: []byte("-- fixture: SeedLabels\nINSERT INTO label VALUES ('-- fixture: a', '-- tx: b');\n")},
+		"README.md":       {Data: []byte("-- fixture: NotAFixture\n")},
+	}
+	fixtures, err := LoadFixtures(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := map[string]string{
+		"SeedCats":        "INSERT INTO cat VALUES (1, 'Tom');\nINSERT INTO cat VALUES (2, 'Felix');",
+		"EmptyCats":       "DELETE FROM cat;",
+		"users.SeedUsers": "INSERT INTO user VALUES (1, 'Tom');",
+		"SeedLabels":      "INSERT INTO label VALUES ('-- fixture: a', '-- tx: b');",
+	}
+	if fmt.Sprint(fixtures) != fmt.Sprint(want) {
+		t.Errorf("got %q, want %q", fixtures, want)
+	}
+
+	testCases := []struct {
+		files fstest.MapFS
+		want  string
+	}{
+		{
+			fstest.MapFS{"a.sql": {Data: []byte("-- fixture: Seed Cats\nDELETE FROM cat;\n")}},
+			"invalid fixture name Seed Cats (a.sql:1)",
+		},
+		{
+			fstest.MapFS{
+				"a.sql": {Data: []byte("-- fixture: SeedCats\nDELETE FROM cat;\n")},
+				"b.sql": {Data: []byte("\n-- fixture: SeedCats\nDELETE FROM cat;\n")},
+			},
+			"fixture SeedCats is defined twice (a.sql:1 and b.sql:2)",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := LoadFixtures(tc.files)
+			if fmt.Sprint(err) != tc.want {
+				t.Errorf("got %v, want %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunFixtures(t *testing.T) {
+	d, db := openFakeDB(t)
+	fixtures := map[string]string{
+		"EmptyUsers": "DELETE FROM user;",
+		"SeedUsers":  "INSERT INTO user VALUES (1, 'Tom');\nINSERT INTO user VALUES (2, 'Ana');",
+		"Broken":     "DROP TABLE user;",
+	}
+	ctx := context.Background()
+	if err := RunFixtures(ctx, db, fixtures, sqload.DialectGeneric, "EmptyUsers", "SeedUsers"); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "[DELETE FROM user; INSERT INTO user VALUES (1, 'Tom'); INSERT INTO user VALUES (2, 'Ana');]"
+	if fmt.Sprint(d.Log()) != want {
+		t.Errorf("got %v, want %s", d.Log(), want)
+	}
+	if want := "[[1 Tom] [2 Ana]]"; fmt.Sprint(d.users) != want {
+		t.Errorf("got %v, want %s", d.users, want)
+	}
+	err := RunFixtures(ctx, db, fixtures, sqload.DialectGeneric, "Broken")
+	if want := "fixture Broken: statement 1: unknown statement DROP TABLE user;"; fmt.Sprint(err) != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+	err = RunFixtures(ctx, db, fixtures, sqload.DialectGeneric, "SeedCats")
+	if want := "could not find fixture SeedCats"; fmt.Sprint(err) != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
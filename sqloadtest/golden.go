@@ -0,0 +1,226 @@
+// Package sqloadtest provides helpers to test the queries loaded by the sqload package
+// against a test database.
+//
+// A query can have a golden file next to the .sql file that defines it, named after the
+// query, with the arguments to run it with and the rows it must return:
+//
+// File users/FindUserById.sql:
+//
+//	-- query: FindUserById
+//	SELECT id, name FROM user WHERE id = :id;
+//
+// File users/FindUserById.golden.json:
+//
+//	{
+//		"fixtures": ["SeedUsers"],
+//		"args": {"id": 1},
+//		"rows": [{"id": 1, "name": "Tom"}]
+//	}
+//
+// RunGolden runs every query that has a golden file and compares its rows with them,
+// after running the fixtures listed in the golden file: the blocks of test data written
+// in the .sql files with a fixture comment, see LoadFixtures.
+package sqloadtest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/midir99/sqload"
+)
+
+// GoldenSuffix is the suffix of the golden files, added to the names of the queries.
+const GoldenSuffix = ".golden.json"
+
+// Golden is a query paired with the contents of its golden file.
+type Golden struct {
+	// Name is the name of the query.
+	Name string `json:"-"`
+	// File is the path of the golden file.
+	File string `json:"-"`
+	// Fixtures are the names of the fixtures to run before the query, see LoadFixtures.
+	Fixtures []string `json:"fixtures"`
+	// Args are the values of the named parameters of the query.
+	Args map[string]any `json:"args"`
+	// Rows are the rows the query must return, in order, by column name.
+	Rows []map[string]any `json:"rows"`
+}
+
+// LoadGolden returns the golden files of the queries of qs found in the fsys file system,
+// sorted by query name. The golden file of a query is in the directory of the file that
+// defines it, named after the query without its namespace plus GoldenSuffix; qs must
+// have been loaded from fsys. The queries without a golden file are skipped.
+func LoadGolden(fsys fs.FS, qs *sqload.QuerySet) ([]Golden, error) {
+	goldens := []Golden{}
+	for _, name := range qs.Names() {
+		file, _ := qs.Source(name)
+		if file == "" {
+			continue
+		}
+		base := name[strings.LastIndex(name, ".")+1:]
+		goldenFile := path.Join(path.Dir(file), base+GoldenSuffix)
+		data, err := fs.ReadFile(fsys, goldenFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		g, err := parseGolden(data)
+		if err != nil {
+			return nil, fmt.Errorf("golden file %s: %w", goldenFile, err)
+		}
+		g.Name, g.File = name, goldenFile
+		goldens = append(goldens, g)
+	}
+	return goldens, nil
+}
+
+// parseGolden parses the contents data of a golden file.
+func parseGolden(data []byte) (Golden, error) {
+	var g Golden
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	d.DisallowUnknownFields()
+	if err := d.Decode(&g); err != nil {
+		return g, err
+	}
+	if g.Rows == nil {
+		return g, errors.New("missing rows")
+	}
+	for name, value := range g.Args {
+		g.Args[name] = argValue(value)
+	}
+	return g, nil
+}
+
+// argValue returns the value of an argument decoded from JSON as a value the drivers
+// accept: the numbers are converted to int64 or float64.
+func argValue(value any) any {
+	n, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// Check runs the query of g from qs with the arguments of g (see sqload.QuerySet.Bind)
+// and returns an error if the rows it returns are not the rows of g. The values are
+// compared as JSON values, so the columns of type []byte are compared as strings and the
+// numbers regardless of their Go type.
+func (g Golden) Check(ctx context.Context, db sqload.Queryer, qs *sqload.QuerySet) error {
+	query, args, err := qs.Bind(g.Name, g.Args)
+	if err != nil {
+		return err
+	}
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	got, err := scanRows(rows)
+	if err != nil {
+		return err
+	}
+	want, err := normalize(g.Rows)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		return fmt.Errorf("query %s returned %s, want %s (%s)", g.Name, gotJSON, wantJSON, g.File)
+	}
+	return nil
+}
+
+// scanRows reads and closes rows and returns them as JSON values.
+func scanRows(rows *sql.Rows) (any, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	result := []map[string]any{}
+	for rows.Next() {
+		values := make([]any, len(columns))
+		pointers := make([]any, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			if b, ok := values[i].([]byte); ok {
+				values[i] = string(b)
+			}
+			row[column] = values[i]
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return normalize(result)
+}
+
+// normalize returns v as the value encoding/json decodes from its JSON encoding, so
+// values of different Go types can be compared.
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var normalized any
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
+
+// RunGolden runs, as a subtest of t named after it, every query of qs that has a golden
+// file in the fsys file system (see LoadGolden) on the database db, after the fixtures
+// of its golden file, and reports the queries that do not return the rows of their
+// golden files:
+//
+//	func TestQueries(t *testing.T) {
+//		sqloadtest.RunGolden(t, testDB, sqlFiles, qs)
+//	}
+//
+// The fixtures are run with the dialect of qs. The queries are run one after the other,
+// so each one sees the data left by the fixtures and queries before it.
+func RunGolden(t *testing.T, db DB, fsys fs.FS, qs *sqload.QuerySet) {
+	t.Helper()
+	goldens, err := LoadGolden(fsys, qs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures, err := LoadFixtures(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range goldens {
+		g := g
+		t.Run(g.Name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := RunFixtures(ctx, db, fixtures, qs.Dialect(), g.Fixtures...); err != nil {
+				t.Fatal(err)
+			}
+			if err := g.Check(ctx, db, qs); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package sqloadtest
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/midir99/sqload"
+)
+
+func TestLoadGolden(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users/FindUserById.sql":         {Data: []byte("-- query: FindUserById\nSELECT id, name FROM user WHERE id = :id;\n")},
+		"users/FindUserById.golden.json": {Data: []byte(`{"args": {"id": 1}, "rows": [{"id": 1, "name": "Tom"}]}`)},
+		"users/FindUsers.sql":            {Data: []byte("-- query: FindUsers\nSELECT id, name FROM user;\n")},
+		"users/CountUsers.golden.json":   {Data: []byte(`{"rows": []}`)},
+	}
+	qs, err := sqload.LoadQuerySet(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	goldens, err := LoadGolden(fsys, qs)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "[{FindUserById users/FindUserById.golden.json [] map[id:1] [map[id:1 name:Tom]]}]"
+	if fmt.Sprint(goldens) != want {
+		t.Errorf("got %v, want %s", goldens, want)
+	}
+	if _, ok := goldens[0].Args["id"].(int64); !ok {
+		t.Errorf("got %T, want int64", goldens[0].Args["id"])
+	}
+
+	testCases := []struct {
+		golden string
+		want   string
+	}{
+		{`{"args": {"id": 1}}`, "golden file users/FindUserById.golden.json: missing rows"},
+		{`{"args": {"id": 1}, "rows": [], "extra": true}`, `golden file users/FindUserById.golden.json: json: unknown field "extra"`},
+		{`[`, "golden file users/FindUserById.golden.json: unexpected EOF"},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			fsys["users/FindUserById.golden.json"] = &fstest.MapFile{Data: []byte(tc.golden)}
+			_, err := LoadGolden(fsys, qs)
+			if fmt.Sprint(err) != tc.want {
+				t.Errorf("got %v, want %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestGoldenCheck(t *testing.T) {
+	_, db := openFakeDB(t, []driver.Value{int64(1), []byte("Tom")}, []driver.Value{int64(2), "Ana"})
+	fsys := fstest.MapFS{
+		"FindUserById.sql": {Data: []byte("-- query: FindUserById\nSELECT id, name FROM user WHERE id = :id;\n")},
+	}
+	qs, err := sqload.LoadQuerySet(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		golden Golden
+		want   string
+	}{
+		{Golden{Name: "FindUserById", Args: map[string]any{"id": int64(1)}, Rows: []map[string]any{{"id": 1, "name": "Tom"}}}, "<nil>"},
+		{Golden{Name: "FindUserById", Args: map[string]any{"id": int64(3)}, Rows: []map[string]any{}}, "<nil>"},
+		{
+			Golden{Name: "FindUserById", File: "FindUserById.golden.json", Args: map[string]any{"id": int64(2)}, Rows: []map[string]any{{"id": 2, "name": "Tom"}}},
+			`query FindUserById returned [{"id":2,"name":"Ana"}], want [{"id":2,"name":"Tom"}] (FindUserById.golden.json)`,
+		},
+		{Golden{Name: "FindUserById", Rows: []map[string]any{}}, "missing value for parameter id"},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			err := tc.golden.Check(context.Background(), db, qs)
+			if fmt.Sprint(err) != tc.want {
+				t.Errorf("got %v, want %s", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunGolden(t *testing.T) {
+	d, db := openFakeDB(t)
+	fsys := fstest.MapFS{
+		"FindUserById.sql":         {Data: []byte("-- fixture: SeedUsers\nDELETE FROM user;\nINSERT INTO user VALUES (1, 'Tom');\n\n-- query: FindUserById\nSELECT id, name FROM user WHERE id = :id;\n")},
+		"FindUserById.golden.json": {Data: []byte(`{"fixtures": ["SeedUsers"], "args": {"id": 1}, "rows": [{"id": 1, "name": "Tom"}]}`)},
+	}
+	qs, err := sqload.LoadQuerySet(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	RunGolden(t, db, fsys, qs)
+	want := "[DELETE FROM user; INSERT INTO user VALUES (1, 'Tom'); SELECT id, name FROM user WHERE id = ?;]"
+	if fmt.Sprint(d.Log()) != want {
+		t.Errorf("got %v, want %s", d.Log(), want)
+	}
+}
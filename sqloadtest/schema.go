@@ -0,0 +1,110 @@
+package sqloadtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/midir99/sqload"
+)
+
+// Preparer prepares statements. It is implemented by *sql.DB, *sql.Conn and *sql.Tx.
+type Preparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// SchemaDB is a database a schema can be applied to and queries checked against.
+type SchemaDB interface {
+	sqload.Execer
+	Preparer
+}
+
+// ApplySchema runs the DDL and procedure queries of qs on the database db, in the order
+// they were defined (see sqload.QuerySet.ByKind), so the schema they define can be built
+// from scratch in an ephemeral database, like an in-memory SQLite database. Each query
+// can hold several statements, see sqload.ExecScript. If a query fails, it stops and
+// returns its error.
+func ApplySchema(ctx context.Context, db sqload.Execer, qs *sqload.QuerySet) error {
+	for _, q := range qs.ByKind(sqload.KindDDL, sqload.KindProcedure) {
+		if err := sqload.ExecScript(ctx, db, q.SQL, qs.Dialect()); err != nil {
+			return fmt.Errorf("query %s: %w", q.Name, err)
+		}
+	}
+	return nil
+}
+
+// CheckQueries prepares the DML queries of qs, and their variants, on the database db
+// and returns the errors of the ones that can not be prepared, in the order they were
+// defined. Most databases check the tables and columns a statement uses when it is
+// prepared, so after ApplySchema it finds the queries that do not match the schema
+// without running them. The named parameters are rewritten for the dialect of qs first,
+// see sqload.BindParams. The queries with a no-prepare annotation are skipped, see
+// sqload.Query.Preparable.
+func CheckQueries(ctx context.Context, db Preparer, qs *sqload.QuerySet) []error {
+	errs := []error{}
+	for _, q := range qs.ByKind(sqload.KindDML) {
+		queries := []sqload.Query{q}
+		for _, variant := range qs.Variants(q.Name) {
+			v, _ := qs.SelectQuery(q.Name, sqload.Flags{q.Name: variant})
+			queries = append(queries, v)
+		}
+		for _, q := range queries {
+			if !q.Preparable() {
+				continue
+			}
+			if err := prepare(ctx, db, qs, q); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+// prepare prepares the query q of qs on the database db.
+func prepare(ctx context.Context, db Preparer, qs *sqload.QuerySet, q sqload.Query) error {
+	name := q.Name
+	if q.Variant != "" {
+		name += ":" + q.Variant
+	}
+	args := make(map[string]any, len(q.Params))
+	for _, p := range q.Params {
+		args[p] = nil
+	}
+	query, _, err := sqload.BindParams(q.SQL, qs.Dialect(), args, qs.ParamStyles()...)
+	if err != nil {
+		return fmt.Errorf("query %s: %w", name, err)
+	}
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("query %s: %w", name, err)
+	}
+	return stmt.Close()
+}
+
+// CheckSchema applies the schema of qs to the database db (see ApplySchema) and reports
+// the queries of qs that do not match it (see CheckQueries), so the queries can be
+// validated offline, for example in CI, against an in-memory database:
+//
+//	func TestQueriesMatchSchema(t *testing.T) {
+//		db, err := sql.Open("sqlite", ":memory:")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		defer db.Close()
+//		db.SetMaxOpenConns(1)
+//		sqloadtest.CheckSchema(t, db, qs)
+//	}
+//
+// An in-memory SQLite database only lives as long as its connection, so the pool must
+// be limited to one connection.
+func CheckSchema(t *testing.T, db SchemaDB, qs *sqload.QuerySet) {
+	t.Helper()
+	ctx := context.Background()
+	if err := ApplySchema(ctx, db, qs); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range CheckQueries(ctx, db, qs) {
+		t.Error(err)
+	}
+}
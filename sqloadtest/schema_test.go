@@ -0,0 +1,75 @@
+package sqloadtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/midir99/sqload"
+)
+
+func TestApplySchemaAndCheckQueries(t *testing.T) {
+	sql := `-- query: FindCat
+SELECT * FROM cat WHERE id = :id;
+
+-- query: CreateCat
+CREATE TABLE cat (id INTEGER);
+
+-- query: FindUser
+SELECT * FROM user WHERE id = :id AND org = :org;
+
+-- query: FindUser variant=fast
+SELECT * FROM user_by_id WHERE id = :id;
+
+-- query: FindOrder
+SELECT * FROM "order" JOIN invoice ON invoice.order_id = "order".id;
+
+-- query: CopyDogs
+-- no-prepare
+COPY dog FROM STDIN;
+`
+	qs, err := sqload.LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte(sql)}}, sqload.WithDialect(sqload.DialectSQLite))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	d, db := openFakeDB(t)
+	ctx := context.Background()
+	if err := ApplySchema(ctx, db, qs); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := "[CREATE TABLE cat (id INTEGER);]"; fmt.Sprint(d.Log()) != want {
+		t.Errorf("got %v, want %s", d.Log(), want)
+	}
+	errs := CheckQueries(ctx, db, qs)
+	want := "[query FindUser:fast: no such table: user_by_id query FindOrder: no such table: invoice]"
+	if fmt.Sprint(errs) != want {
+		t.Errorf("got %v, want %s", errs, want)
+	}
+	if want := "PREPARE SELECT * FROM user WHERE id = ? AND org = ?;"; !containsString(d.Log(), want) {
+		t.Errorf("got %v, want %s to be prepared", d.Log(), want)
+	}
+
+	broken, err := sqload.LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte("-- query: DropCat\nDROP TABLE cat;")}})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	err = ApplySchema(ctx, db, broken)
+	if want := "query DropCat: statement 1: unknown statement DROP TABLE cat;"; fmt.Sprint(err) != want {
+		t.Errorf("got %v, want %s", err, want)
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	sql := "-- query: CreateCat\nCREATE TABLE cat (id INTEGER);\n\n-- query: FindCat\nSELECT * FROM cat WHERE id = :id;\n"
+	qs, err := sqload.LoadQuerySet(fstest.MapFS{"q.sql": {Data: []byte(sql)}}, sqload.WithDialect(sqload.DialectPostgres))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	d, db := openFakeDB(t)
+	CheckSchema(t, db, qs)
+	want := "[CREATE TABLE cat (id INTEGER); PREPARE SELECT * FROM cat WHERE id = $1;]"
+	if fmt.Sprint(d.Log()) != want {
+		t.Errorf("got %v, want %s", d.Log(), want)
+	}
+}
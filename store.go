@@ -0,0 +1,226 @@
+package sqload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNoHistory is returned by QueryStore.Rollback when there is no previous QuerySet
+// to restore.
+var ErrNoHistory = errors.New("no previous query set")
+
+// QueryStore holds the current QuerySet of a long-running program and lets it be
+// replaced while other goroutines read from it. Readers always see a complete
+// QuerySet, either the one before a Reload or the one after it, never a mix of both.
+//
+//	//go:embed sql
+//	var fsys embed.FS
+//
+//	var store = sqload.NewQueryStore()
+//
+//	func main() {
+//		if err := store.Reload(fsys); err != nil {
+//			log.Fatal(err)
+//		}
+//		sql, found := store.Get("FindUserById")
+//		...
+//	}
+//
+// A QueryStore created with the WithHistory option also keeps the QuerySets it
+// replaced, so a bad Reload can be undone with Rollback.
+type QueryStore struct {
+	cfg     *config
+	writing sync.Mutex // serializes the writers, from reading the current QuerySet to replacing it
+	mu      sync.Mutex // guards history, version, loadedAt and lastErr
+	current atomic.Value
+	history []*QuerySet // previous QuerySets, the most recent one last
+	version int
+
+	loadedAt time.Time // when the current QuerySet was loaded by Reload or Update
+	lastErr  error     // error of the last Reload or Update, if it failed
+}
+
+// NewQueryStore returns an empty QueryStore. The options in opts are applied on every
+// Reload.
+func NewQueryStore(opts ...Option) *QueryStore {
+	s := &QueryStore{cfg: newConfig(opts)}
+	s.current.Store(&QuerySet{queries: map[string]Query{}, dialect: s.cfg.dialect, paramStyles: s.cfg.paramStyles})
+	return s
+}
+
+// Reload reads all the .sql files in the fsys file system (recursively) and atomically
+// replaces the current QuerySet with the result. If any error occurs, the current
+// QuerySet is kept and the error is returned.
+func (s *QueryStore) Reload(fsys fs.FS) error {
+	s.writing.Lock()
+	defer s.writing.Unlock()
+	return s.reload(fsys)
+}
+
+// reload is like Reload but the caller must hold s.writing.
+func (s *QueryStore) reload(fsys fs.FS) error {
+	qs, err := loadQuerySet(fsys, s.cfg)
+	if err != nil {
+		s.fail(err)
+		return err
+	}
+	s.replace(qs)
+	return nil
+}
+
+// Update is like Reload but it only parses again the files of fsys in changedPaths, see
+// QuerySet.Update. If the current QuerySet was not loaded by Reload or Update, all the
+// files are read. The writers of the store are serialized, so concurrent updates each
+// start from the result of the previous one instead of overwriting it.
+//
+//	// on every change notified by a file watcher
+//	if err := store.Update(os.DirFS("sql"), []string{event.Name}); err != nil {
+//		log.Print(err)
+//	}
+func (s *QueryStore) Update(fsys fs.FS, changedPaths []string) error {
+	s.writing.Lock()
+	defer s.writing.Unlock()
+	current := s.QuerySet()
+	if current.src == nil {
+		return s.reload(fsys)
+	}
+	qs, err := current.Update(fsys, changedPaths)
+	if err != nil {
+		s.fail(err)
+		return err
+	}
+	s.replace(qs)
+	return nil
+}
+
+// replace replaces the current QuerySet with qs. The empty QuerySet of a new store is
+// not kept in the history, since there is nothing to roll back to.
+func (s *QueryStore) replace(qs *QuerySet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg.history > 0 && !s.loadedAt.IsZero() {
+		s.history = append(s.history, s.QuerySet())
+		if len(s.history) > s.cfg.history {
+			s.history = s.history[len(s.history)-s.cfg.history:]
+		}
+	}
+	s.current.Store(qs)
+	s.version++
+	s.loadedAt, s.lastErr = time.Now(), nil
+	if s.cfg.publish {
+		qs.publish()
+	}
+}
+
+// fail records err as the error of the last load.
+func (s *QueryStore) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+}
+
+// Refresh reloads the store from the file system returned by fetch every ttl, until ctx
+// is done, so a bundle of queries fetched remotely is kept up to date. If fetch or the
+// reload fails, the current QuerySet keeps being served and the error can be read with
+// LastError; it is retried after ttl. Refresh blocks, so it is usually run in its own
+// goroutine after a first Reload:
+//
+//	if err := store.Reload(bundle); err != nil {
+//		log.Fatal(err)
+//	}
+//	go store.Refresh(ctx, 5*time.Minute, func(ctx context.Context) (fs.FS, error) {
+//		return fetchBundle(ctx, "https://config.example.com/queries.zip")
+//	})
+//
+// It returns the error of ctx when ctx is done. If ttl is not positive, it will return an
+// error right away.
+func (s *QueryStore) Refresh(ctx context.Context, ttl time.Duration, fetch func(ctx context.Context) (fs.FS, error)) error {
+	if ttl <= 0 {
+		return fmt.Errorf("invalid refresh interval %s", ttl)
+	}
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		fsys, err := fetch(ctx)
+		if err != nil {
+			s.fail(err)
+			continue
+		}
+		s.Reload(fsys)
+	}
+}
+
+// LastLoaded returns when the current QuerySet was loaded by Reload or Update, or the
+// zero time if it has not been loaded yet. A QuerySet restored by Rollback keeps the time
+// of the load it replaced.
+func (s *QueryStore) LastLoaded() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loadedAt
+}
+
+// LastError returns the error of the last Reload, Update or refresh (see Refresh) if it
+// failed, or nil if it succeeded.
+func (s *QueryStore) LastError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastErr
+}
+
+// Rollback replaces the current QuerySet with the one it replaced, discarding the
+// current one. It can be called as many times as previous QuerySets are retained; when
+// there are none left, it will return ErrNoHistory. With WithPublish, the queries of the
+// restored QuerySet are published again.
+func (s *QueryStore) Rollback() error {
+	s.writing.Lock()
+	defer s.writing.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.history) == 0 {
+		return ErrNoHistory
+	}
+	previous := s.history[len(s.history)-1]
+	s.history[len(s.history)-1] = nil
+	s.history = s.history[:len(s.history)-1]
+	s.current.Store(previous)
+	s.version++
+	if s.cfg.publish {
+		previous.publish()
+	}
+	return nil
+}
+
+// Version returns the number of times the current QuerySet has been replaced, either by
+// Reload or by Rollback.
+func (s *QueryStore) Version() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.version
+}
+
+// QuerySet returns the current QuerySet. Callers that need several queries consistent
+// with each other should get them from the same QuerySet.
+func (s *QueryStore) QuerySet() *QuerySet {
+	return s.current.Load().(*QuerySet)
+}
+
+// Get returns the SQL code of the query name from the current QuerySet.
+func (s *QueryStore) Get(name string) (string, bool) {
+	return s.QuerySet().Get(name)
+}
+
+// NameOf returns the name of the query of the current QuerySet whose SQL code is sql, see
+// QuerySet.NameOf.
+func (s *QueryStore) NameOf(sql string) (string, bool) {
+	return s.QuerySet().NameOf(sql)
+}
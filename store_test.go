@@ -0,0 +1,288 @@
+package sqload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestQueryStore(t *testing.T) {
+	store := NewQueryStore()
+	if store.QuerySet().Len() != 0 {
+		t.Fatalf("got %d queries, want 0", store.QuerySet().Len())
+	}
+	if err := store.Reload(os.DirFS("testdata/test-load-from-fs")); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	sql, found := store.Get("FindRiders")
+	if !found {
+		t.Fatal("query FindRiders not found")
+	}
+	if sql != RiderTestQueries["FindRiders"] {
+		t.Errorf("got %s, want %s", sql, RiderTestQueries["FindRiders"])
+	}
+	// A failed reload keeps the current queries
+	err := store.Reload(fstest.MapFS{
+		"bad.sql": {Data: []byte("-- query: bad-name\nSELECT 1;")},
+	})
+	if err == nil {
+		t.Fatal("err is nil")
+	}
+	if _, found := store.Get("FindRiders"); !found {
+		t.Fatal("query FindRiders not found")
+	}
+	if err := store.Reload(fstest.MapFS{
+		"ping.sql": {Data: []byte("-- query: Ping\nSELECT 1;")},
+	}); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if _, found := store.Get("FindRiders"); found {
+		t.Fatal("query FindRiders must not be found after the reload")
+	}
+	if sql, _ := store.Get("Ping"); sql != "SELECT 1;" {
+		t.Errorf("got %s, want %s", sql, "SELECT 1;")
+	}
+}
+
+func TestQueryStoreConcurrentReload(t *testing.T) {
+	bundles := []fstest.MapFS{
+		{"q.sql": {Data: []byte("-- query: A\nSELECT 'a';\n-- query: B\nSELECT 'a';")}},
+		{"q.sql": {Data: []byte("-- query: A\nSELECT 'b';\n-- query: B\nSELECT 'b';")}},
+	}
+	store := NewQueryStore()
+	if err := store.Reload(bundles[0]); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				qs := store.QuerySet()
+				a, _ := qs.Get("A")
+				b, _ := qs.Get("B")
+				if a != b {
+					t.Errorf("torn read: got %s and %s", a, b)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 50; i++ {
+		if err := store.Reload(bundles[i%2]); err != nil {
+			t.Errorf("err must be nil, got %s", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestQueryStoreRollback(t *testing.T) {
+	bundle := func(sql string) fstest.MapFS {
+		return fstest.MapFS{"q.sql": {Data: []byte("-- query: Q\n" + sql)}}
+	}
+	store := NewQueryStore(WithHistory(2))
+	if err := store.Rollback(); err != ErrNoHistory {
+		t.Fatalf("got %v, want %v", err, ErrNoHistory)
+	}
+	for _, sql := range []string{"SELECT 1;", "SELECT 2;", "SELECT 3;", "SELECT 4;"} {
+		if err := store.Reload(bundle(sql)); err != nil {
+			t.Fatalf("err must be nil, got %s", err)
+		}
+	}
+	if store.Version() != 4 {
+		t.Fatalf("got version %d, want 4", store.Version())
+	}
+	// Only the two previous QuerySets are retained
+	for _, want := range []string{"SELECT 3;", "SELECT 2;"} {
+		if err := store.Rollback(); err != nil {
+			t.Fatalf("err must be nil, got %s", err)
+		}
+		if sql, _ := store.Get("Q"); sql != want {
+			t.Errorf("got %s, want %s", sql, want)
+		}
+	}
+	if err := store.Rollback(); err != ErrNoHistory {
+		t.Fatalf("got %v, want %v", err, ErrNoHistory)
+	}
+	if store.Version() != 6 {
+		t.Fatalf("got version %d, want 6", store.Version())
+	}
+	// The empty QuerySet of a new store is not retained
+	store = NewQueryStore(WithHistory(2))
+	if err := store.Reload(bundle("SELECT 1;")); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if err := store.Rollback(); err != ErrNoHistory {
+		t.Fatalf("got %v, want %v", err, ErrNoHistory)
+	}
+	// Without history there is nothing to roll back to
+	store = NewQueryStore()
+	if err := store.Reload(bundle("SELECT 1;")); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if err := store.Rollback(); err != ErrNoHistory {
+		t.Fatalf("got %v, want %v", err, ErrNoHistory)
+	}
+}
+
+func TestQueryStoreRollbackPublish(t *testing.T) {
+	defer resetRegistry()
+	store := NewQueryStore(WithHistory(1), WithPublish())
+	for _, sql := range []string{"SELECT 1;", "SELECT 2;"} {
+		if err := store.Reload(fstest.MapFS{"q.sql": {Data: []byte("-- query: Q\n" + sql)}}); err != nil {
+			t.Fatalf("err must be nil, got %s", err)
+		}
+	}
+	if err := store.Rollback(); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	sql, _ := store.Get("Q")
+	if published, _ := Lookup("Q"); sql != "SELECT 1;" || published != sql {
+		t.Errorf("got %s and %s, want %s", sql, published, "SELECT 1;")
+	}
+}
+
+func TestQueryStoreUpdate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.sql": {Data: []byte("-- query: A\nSELECT 'a';")},
+		"b.sql": {Data: []byte("-- query: B\nSELECT 'b';")},
+	}
+	store := NewQueryStore(WithHistory(1))
+	// The empty QuerySet of a new store is loaded whole
+	if err := store.Update(fsys, nil); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if store.QuerySet().Len() != 2 {
+		t.Fatalf("got %d queries, want 2", store.QuerySet().Len())
+	}
+	fsys["b.sql"] = &fstest.MapFile{Data: []byte("-- query: B\nSELECT 'B';")}
+	if err := store.Update(fsys, []string{"b.sql"}); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if sql, _ := store.Get("B"); sql != "SELECT 'B';" {
+		t.Errorf("got %s, want %s", sql, "SELECT 'B';")
+	}
+	if store.Version() != 2 {
+		t.Errorf("got version %d, want 2", store.Version())
+	}
+	if err := store.Rollback(); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if sql, _ := store.Get("B"); sql != "SELECT 'b';" {
+		t.Errorf("got %s, want %s", sql, "SELECT 'b';")
+	}
+}
+
+// slowFS is a file system that takes a while to open its files, so the loads of
+// concurrent calls overlap.
+type slowFS struct {
+	fsys fs.FS
+}
+
+func (s slowFS) Open(name string) (fs.File, error) {
+	time.Sleep(5 * time.Millisecond)
+	return s.fsys.Open(name)
+}
+
+func TestQueryStoreConcurrentUpdate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.sql": {Data: []byte("-- query: A\nSELECT 'a';")},
+		"b.sql": {Data: []byte("-- query: B\nSELECT 'b';")},
+	}
+	store := NewQueryStore()
+	if err := store.Reload(fsys); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	fsys["a.sql"] = &fstest.MapFile{Data: []byte("-- query: A\nSELECT 'A';")}
+	fsys["b.sql"] = &fstest.MapFile{Data: []byte("-- query: B\nSELECT 'B';")}
+	var wg sync.WaitGroup
+	for _, changed := range []string{"a.sql", "b.sql"} {
+		wg.Add(1)
+		go func(changed string) {
+			defer wg.Done()
+			if err := store.Update(slowFS{fsys}, []string{changed}); err != nil {
+				t.Errorf("err must be nil, got %s", err)
+			}
+		}(changed)
+	}
+	wg.Wait()
+	// Each update starts from the result of the other one, so none is lost
+	a, _ := store.Get("A")
+	b, _ := store.Get("B")
+	if a != "SELECT 'A';" || b != "SELECT 'B';" {
+		t.Errorf("got %s and %s, want both updates", a, b)
+	}
+}
+
+func TestQueryStoreRefresh(t *testing.T) {
+	store := NewQueryStore()
+	if !store.LastLoaded().IsZero() || store.LastError() != nil {
+		t.Fatalf("got %s and %v, want the zero time and nil", store.LastLoaded(), store.LastError())
+	}
+	if err := store.Reload(fstest.MapFS{"q.sql": {Data: []byte("-- query: Ping\nSELECT 1;")}}); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	loaded := store.LastLoaded()
+	if loaded.IsZero() {
+		t.Fatal("got the zero time, want the time of the reload")
+	}
+
+	unavailable, stop := errors.New("unavailable"), errors.New("stop")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	fetch := func(ctx context.Context) (fs.FS, error) {
+		calls++
+		switch calls {
+		case 1:
+			return nil, unavailable
+		case 2:
+			// The failed refresh keeps serving the current queries
+			if store.LastError() != unavailable {
+				t.Errorf("got %v, want %s", store.LastError(), unavailable)
+			}
+			if sql, _ := store.Get("Ping"); sql != "SELECT 1;" {
+				t.Errorf("got %s, want SELECT 1;", sql)
+			}
+			return fstest.MapFS{"q.sql": {Data: []byte("-- query: Ping\nSELECT 2;")}}, nil
+		case 3:
+			if store.LastError() != nil {
+				t.Errorf("got %v, want nil", store.LastError())
+			}
+		}
+		cancel()
+		return nil, stop
+	}
+	if err := store.Refresh(ctx, time.Millisecond, fetch); err != context.Canceled {
+		t.Errorf("got %v, want %s", err, context.Canceled)
+	}
+	if calls != 3 {
+		t.Errorf("got %d calls, want 3", calls)
+	}
+	if sql, _ := store.Get("Ping"); sql != "SELECT 2;" {
+		t.Errorf("got %s, want SELECT 2;", sql)
+	}
+	if store.LastError() != stop {
+		t.Errorf("got %v, want %s", store.LastError(), stop)
+	}
+	if !store.LastLoaded().After(loaded) {
+		t.Errorf("got %s, want a time after %s", store.LastLoaded(), loaded)
+	}
+
+	if err := store.Refresh(context.Background(), 0, fetch); fmt.Sprint(err) != "invalid refresh interval 0s" {
+		t.Errorf("got %v, want invalid refresh interval 0s", err)
+	}
+
+	if err := store.Reload(fstest.MapFS{"q.sql": {Data: []byte("-- query: bad-name\nSELECT 1;")}}); err == nil {
+		t.Fatal("err is nil")
+	} else if store.LastError() != err {
+		t.Errorf("got %v, want %s", store.LastError(), err)
+	}
+}
@@ -0,0 +1,221 @@
+package sqload
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// engineDialects are the dialects that can be used as engine suffixes in file names.
+var engineDialects = map[string]bool{
+	string(DialectPostgres): true,
+	string(DialectMySQL):    true,
+	string(DialectSQLite):   true,
+	string(DialectTSQL):     true,
+}
+
+// findQueryFiles returns the .sql files of the fsys file system that must be loaded with
+// the configuration cfg, see selectEngineFiles.
+func findQueryFiles(fsys fs.FS, cfg *config) ([]string, error) {
+	files, err := findFiles(fsys, ".sql", cfg)
+	if err != nil {
+		return nil, err
+	}
+	files, err = selectEngineFiles(files, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return selectLocaleFiles(files, cfg), nil
+}
+
+// engineChain returns the engines whose files are preferred with the configuration
+// cfg, in order: the ones set with WithEngines or, by default, the dialect.
+func engineChain(cfg *config) []string {
+	if len(cfg.engines) > 0 {
+		return cfg.engines
+	}
+	if cfg.dialect == DialectGeneric {
+		return nil
+	}
+	return []string{string(cfg.dialect)}
+}
+
+// splitEngineSuffix splits the path of a .sql file into its path without the engine
+// suffix and the engine suffix, so users.postgres.sql is split into users.sql and
+// postgres. The engine suffixes are the names of the dialects and the engines of cfg. If
+// the file has no engine suffix, the suffix is an empty string. The suffixes are only
+// recognized when cfg sets a dialect or engines, so without them schema.postgres.sql is
+// an ordinary file.
+func splitEngineSuffix(file string, cfg *config) (string, string) {
+	if len(engineChain(cfg)) == 0 {
+		return file, ""
+	}
+	ext := path.Ext(file)
+	base := strings.TrimSuffix(file, ext)
+	suffix := path.Ext(base)
+	if suffix == "" {
+		return file, ""
+	}
+	engine := strings.ToLower(suffix[1:])
+	if !engineDialects[engine] && !containsString(cfg.engines, engine) {
+		return file, ""
+	}
+	return strings.TrimSuffix(base, suffix) + ext, engine
+}
+
+// selectEngineFiles returns the files of files that must be loaded for the engines of
+// cfg (see engineChain). A file named after an engine, like users.postgres.sql, is only
+// loaded for that engine, and it replaces the file without the suffix, users.sql, if
+// there is one. When there are files for several engines of the chain, the one for the
+// first engine wins. If there are files named after engines but none of them, nor the
+// file without the suffix, matches, it will return an error. Without a dialect or
+// engines, all the files are loaded.
+func selectEngineFiles(files []string, cfg *config) ([]string, error) {
+	chain := engineChain(cfg)
+	type group struct {
+		plain    string
+		selected string
+		rank     int // position in chain of the engine of selected
+		others   []string
+	}
+	groups := map[string]*group{}
+	for _, file := range files {
+		key, engine := splitEngineSuffix(file, cfg)
+		g := groups[key]
+		if g == nil {
+			g = &group{}
+			groups[key] = g
+		}
+		if engine == "" {
+			g.plain = file
+			continue
+		}
+		rank := len(chain)
+		for i, e := range chain {
+			if e == engine {
+				rank = i
+				break
+			}
+		}
+		switch {
+		case rank == len(chain):
+			g.others = append(g.others, file)
+		case g.selected == "" || rank < g.rank:
+			g.selected, g.rank = file, rank
+		}
+	}
+	selected := make([]string, 0, len(files))
+	for _, file := range files {
+		key, _ := splitEngineSuffix(file, cfg)
+		g := groups[key]
+		chosen := g.selected
+		if chosen == "" {
+			chosen = g.plain
+		}
+		if chosen == "" {
+			if len(cfg.engines) > 0 {
+				return nil, errorf(CodeNoMatchingFile, "%w: none of %s matches the engines %s", ErrCannotLoadQueries, strings.Join(g.others, ", "), strings.Join(cfg.engines, ", "))
+			}
+			return nil, errorf(CodeNoMatchingFile, "%w: none of %s matches the dialect %s", ErrCannotLoadQueries, strings.Join(g.others, ", "), dialectName(cfg.dialect))
+		}
+		if file == chosen {
+			selected = append(selected, file)
+		}
+	}
+	return selected, nil
+}
+
+// selectLocaleFiles returns the files of files that must be loaded for the locale of cfg
+// (see WithLocale). A file with a suffix, like welcome_email.de.sql, is a localized
+// version of the file without it, welcome_email.sql, if that file exists. Only the
+// version for the locale is loaded or, if there is none, the file without the suffix.
+func selectLocaleFiles(files []string, cfg *config) []string {
+	if cfg.locale == "" {
+		return files
+	}
+	present := map[string]bool{}
+	for _, file := range files {
+		key, _ := splitEngineSuffix(file, cfg)
+		present[key] = true
+	}
+	// localized returns the file without the locale suffix of the localized file key,
+	// and its locale, or an empty string if key is not localized.
+	localized := func(key string) (string, string) {
+		ext := path.Ext(key)
+		base := strings.TrimSuffix(key, ext)
+		suffix := path.Ext(base)
+		if suffix == "" || !present[strings.TrimSuffix(base, suffix)+ext] {
+			return "", ""
+		}
+		return strings.TrimSuffix(base, suffix) + ext, suffix[1:]
+	}
+	// chosen returns the locale whose version of the file key must be loaded, or an
+	// empty string if the file itself must be loaded.
+	chosen := func(key string) string {
+		ext := path.Ext(key)
+		for _, locale := range localeCandidates(cfg.locale) {
+			if present[strings.TrimSuffix(key, ext)+"."+locale+ext] {
+				return locale
+			}
+		}
+		return ""
+	}
+	selected := make([]string, 0, len(files))
+	for _, file := range files {
+		key, _ := splitEngineSuffix(file, cfg)
+		if plain, locale := localized(key); plain != "" {
+			if chosen(plain) == locale {
+				selected = append(selected, file)
+			}
+			continue
+		}
+		if chosen(key) == "" {
+			selected = append(selected, file)
+		}
+	}
+	return selected
+}
+
+// localeCandidates returns the locales whose files are looked for, in order, for the
+// locale locale: the locale itself and its language, so de-AT falls back to de.
+func localeCandidates(locale string) []string {
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		return []string{locale, locale[:i]}
+	}
+	return []string{locale}
+}
+
+// dialectName returns the name of the dialect d to be shown in messages.
+func dialectName(d Dialect) string {
+	if d == DialectGeneric {
+		return "generic"
+	}
+	return string(d)
+}
+
+// dialectSource returns a function that loads the queries of the fsys file system with
+// the configuration cfg but the dialect d, for the fields that override the dialect. The
+// queries of each dialect are loaded once.
+func dialectSource(fsys fs.FS, cfg *config) func(d Dialect) (map[string]string, error) {
+	loaded := map[Dialect]map[string]string{}
+	return func(d Dialect) (map[string]string, error) {
+		if queries, found := loaded[d]; found {
+			return queries, nil
+		}
+		dialectCfg := *cfg
+		dialectCfg.dialect = d
+		dialectCfg.engines = nil
+		dialectCfg.report = nil // the problems and the events were reported by the first load
+		dialectCfg.events = ParseEvents{}
+		queries, headers, err := extractQueriesFromFS(fsys, &dialectCfg)
+		if err != nil {
+			return nil, err
+		}
+		queries, _, err = resolveQueries(queries, headers, &dialectCfg)
+		if err != nil {
+			return nil, err
+		}
+		loaded[d] = queries
+		return queries, nil
+	}
+}
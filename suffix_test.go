@@ -0,0 +1,200 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestSplitEngineSuffix(t *testing.T) {
+	testCases := []struct {
+		file       string
+		wantFile   string
+		wantEngine string
+	}{
+		{"users.sql", "users.sql", ""},
+		{"sql/users.postgres.sql", "sql/users.sql", "postgres"},
+		{"users.MySQL.sql", "users.sql", "mysql"},
+		{"users.v2.sql", "users.v2.sql", ""},
+		{"postgres.sql", "postgres.sql", ""},
+		{"users.cockroach.sql", "users.sql", "cockroach"},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			file, engine := splitEngineSuffix(tc.file, &config{engines: []string{"cockroach"}})
+			if file != tc.wantFile || engine != tc.wantEngine {
+				t.Errorf("got %s %s, want %s %s", file, engine, tc.wantFile, tc.wantEngine)
+			}
+		})
+	}
+}
+
+func TestLoadFromFSEngineSuffix(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ping.sql":          {Data: []byte("-- query: Ping\nSELECT 1;")},
+		"users.sql":         {Data: []byte("-- query: FindUser\nSELECT * FROM user LIMIT 1;")},
+		"users.tsql.sql":    {Data: []byte("-- query: FindUser\nSELECT TOP 1 * FROM user;")},
+		"cats.postgres.sql": {Data: []byte("-- query: FindCat\nSELECT * FROM cat LIMIT 1;")},
+		"cats.mysql.sql":    {Data: []byte("-- query: FindCat\nSELECT * FROM cat LIMIT 1;")},
+	}
+	testCases := []struct {
+		dialect Dialect
+		want    string
+		wantErr error
+	}{
+		{
+			dialect: DialectMySQL,
+			want:    "map[FindCat:SELECT * FROM cat LIMIT 1; FindUser:SELECT * FROM user LIMIT 1; Ping:SELECT 1;]",
+		},
+		{
+			dialect: DialectTSQL,
+			wantErr: fmt.Errorf("%w: none of cats.mysql.sql, cats.postgres.sql matches the dialect tsql", ErrCannotLoadQueries),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			queries, err := LoadFromFS[map[string]string](fsys, WithDialect(tc.dialect))
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if err == nil && fmt.Sprint(*queries) != tc.want {
+				t.Errorf("got %v, want %s", *queries, tc.want)
+			}
+		})
+	}
+	queries, err := LoadFromFS[map[string]string](fstest.MapFS{
+		"users.sql":      {Data: []byte("-- query: FindUser\nSELECT * FROM user LIMIT 1;")},
+		"users.tsql.sql": {Data: []byte("-- query: FindUser\nSELECT TOP 1 * FROM user;")},
+	}, WithDialect(DialectTSQL))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if (*queries)["FindUser"] != "SELECT TOP 1 * FROM user;" {
+		t.Errorf("got %s, want %s", (*queries)["FindUser"], "SELECT TOP 1 * FROM user;")
+	}
+
+	// Without a dialect the engine suffixes mean nothing
+	queries, err = LoadFromFS[map[string]string](fstest.MapFS{
+		"schema.postgres.sql": {Data: []byte("-- query: CreateSchema\nCREATE SCHEMA app;")},
+	})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := "map[CreateSchema:CREATE SCHEMA app;]"; fmt.Sprint(*queries) != want {
+		t.Errorf("got %v, want %s", *queries, want)
+	}
+}
+
+func TestLoadFromFSDialectOverride(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.postgres.sql": {Data: []byte("-- query: FindUser\nSELECT * FROM users WHERE id = $1;")},
+		"users.mysql.sql":    {Data: []byte("-- query: FindUser\nSELECT * FROM users WHERE id = ?;\n-- query: Batch\nSELECT 1; SELECT 2;")},
+	}
+	q, err := LoadFromFS[struct {
+		FindUser       string   `query:"FindUser"`
+		FindLegacyUser string   `query:"FindUser,dialect=mysql"`
+		Batch          []string `query:"Batch,dialect=mysql"`
+	}](fsys, WithDialect(DialectPostgres))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.FindUser != "SELECT * FROM users WHERE id = $1;" {
+		t.Errorf("got %s, want %s", q.FindUser, "SELECT * FROM users WHERE id = $1;")
+	}
+	if q.FindLegacyUser != "SELECT * FROM users WHERE id = ?;" {
+		t.Errorf("got %s, want %s", q.FindLegacyUser, "SELECT * FROM users WHERE id = ?;")
+	}
+	if fmt.Sprint(q.Batch) != "[SELECT 1; SELECT 2;]" {
+		t.Errorf("got %v, want %s", q.Batch, "[SELECT 1; SELECT 2;]")
+	}
+	_, err = LoadFromFS[struct {
+		FindUser string `query:"FindUser,dialect=oracle"`
+	}](fsys, WithDialect(DialectPostgres))
+	want := fmt.Errorf("%w: field FindUser: invalid option dialect=oracle of query tag FindUser,dialect=oracle", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+	_, err = LoadFromFS[struct {
+		FindUser string `query:"FindUser,dialect=sqlite"`
+	}](fsys, WithDialect(DialectPostgres))
+	want = fmt.Errorf("%w: none of users.mysql.sql, users.postgres.sql matches the dialect sqlite", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+	_, err = LoadFromString[struct {
+		FindUser string `query:"FindUser,dialect=mysql"`
+	}]("-- query: FindUser\nSELECT * FROM users WHERE id = $1;", WithDialect(DialectPostgres))
+	want = fmt.Errorf("%w: field FindUser: the dialect of query tag FindUser,dialect=mysql can only be overridden when loading files, see LoadFromFS", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %s, want %s", err, want)
+	}
+}
+
+func TestLoadFromFSLocale(t *testing.T) {
+	fsys := fstest.MapFS{
+		"welcome_email.sql":          {Data: []byte("-- query: WelcomeEmail\nSELECT 'Welcome';")},
+		"welcome_email.de.sql":       {Data: []byte("-- query: WelcomeEmail\nSELECT 'Willkommen';")},
+		"welcome_email.pt_BR.sql":    {Data: []byte("-- query: WelcomeEmail\nSELECT 'Bem-vindo';")},
+		"goodbye_email.sql":          {Data: []byte("-- query: GoodbyeEmail\nSELECT 'Goodbye';")},
+		"goodbye_email.de.sql":       {Data: []byte("-- query: GoodbyeEmail\nSELECT 'Tschuss';")},
+		"goodbye_email.de.mysql.sql": {Data: []byte("-- query: GoodbyeEmail\nSELECT 'Auf Wiedersehen';")},
+		"users.v2.sql":               {Data: []byte("-- query: FindUser\nSELECT * FROM user;")},
+	}
+	testCases := []struct {
+		opts []Option
+		want string
+	}{
+		{
+			opts: []Option{WithLocale("de-AT")},
+			want: "map[FindUser:SELECT * FROM user; GoodbyeEmail:SELECT 'Tschuss'; WelcomeEmail:SELECT 'Willkommen';]",
+		},
+		{
+			opts: []Option{WithLocale("de"), WithDialect(DialectMySQL)},
+			want: "map[FindUser:SELECT * FROM user; GoodbyeEmail:SELECT 'Auf Wiedersehen'; WelcomeEmail:SELECT 'Willkommen';]",
+		},
+		{
+			opts: []Option{WithLocale("pt_BR")},
+			want: "map[FindUser:SELECT * FROM user; GoodbyeEmail:SELECT 'Goodbye'; WelcomeEmail:SELECT 'Bem-vindo';]",
+		},
+		{
+			opts: []Option{WithLocale("fr")},
+			want: "map[FindUser:SELECT * FROM user; GoodbyeEmail:SELECT 'Goodbye'; WelcomeEmail:SELECT 'Welcome';]",
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			queries, err := LoadFromFS[map[string]string](fsys, tc.opts...)
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if fmt.Sprint(*queries) != tc.want {
+				t.Errorf("got %v, want %s", *queries, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromFSEngines(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql":          {Data: []byte("-- query: FindUser\nSELECT * FROM user;")},
+		"users.postgres.sql": {Data: []byte("-- query: FindUser\nSELECT * FROM \"user\";")},
+		"cats.sql":           {Data: []byte("-- query: FindCat\nSELECT * FROM cat;")},
+		"cats.cockroach.sql": {Data: []byte("-- query: FindCat\nSELECT * FROM cat AS OF SYSTEM TIME '-1s';")},
+		"cats.postgres.sql":  {Data: []byte("-- query: FindCat\nSELECT * FROM cat FOR SHARE;")},
+		"dogs.mysql.sql":     {Data: []byte("-- query: FindDog\nSELECT * FROM dog;")},
+		"dogs.cockroach.sql": {Data: []byte("-- query: FindDog\nSELECT * FROM dog AS OF SYSTEM TIME '-1s';")},
+	}
+	queries, err := LoadFromFS[map[string]string](fsys, WithDialect(DialectPostgres), WithEngines("cockroach", "postgres"))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := `map[FindCat:SELECT * FROM cat AS OF SYSTEM TIME '-1s'; FindDog:SELECT * FROM dog AS OF SYSTEM TIME '-1s'; FindUser:SELECT * FROM "user";]`
+	if fmt.Sprint(*queries) != want {
+		t.Errorf("got %v, want %s", *queries, want)
+	}
+	_, err = LoadFromFS[map[string]string](fsys, WithEngines("sqlite"))
+	wantErr := fmt.Errorf("%w: none of dogs.mysql.sql matches the engines sqlite", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %s, want %s", err, wantErr)
+	}
+}
@@ -0,0 +1,66 @@
+package sqload
+
+import "strings"
+
+// hasAnyTag reports whether any of the tags in wanted is in tags, without regard to case.
+func hasAnyTag(tags []string, wanted []string) bool {
+	for _, tag := range wanted {
+		if containsFold(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterByTags returns a new map with the queries kept by the tags included and excluded
+// in cfg, see WithTags and WithoutTags. The variants of the queries that are not kept
+// are not kept either.
+func filterByTags(queries map[string]string, headers map[string]header, cfg *config) map[string]string {
+	keep := func(name string) bool {
+		tags := headers[name].annotations.List("tags")
+		if len(cfg.includeTags) > 0 && !hasAnyTag(tags, cfg.includeTags) {
+			return false
+		}
+		return !hasAnyTag(tags, cfg.excludeTags)
+	}
+	filtered := make(map[string]string, len(queries))
+	for name, sql := range queries {
+		base, _, isVariant := strings.Cut(name, variantSeparator)
+		if keep(name) && (!isVariant || keep(base)) {
+			filtered[name] = sql
+		}
+	}
+	return filtered
+}
+
+// FilterByTag returns a new QuerySet with the queries of qs tagged with any of the tags
+// in tags (see WithTags), and their variants that are tagged the same way.
+//
+//	reports := qs.FilterByTag("reporting")
+//	for _, q := range reports.Queries() {
+//		...
+//	}
+func (qs *QuerySet) FilterByTag(tags ...string) *QuerySet {
+	filtered := &QuerySet{
+		queries:     map[string]Query{},
+		variants:    map[string]map[string]Query{},
+		dialect:     qs.dialect,
+		paramStyles: qs.paramStyles,
+		deprecated:  qs.deprecated,
+	}
+	for name, q := range qs.queries {
+		if !hasAnyTag(q.Tags, tags) {
+			continue
+		}
+		filtered.queries[name] = q
+		for variant, v := range qs.variants[name] {
+			if hasAnyTag(v.Tags, tags) {
+				if filtered.variants[name] == nil {
+					filtered.variants[name] = map[string]Query{}
+				}
+				filtered.variants[name][variant] = v
+			}
+		}
+	}
+	return filtered
+}
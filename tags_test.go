@@ -0,0 +1,94 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+var tagsTestSql = `
+-- query: MonthlyRevenue
+-- tags: reporting, slow
+SELECT SUM(total) FROM invoice;
+
+-- query: MonthlyRevenue variant=fast
+-- tags: reporting
+SELECT total FROM revenue_cache;
+
+-- query: FindInvoice
+-- tags: api
+SELECT id FROM invoice WHERE id = :id;
+
+-- query: PurgeInvoices
+-- tags: batch, slow
+DELETE FROM invoice WHERE paid;
+
+-- query: CountInvoices
+SELECT COUNT(*) FROM invoice;
+`
+
+func TestLoadWithTags(t *testing.T) {
+	testCases := []struct {
+		opts      []Option
+		wantNames []string
+	}{
+		{nil, []string{"CountInvoices", "FindInvoice", "MonthlyRevenue", "PurgeInvoices"}},
+		{[]Option{WithTags("Reporting")}, []string{"MonthlyRevenue"}},
+		{[]Option{WithTags("reporting"), WithTags("api")}, []string{"FindInvoice", "MonthlyRevenue"}},
+		{[]Option{WithoutTags("slow")}, []string{"CountInvoices", "FindInvoice"}},
+		{[]Option{WithTags("slow"), WithoutTags("batch")}, []string{"MonthlyRevenue"}},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			qs, err := LoadQuerySet(fstest.MapFS{"invoice.sql": {Data: []byte(tagsTestSql)}}, testCase.opts...)
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if fmt.Sprint(qs.Names()) != fmt.Sprint(testCase.wantNames) {
+				t.Errorf("got %v, want %v", qs.Names(), testCase.wantNames)
+			}
+		})
+	}
+	qs, err := LoadQuerySet(fstest.MapFS{"invoice.sql": {Data: []byte(tagsTestSql)}}, WithTags("slow"))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if len(qs.Variants("MonthlyRevenue")) != 0 {
+		t.Errorf("got %v, want no variants", qs.Variants("MonthlyRevenue"))
+	}
+	_, err = LoadFromString[struct {
+		FindInvoice string `query:"FindInvoice"`
+	}](tagsTestSql, WithTags("batch"))
+	want := fmt.Errorf("%w: could not find query FindInvoice", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+func TestQuerySetFilterByTag(t *testing.T) {
+	qs, err := LoadQuerySet(fstest.MapFS{"invoice.sql": {Data: []byte(tagsTestSql)}})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	q, _ := qs.Query("MonthlyRevenue")
+	if fmt.Sprint(q.Tags) != "[reporting slow]" {
+		t.Errorf("got %v, want %v", q.Tags, "[reporting slow]")
+	}
+	reports := qs.FilterByTag("reporting")
+	if fmt.Sprint(reports.Names()) != "[MonthlyRevenue]" {
+		t.Errorf("got %v, want %v", reports.Names(), "[MonthlyRevenue]")
+	}
+	if fmt.Sprint(reports.Variants("MonthlyRevenue")) != "[fast]" {
+		t.Errorf("got %v, want %v", reports.Variants("MonthlyRevenue"), "[fast]")
+	}
+	slow := qs.FilterByTag("slow", "api")
+	if fmt.Sprint(slow.Names()) != "[FindInvoice MonthlyRevenue PurgeInvoices]" {
+		t.Errorf("got %v, want %v", slow.Names(), "[FindInvoice MonthlyRevenue PurgeInvoices]")
+	}
+	if len(slow.Variants("MonthlyRevenue")) != 0 {
+		t.Errorf("got %v, want no variants", slow.Variants("MonthlyRevenue"))
+	}
+	if qs.Len() != 4 {
+		t.Errorf("got %d, want %d", qs.Len(), 4)
+	}
+}
@@ -0,0 +1,31 @@
+package sqload
+
+import (
+	"strings"
+	"text/template"
+)
+
+// expandTemplates executes the SQL code of every query as a template using the functions
+// and data configured in cfg, and returns a new map with the results.
+func expandTemplates(queries map[string]string, cfg *config) (map[string]string, error) {
+	funcs := template.FuncMap{
+		"columns": columnsFunc,
+	}
+	for name, fn := range cfg.templateFuncs {
+		funcs[name] = fn
+	}
+	expanded := make(map[string]string, len(queries))
+	for _, name := range sortedKeys(queries) {
+		sql := queries[name]
+		tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(sql)
+		if err != nil {
+			return nil, errorf(CodeExpansionFailed, "%w: query %s: %s", ErrCannotLoadQueries, name, cfg.detail(err))
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, cfg.templateData); err != nil {
+			return nil, errorf(CodeExpansionFailed, "%w: query %s: %s", ErrCannotLoadQueries, name, cfg.detail(err))
+		}
+		expanded[name] = b.String()
+	}
+	return expanded, nil
+}
@@ -0,0 +1,53 @@
+package sqload
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestWithTemplateFuncs(t *testing.T) {
+	type UserQuery struct {
+		FindActiveUsers string `query:"FindActiveUsers"`
+	}
+	sql := strings.TrimSpace(`
+-- query: FindActiveUsers
+SELECT * FROM user WHERE {{notDeleted "user"}} AND role IN ({{inClause 3}});
+`)
+	// Without templates the SQL code is loaded untouched
+	q, err := LoadFromString[UserQuery](sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := `SELECT * FROM user WHERE {{notDeleted "user"}} AND role IN ({{inClause 3}});`
+	if q.FindActiveUsers != want {
+		t.Errorf("got %s, want %s", q.FindActiveUsers, want)
+	}
+	// Functions that were not registered make the load fail
+	_, err = LoadFromString[UserQuery](sql, WithTemplateFuncs(template.FuncMap{
+		"notDeleted": func(table string) string { return table + ".deleted_at IS NULL" },
+	}))
+	if !errors.Is(err, ErrCannotLoadQueries) {
+		t.Fatalf("got %v, want an error wrapping %v", err, ErrCannotLoadQueries)
+	}
+	if !strings.Contains(err.Error(), `function "inClause" not defined`) {
+		t.Errorf("got %s, want an error about inClause", err)
+	}
+	q, err = LoadFromString[UserQuery](
+		sql,
+		WithTemplateFuncs(template.FuncMap{
+			"notDeleted": func(table string) string { return table + ".deleted_at IS NULL" },
+		}),
+		WithTemplateFuncs(template.FuncMap{
+			"inClause": func(n int) string { return strings.TrimSuffix(strings.Repeat("?, ", n), ", ") },
+		}),
+	)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want = "SELECT * FROM user WHERE user.deleted_at IS NULL AND role IN (?, ?, ?);"
+	if q.FindActiveUsers != want {
+		t.Errorf("got %s, want %s", q.FindActiveUsers, want)
+	}
+}
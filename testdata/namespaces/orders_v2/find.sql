@@ -0,0 +1,2 @@
+-- query: FindOrder
+SELECT * FROM "order" WHERE id = :id;
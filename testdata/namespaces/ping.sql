@@ -0,0 +1,2 @@
+-- query: Ping
+SELECT 1;
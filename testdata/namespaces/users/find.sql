@@ -0,0 +1,2 @@
+-- query: FindUser
+SELECT * FROM user WHERE id = :id;
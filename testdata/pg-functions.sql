@@ -0,0 +1,17 @@
+-- query: CreateIncFunction
+-- Creates the inc function.
+CREATE OR REPLACE FUNCTION inc(i integer) RETURNS integer AS $$
+BEGIN
+    -- Increments i by one; nothing else.
+    RETURN i + 1;
+END;
+$$ LANGUAGE plpgsql;
+
+
+-- query: NotifyCats
+DO $body$
+BEGIN
+    -- Say hello to the cats.
+    RAISE NOTICE 'meow';
+END
+$body$;
@@ -0,0 +1,31 @@
+package sqload
+
+// Transform is a function applied to every query at load time, see WithTransform. It
+// receives the name of a query and its SQL code and returns the new SQL code. The
+// variants of a query are named Name:variant (see QuerySet.Select).
+//
+// If it returns an error, the load fails.
+type Transform func(name, sql string) (string, error)
+
+// applyTransforms applies the transforms configured in cfg to every query, and returns a
+// new map with the results. The queries are transformed in order of name, so the first
+// error is always the same.
+func applyTransforms(queries map[string]string, cfg *config) (map[string]string, error) {
+	if len(cfg.transforms) == 0 {
+		return queries, nil
+	}
+	names := sortedKeys(queries)
+	transformed := make(map[string]string, len(queries))
+	for _, name := range names {
+		sql := queries[name]
+		for _, transform := range cfg.transforms {
+			var err error
+			sql, err = transform(name, sql)
+			if err != nil {
+				return nil, errorf(CodeExpansionFailed, "%w: query %s: %s", ErrCannotLoadQueries, name, cfg.detail(err))
+			}
+		}
+		transformed[name] = sql
+	}
+	return transformed, nil
+}
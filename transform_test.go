@@ -0,0 +1,54 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLoadWithTransform(t *testing.T) {
+	sql := `
+-- query: FindCat
+SELECT * FROM cat WHERE id = :id;
+
+-- query: DeleteCat
+DELETE FROM cat WHERE id = :id;
+`
+	tagQuery := func(name, sql string) (string, error) {
+		return "/* " + name + " */ " + sql, nil
+	}
+	qualify := func(name, sql string) (string, error) {
+		return strings.ReplaceAll(sql, " cat ", " pets.cat "), nil
+	}
+	q, err := LoadFromString[struct {
+		FindCat   string `query:"FindCat"`
+		DeleteCat string `query:"DeleteCat"`
+	}](sql, WithTransform(qualify), WithTransform(tagQuery))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := "/* FindCat */ SELECT * FROM pets.cat WHERE id = :id;"; q.FindCat != want {
+		t.Errorf("got %s, want %s", q.FindCat, want)
+	}
+	if want := "/* DeleteCat */ DELETE FROM pets.cat WHERE id = :id;"; q.DeleteCat != want {
+		t.Errorf("got %s, want %s", q.DeleteCat, want)
+	}
+
+	forbidDelete := func(name, sql string) (string, error) {
+		if strings.HasPrefix(sql, "DELETE") {
+			return "", errors.New("DELETE is forbidden")
+		}
+		return sql, nil
+	}
+	_, err = LoadFromString[struct {
+		FindCat string `query:"FindCat"`
+	}](sql, WithTransform(forbidDelete))
+	want := fmt.Errorf("%w: query DeleteCat: DELETE is forbidden", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(want) {
+		t.Errorf("got %v, want %s", err, want)
+	}
+	if !errors.Is(err, ErrCannotLoadQueries) {
+		t.Errorf("err must be ErrCannotLoadQueries, got %s", err)
+	}
+}
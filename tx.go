@@ -0,0 +1,266 @@
+package sqload
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// txComment is the prefix of the transaction comments, which start the transaction
+// scripts. Like a fixture block, a transaction script ends the query before it.
+const txComment = "-- tx:"
+
+// TxBeginner is the interface used to start transactions. It is implemented by *sql.DB
+// and *sql.Conn.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// TxScript is a transaction script: a named group of queries, its steps, that are run in
+// order in a single transaction, see TxScript.Run. It is written in the .sql files next
+// to the queries, in a transaction comment listing the names of its steps, followed by
+// its annotations:
+//
+//	-- tx: OnboardUser steps=CreateUser,CreateWallet,SendWelcome
+//	-- savepoint: SendWelcome
+//	-- isolation: serializable
+//
+//	-- query: CreateUser
+//	INSERT INTO user (id, email) VALUES (:user_id, :email);
+//
+// The steps listed in the savepoint annotation run after a savepoint, so they can fail
+// without failing the whole script, see TxScript.Run. The isolation annotation sets the
+// isolation level of the transaction, see ParseIsolation.
+type TxScript struct {
+	// Name is the name of the script, as written in its transaction comment.
+	Name string
+	// Steps are the names of the queries run by the script, in order.
+	Steps []string
+	// Annotations are the annotations written after the transaction comment.
+	Annotations Annotations
+
+	file string // file the script was read from
+	line int    // line of the transaction comment in file
+}
+
+// LoadTxScripts returns the transaction scripts of the .sql files of the fsys file system
+// (recursively), by name. The files are chosen and read as the Load functions do with the
+// options opts, so the preprocessors and WithSkipUnreadable apply to them.
+//
+// If a script has an invalid name or steps, or a name is used twice, it will return an
+// error. The steps are only looked up when the script runs.
+func LoadTxScripts(fsys fs.FS, opts ...Option) (map[string]TxScript, error) {
+	cfg := newConfig(opts)
+	files, err := findQueryFiles(fsys, cfg)
+	if err != nil {
+		return nil, err
+	}
+	scripts := map[string]TxScript{}
+	for _, filename := range files {
+		sql, err := readFile(fsys, filename, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := parseTxScripts(sql, filename, cfg.dialect, scripts); err != nil {
+			return nil, err
+		}
+	}
+	return scripts, nil
+}
+
+// parseTxScripts adds the transaction scripts of the SQL code sql, the contents of the
+// file filename written in dialect d, to scripts. The transaction comments are found with
+// BlockComments, so the ones inside string literals are part of the queries.
+func parseTxScripts(sql, filename string, d Dialect, scripts map[string]TxScript) error {
+	if !strings.Contains(sql, txComment) {
+		return nil
+	}
+	sql = strings.ReplaceAll(sql, "\r\n", "\n")
+	blocks := BlockComments(sql, d)
+	for i, start := range blocks {
+		if !strings.HasPrefix(sql[start:], txComment) {
+			continue
+		}
+		end := len(sql)
+		if i+1 < len(blocks) {
+			end = blocks[i+1]
+		}
+		if next := strings.Index(sql[start:end], queryComment); next != -1 {
+			end = start + next
+		}
+		line := strings.Count(sql[:start], "\n") + 1
+		txLine, header, _ := strings.Cut(sql[start:end], "\n")
+		tx, err := parseTxComment(strings.TrimPrefix(txLine, txComment))
+		if err != nil {
+			return fmt.Errorf("%w (%s:%d)", err, filename, line)
+		}
+		if previous, found := scripts[tx.Name]; found {
+			return errorf(CodeConflict, "%w: transaction %s is defined twice (%s:%d and %s:%d)", ErrCannotLoadQueries, tx.Name, previous.file, previous.line, filename, line)
+		}
+		tx.Annotations = parseHeader(header).annotations
+		for _, step := range tx.Annotations.List("savepoint") {
+			if !containsString(tx.Steps, step) {
+				return errorf(CodeInvalidAnnotation, "%w: transaction %s: savepoint %s is not a step (%s:%d)", ErrCannotLoadQueries, tx.Name, step, filename, line)
+			}
+		}
+		if isolation := tx.Annotations.Get("isolation"); isolation != "" {
+			if _, err := ParseIsolation(isolation); err != nil {
+				return errorf(CodeInvalidAnnotation, "%w: transaction %s: %s (%s:%d)", ErrCannotLoadQueries, tx.Name, err, filename, line)
+			}
+		}
+		tx.file, tx.line = filename, line
+		scripts[tx.Name] = tx
+	}
+	return nil
+}
+
+// parseTxComment parses the line of a transaction comment that follows "-- tx:", made of
+// the name of the script and its steps attribute.
+func parseTxComment(line string) (TxScript, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !validQueryNamePattern.MatchString(fields[0]) {
+		return TxScript{}, errorf(CodeInvalidName, "%w: invalid transaction name %s", ErrCannotLoadQueries, strings.TrimSpace(line))
+	}
+	tx := TxScript{Name: fields[0]}
+	for _, attr := range fields[1:] {
+		key, value, _ := strings.Cut(attr, "=")
+		if key != "steps" || tx.Steps != nil {
+			return TxScript{}, errorf(CodeInvalidAnnotation, "%w: transaction %s: invalid attribute %s", ErrCannotLoadQueries, tx.Name, attr)
+		}
+		tx.Steps = strings.Split(value, ",")
+		for _, step := range tx.Steps {
+			if !validQueryNamePattern.MatchString(step) {
+				return TxScript{}, errorf(CodeInvalidName, "%w: transaction %s: invalid step %s", ErrCannotLoadQueries, tx.Name, step)
+			}
+		}
+	}
+	if len(tx.Steps) == 0 {
+		return TxScript{}, errorf(CodeInvalidAnnotation, "%w: transaction %s has no steps", ErrCannotLoadQueries, tx.Name)
+	}
+	return tx, nil
+}
+
+// Run runs the steps of the script, the queries of the QuerySet qs, in order in a single
+// transaction started on db, and commits it. The steps are bound with args, which holds
+// the values of the parameters of all of them (see QuerySet.Bind). If any step fails, the
+// transaction is rolled back and the error names the step:
+//
+//	scripts, err := sqload.LoadTxScripts(fsys)
+//	...
+//	err = scripts["OnboardUser"].Run(ctx, db, qs, map[string]any{"user_id": id, "email": email})
+//
+// All the steps are bound before the transaction starts, so a missing query or parameter
+// does not open a transaction.
+//
+// The steps listed in the savepoint annotation of the script run after a savepoint,
+// written in the dialect of qs. If one of them fails, the transaction is rolled back to
+// its savepoint and the next steps run; the transaction is committed and the steps
+// rolled back are returned in a *PartialTxError.
+//
+// The transaction runs with the isolation level written in the isolation annotation of
+// the script or, if it has none, with the strictest one written in the isolation
+// annotations of its steps, see Query.TxOptions.
+func (tx TxScript) Run(ctx context.Context, db TxBeginner, qs *QuerySet, args map[string]any) error {
+	type statement struct {
+		sql  string
+		args []any
+	}
+	statements := make([]statement, 0, len(tx.Steps))
+	for _, step := range tx.Steps {
+		sql, stepArgs, err := qs.Bind(step, args)
+		if err != nil {
+			return fmt.Errorf("transaction %s: step %s: %w", tx.Name, step, err)
+		}
+		statements = append(statements, statement{sql, stepArgs})
+	}
+	t, err := db.BeginTx(ctx, tx.txOptions(qs))
+	if err != nil {
+		return fmt.Errorf("transaction %s: %w", tx.Name, err)
+	}
+	savepoints := tx.Annotations.List("savepoint")
+	var partial *PartialTxError
+	for i, s := range statements {
+		step := tx.Steps[i]
+		var sp savepoint
+		if containsString(savepoints, step) {
+			sp = newSavepoint(fmt.Sprintf("sqload_%d", i+1), qs.dialect)
+		}
+		err := sp.exec(ctx, t, sp.create)
+		if err == nil {
+			_, err = t.ExecContext(ctx, s.sql, s.args...)
+		}
+		if err != nil && sp.create != "" {
+			if rollbackErr := sp.exec(ctx, t, sp.rollback); rollbackErr == nil {
+				if partial == nil {
+					partial = &PartialTxError{Name: tx.Name}
+				}
+				partial.Steps = append(partial.Steps, step)
+				partial.Errs = append(partial.Errs, err)
+				continue
+			}
+		}
+		if err == nil {
+			err = sp.exec(ctx, t, sp.release)
+		}
+		if err != nil {
+			t.Rollback()
+			return fmt.Errorf("transaction %s: step %s: %w", tx.Name, step, err)
+		}
+	}
+	if err := t.Commit(); err != nil {
+		return fmt.Errorf("transaction %s: %w", tx.Name, err)
+	}
+	if partial != nil {
+		return partial
+	}
+	return nil
+}
+
+// PartialTxError is the error returned by TxScript.Run when the transaction is committed
+// but some of the steps of the script failed and were rolled back to their savepoints.
+type PartialTxError struct {
+	// Name is the name of the script.
+	Name string
+	// Steps are the names of the steps rolled back, in the order they ran.
+	Steps []string
+	// Errs are the errors of the steps rolled back, in the same order.
+	Errs []error
+}
+
+func (e *PartialTxError) Error() string {
+	failed := make([]string, len(e.Steps))
+	for i, step := range e.Steps {
+		failed[i] = fmt.Sprintf("%s (%s)", step, e.Errs[i])
+	}
+	return fmt.Sprintf("transaction %s: rolled back steps %s", e.Name, strings.Join(failed, ", "))
+}
+
+// savepoint holds the statements that create a savepoint, roll back to it and release
+// it. The zero savepoint has no statements.
+type savepoint struct {
+	create, rollback, release string
+}
+
+// newSavepoint returns the statements of the savepoint name in dialect d. SQL Server has
+// no statement to release a savepoint.
+func newSavepoint(name string, d Dialect) savepoint {
+	if d == DialectTSQL {
+		return savepoint{create: "SAVE TRANSACTION " + name, rollback: "ROLLBACK TRANSACTION " + name}
+	}
+	return savepoint{
+		create:   "SAVEPOINT " + name,
+		rollback: "ROLLBACK TO SAVEPOINT " + name,
+		release:  "RELEASE SAVEPOINT " + name,
+	}
+}
+
+// exec runs the statement statement of the savepoint on t, if it is not empty.
+func (sp savepoint) exec(ctx context.Context, t *sql.Tx, statement string) error {
+	if statement == "" {
+		return nil
+	}
+	_, err := t.ExecContext(ctx, statement)
+	return err
+}
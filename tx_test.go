@@ -0,0 +1,279 @@
+package sqload
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadTxScripts(t *testing.T) {
+	testCases := []struct {
+		sql     string
+		want    string
+		wantErr error
+	}{
+		{
+			sql: `-- tx: OnboardUser steps=CreateUser,CreateWallet
+-- isolation: serializable
+
+-- query: CreateUser
+INSERT INTO user (id) VALUES (:user_id);
+`,
+			want: "map[OnboardUser:{OnboardUser [CreateUser CreateWallet] map[isolation:[serializable]] users.sql 1}]",
+		},
+		{
+			sql:  "-- query: Ping\nSELECT 1;\n",
+			want: "map[]",
+		},
+		{
+			sql:  "-- query: FindPending\nSELECT id,\n  '-- tx: pending' AS label\n  FROM job;\n",
+			want: "map[]",
+		},
+		{
+			sql:     "-- tx: Onboard-User steps=CreateUser\n",
+			wantErr: fmt.Errorf("%w: invalid transaction name Onboard-User steps=CreateUser (users.sql:1)", ErrCannotLoadQueries),
+		},
+		{
+			sql:     "-- query: Ping\nSELECT 1;\n\n-- tx: OnboardUser\n",
+			wantErr: fmt.Errorf("%w: transaction OnboardUser has no steps (users.sql:4)", ErrCannotLoadQueries),
+		},
+		{
+			sql:     "-- tx: OnboardUser steps=CreateUser,\n",
+			wantErr: fmt.Errorf("%w: transaction OnboardUser: invalid step  (users.sql:1)", ErrCannotLoadQueries),
+		},
+		{
+			sql:     "-- tx: OnboardUser steps=CreateUser timeout=2s\n",
+			wantErr: fmt.Errorf("%w: transaction OnboardUser: invalid attribute timeout=2s (users.sql:1)", ErrCannotLoadQueries),
+		},
+		{
+			sql:     "-- tx: OnboardUser steps=CreateUser\n-- tx: OnboardUser steps=CreateWallet\n",
+			wantErr: fmt.Errorf("%w: transaction OnboardUser is defined twice (users.sql:1 and users.sql:2)", ErrCannotLoadQueries),
+		},
+		{
+			sql:     "-- tx: OnboardUser steps=CreateUser\n-- isolation: eventual\n",
+			wantErr: fmt.Errorf("%w: transaction OnboardUser: invalid isolation level eventual (users.sql:1)", ErrCannotLoadQueries),
+		},
+		{
+			sql:     "-- tx: OnboardUser steps=CreateUser\n-- savepoint: SendWelcome\n",
+			wantErr: fmt.Errorf("%w: transaction OnboardUser: savepoint SendWelcome is not a step (users.sql:1)", ErrCannotLoadQueries),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			scripts, err := LoadTxScripts(fstest.MapFS{"users.sql": {Data: []byte(tc.sql)}})
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if err == nil && fmt.Sprint(scripts) != tc.want {
+				t.Errorf("got %v, want %s", scripts, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadTxScriptsOptions(t *testing.T) {
+	bundle := fstest.MapFS{"users.sql": {Data: []byte("-- tx: OnboardUser steps={{steps}}\n")}}
+	scripts, err := LoadTxScripts(bundle, WithPreprocessor(func(path string, data []byte) ([]byte, error) {
+		return bytes.ReplaceAll(data, []byte("{{steps}}"), []byte("CreateUser,CreateWallet")), nil
+	}))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if got, want := fmt.Sprint(scripts["OnboardUser"].Steps), "[CreateUser CreateWallet]"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	// Permission-based tests do not work on Windows
+	if runtime.GOOS == "windows" {
+		t.Skip("permissions are not supported")
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "users.sql"), []byte("-- tx: OnboardUser steps=CreateUser\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.sql"), []byte("-- tx: RotateKeys steps=RotateKey\n"), 0o222); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTxScripts(os.DirFS(dir)); err == nil {
+		t.Fatal("err is nil")
+	}
+	scripts, err = LoadTxScripts(os.DirFS(dir), WithSkipUnreadable())
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if len(scripts) != 1 || scripts["OnboardUser"].Name != "OnboardUser" {
+		t.Errorf("got %v, want only OnboardUser", scripts)
+	}
+}
+
+func TestParseTxBlocks(t *testing.T) {
+	sql := `-- query: CreateUser
+INSERT INTO user (id) VALUES (:user_id);
+
+-- tx: OnboardUser steps=CreateUser
+-- isolation: serializable
+-- query: Ping
+SELECT 1;
+`
+	queries, err := ExtractQueryMap(sql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "map[CreateUser:INSERT INTO user (id) VALUES (:user_id); Ping:SELECT 1;]"
+	if fmt.Sprint(queries) != want {
+		t.Errorf("got %v, want %s", queries, want)
+	}
+
+	queries, err = ExtractQueryMap("-- query: FindPending\nSELECT id,\n  '-- tx: pending' AS label\n  FROM job;\n")
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := "SELECT id,\n  '-- tx: pending' AS label\n  FROM job;"; queries["FindPending"] != want {
+		t.Errorf("got %q, want %q", queries["FindPending"], want)
+	}
+}
+
+func TestTxScriptRun(t *testing.T) {
+	fsys := fstest.MapFS{"users.sql": {Data: []byte(`-- tx: OnboardUser steps=CreateUser,CreateWallet
+-- tx: BrokenOnboarding steps=CreateUser,FailToCreateWallet
+-- tx: MissingStep steps=CreateUser,SendWelcome
+-- tx: LenientOnboarding steps=CreateUser,FailToCreateWallet,CreateWallet
+-- savepoint: FailToCreateWallet, CreateWallet
+-- tx: SafeOnboarding steps=CreateUser,CreateWallet
+-- isolation: repeatable-read
+-- tx: LockedOnboarding steps=CreateUser,LockWallet,CreateWallet
+
+-- query: CreateUser
+INSERT INTO user (id) VALUES (:user_id);
+
+-- query: CreateWallet
+INSERT INTO wallet (user_id) VALUES (:user_id);
+
+-- query: LockWallet
+-- isolation: serializable
+SELECT * FROM wallet WHERE user_id = :user_id FOR UPDATE;
+
+-- query: FailToCreateWallet
+INSERT INTO FAIL (user_id) VALUES (:user_id);
+`)}}
+	qs, err := LoadQuerySet(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	scripts, err := LoadTxScripts(fsys)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		script  string
+		args    map[string]any
+		wantLog []string
+		wantErr error
+	}{
+		{
+			script: "OnboardUser",
+			args:   map[string]any{"user_id": 1},
+			wantLog: []string{
+				"1: BEGIN",
+				"1: INSERT INTO user (id) VALUES (?);",
+				"1: INSERT INTO wallet (user_id) VALUES (?);",
+				"1: COMMIT",
+			},
+		},
+		{
+			script: "BrokenOnboarding",
+			args:   map[string]any{"user_id": 1},
+			wantLog: []string{
+				"1: BEGIN",
+				"1: INSERT INTO user (id) VALUES (?);",
+				"1: INSERT INTO FAIL (user_id) VALUES (?);",
+				"1: ROLLBACK",
+			},
+			wantErr: fmt.Errorf("transaction BrokenOnboarding: step FailToCreateWallet: statement failed"),
+		},
+		{
+			script: "LenientOnboarding",
+			args:   map[string]any{"user_id": 1},
+			wantLog: []string{
+				"1: BEGIN",
+				"1: INSERT INTO user (id) VALUES (?);",
+				"1: SAVEPOINT sqload_2",
+				"1: INSERT INTO FAIL (user_id) VALUES (?);",
+				"1: ROLLBACK TO SAVEPOINT sqload_2",
+				"1: SAVEPOINT sqload_3",
+				"1: INSERT INTO wallet (user_id) VALUES (?);",
+				"1: RELEASE SAVEPOINT sqload_3",
+				"1: COMMIT",
+			},
+			wantErr: fmt.Errorf("transaction LenientOnboarding: rolled back steps FailToCreateWallet (statement failed)"),
+		},
+		{
+			script: "SafeOnboarding",
+			args:   map[string]any{"user_id": 1},
+			wantLog: []string{
+				"1: BEGIN ISOLATION LEVEL Repeatable Read",
+				"1: INSERT INTO user (id) VALUES (?);",
+				"1: INSERT INTO wallet (user_id) VALUES (?);",
+				"1: COMMIT",
+			},
+		},
+		{
+			script: "LockedOnboarding",
+			args:   map[string]any{"user_id": 1},
+			wantLog: []string{
+				"1: BEGIN ISOLATION LEVEL Serializable",
+				"1: INSERT INTO user (id) VALUES (?);",
+				"1: SELECT * FROM wallet WHERE user_id = ? FOR UPDATE;",
+				"1: INSERT INTO wallet (user_id) VALUES (?);",
+				"1: COMMIT",
+			},
+		},
+		{
+			script:  "MissingStep",
+			args:    map[string]any{"user_id": 1},
+			wantLog: []string{},
+			wantErr: fmt.Errorf("transaction MissingStep: step SendWelcome: could not find query SendWelcome"),
+		},
+		{
+			script:  "OnboardUser",
+			args:    map[string]any{},
+			wantLog: []string{},
+			wantErr: fmt.Errorf("transaction OnboardUser: step CreateUser: missing value for parameter user_id"),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			d, db := openFakeDB(t)
+			err := scripts[tc.script].Run(context.Background(), db, qs, tc.args)
+			if fmt.Sprint(err) != fmt.Sprint(tc.wantErr) {
+				t.Fatalf("got %s, want %s", err, tc.wantErr)
+			}
+			if fmt.Sprintf("%q", d.Log()) != fmt.Sprintf("%q", tc.wantLog) {
+				t.Errorf("got %q, want %q", d.Log(), tc.wantLog)
+			}
+		})
+	}
+}
+
+func TestNewSavepoint(t *testing.T) {
+	testCases := []struct {
+		d    Dialect
+		want savepoint
+	}{
+		{DialectPostgres, savepoint{"SAVEPOINT sp", "ROLLBACK TO SAVEPOINT sp", "RELEASE SAVEPOINT sp"}},
+		{DialectMySQL, savepoint{"SAVEPOINT sp", "ROLLBACK TO SAVEPOINT sp", "RELEASE SAVEPOINT sp"}},
+		{DialectTSQL, savepoint{"SAVE TRANSACTION sp", "ROLLBACK TRANSACTION sp", ""}},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if got := newSavepoint("sp", tc.d); got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
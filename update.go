@@ -0,0 +1,115 @@
+package sqload
+
+import (
+	"io/fs"
+	"path"
+)
+
+// source holds the queries parsed from the .sql files of a file system, file by file, so
+// some of the files can be parsed again without parsing the others, see QuerySet.Update.
+type source struct {
+	files   []string // files loaded, in the order they are read
+	queries map[string]map[string]string
+	headers map[string]map[string]header
+}
+
+// parseSource parses the .sql files of the fsys file system that must be loaded with the
+// configuration cfg. The files already parsed in previous, if it is not nil, are taken
+// from it unless they are in changed.
+func parseSource(fsys fs.FS, cfg *config, previous *source, changed map[string]bool) (*source, error) {
+	files, err := findQueryFiles(fsys, cfg)
+	if err != nil {
+		return nil, err
+	}
+	src := &source{
+		files:   files,
+		queries: make(map[string]map[string]string, len(files)),
+		headers: make(map[string]map[string]header, len(files)),
+	}
+	for _, filename := range files {
+		if previous != nil && !changed[filename] {
+			if queries, found := previous.queries[filename]; found {
+				src.queries[filename], src.headers[filename] = queries, previous.headers[filename]
+				continue
+			}
+		}
+		queries, headers, err := parseFile(fsys, filename, cfg)
+		if err != nil {
+			return nil, err
+		}
+		src.queries[filename], src.headers[filename] = queries, headers
+	}
+	return src, nil
+}
+
+// merge returns the queries of all the files of src and their headers. The queries of
+// each file go after the ones of the previous files in the order of the queries, and
+// they replace the queries of the previous files with the same names; the replaced
+// queries are added to the report of cfg, if any. If any query has variants but no
+// default version, it will return an error.
+func (src *source) merge(cfg *config) (map[string]string, map[string]header, error) {
+	queries := make(map[string]string)
+	headers := make(map[string]header)
+	definedIn := make(map[string][]string)
+	offset := 0
+	for _, filename := range src.files {
+		next := offset
+		for name, sql := range src.queries[filename] {
+			h := src.headers[filename][name]
+			h.order += offset
+			if h.order >= next {
+				next = h.order + 1
+			}
+			queries[name] = sql
+			headers[name] = h
+			definedIn[name] = append(definedIn[name], filename)
+		}
+		offset = next
+	}
+	if err := checkVariants(queries); err != nil {
+		return nil, nil, err
+	}
+	if cfg.report != nil {
+		cfg.report.addShadowed(definedIn)
+	}
+	return queries, headers, nil
+}
+
+// Update returns a new QuerySet with the queries of qs, after parsing again the files
+// of fsys in changedPaths, which are slash-separated paths relative to the root of fsys.
+// The other files are not read again, so a program can reload a large tree of .sql files
+// cheaply every time a few of them change, for example from a file watcher. Added and
+// removed files are detected, as long as the added ones are in changedPaths. The options
+// the set was loaded with are applied again.
+//
+//	qs, err = qs.Update(fsys, []string{"users/find.sql"})
+//	if err != nil {
+//		log.Print(err) // keep serving the previous set
+//	}
+//
+// Only the sets returned by LoadQuerySet and by Update can be updated; for the others,
+// it will return an error. If any file can not be read or parsed, it will return an
+// error and qs is left as it was. If qs was loaded with WithPublish, the updated queries
+// are published too.
+func (qs *QuerySet) Update(fsys fs.FS, changedPaths []string) (*QuerySet, error) {
+	if qs.src == nil {
+		return nil, errorf(CodeBadTarget, "%w: the query set was not loaded from a file system", ErrCannotLoadQueries)
+	}
+	changed := make(map[string]bool, len(changedPaths))
+	for _, p := range changedPaths {
+		changed[path.Clean(p)] = true
+	}
+	cfg := *qs.cfg
+	src, err := parseSource(fsys, &cfg, qs.src, changed)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := newSourceQuerySet(src, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.publish {
+		updated.publish()
+	}
+	return updated, nil
+}
@@ -0,0 +1,74 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+func TestQuerySetUpdate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.sql":  {Data: []byte("-- query: FindUser\nSELECT * FROM user WHERE id = :id;\n")},
+		"orders.sql": {Data: []byte("-- query: FindOrder\nSELECT * FROM \"order\" WHERE id = :id;\n")},
+	}
+	reads := map[string]int{}
+	counter := WithPreprocessor(func(path string, data []byte) ([]byte, error) {
+		reads[path]++
+		return data, nil
+	})
+	qs, err := LoadQuerySet(fsys, counter)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+
+	fsys["users.sql"] = &fstest.MapFile{Data: []byte("-- query: FindUser\nSELECT id FROM user WHERE id = :id;\n\n-- query: DeleteUser\nDELETE FROM user WHERE id = :id;\n")}
+	fsys["invoices.sql"] = &fstest.MapFile{Data: []byte("-- query: FindInvoice\nSELECT * FROM invoice;\n")}
+	delete(fsys, "orders.sql")
+	updated, err := qs.Update(fsys, []string{"./users.sql", "invoices.sql", "orders.sql"})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	wantedNames := []string{"DeleteUser", "FindInvoice", "FindUser"}
+	if fmt.Sprint(updated.Names()) != fmt.Sprint(wantedNames) {
+		t.Errorf("got %v, want %v", updated.Names(), wantedNames)
+	}
+	if sql, _ := updated.Get("FindUser"); sql != "SELECT id FROM user WHERE id = :id;" {
+		t.Errorf("got %s, want %s", sql, "SELECT id FROM user WHERE id = :id;")
+	}
+	if sql, _ := qs.Get("FindUser"); sql != "SELECT * FROM user WHERE id = :id;" {
+		t.Errorf("the updated set must not change, got %s", sql)
+	}
+	wantedOrder := []string{"FindInvoice", "FindUser", "DeleteUser"}
+	var order []string
+	for _, q := range updated.InOrder() {
+		order = append(order, q.Name)
+	}
+	if fmt.Sprint(order) != fmt.Sprint(wantedOrder) {
+		t.Errorf("got %v, want %v", order, wantedOrder)
+	}
+
+	fsys["invoices.sql"] = &fstest.MapFile{Data: []byte("-- query: FindInvoice\nSELECT id FROM invoice;\n")}
+	updated, err = updated.Update(fsys, nil)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if sql, _ := updated.Get("FindInvoice"); sql != "SELECT * FROM invoice;" {
+		t.Errorf("the files not changed must not be read again, got %s", sql)
+	}
+	if want := "map[invoices.sql:1 orders.sql:1 users.sql:2]"; fmt.Sprint(reads) != want {
+		t.Errorf("got %v, want %s", reads, want)
+	}
+
+	fsys["users.sql"] = &fstest.MapFile{Data: []byte("-- query: find-user\nSELECT 1;\n")}
+	_, err = updated.Update(fsys, []string{"users.sql"})
+	wantErr := fmt.Errorf("%w: invalid query name find-user (users.sql:1)", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %s, want %s", err, wantErr)
+	}
+	filtered := updated.FilterByTag("users")
+	_, err = filtered.Update(fsys, []string{"users.sql"})
+	wantErr = fmt.Errorf("%w: the query set was not loaded from a file system", ErrCannotLoadQueries)
+	if fmt.Sprint(err) != fmt.Sprint(wantErr) {
+		t.Errorf("got %s, want %s", err, wantErr)
+	}
+}
@@ -0,0 +1,190 @@
+package sqload
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var upsertClausePattern = regexp.MustCompile(`(?i)(conflict|update|returning)[ \t]*\(([^)]*)\)`)
+
+// upsert is a parsed upsert annotation.
+type upsert struct {
+	conflict  []string
+	update    []string
+	returning []string
+}
+
+// parseUpsert parses the value of an upsert annotation.
+func parseUpsert(annotation string) (upsert, error) {
+	u := upsert{}
+	rest := upsertClausePattern.ReplaceAllStringFunc(annotation, func(clause string) string {
+		match := upsertClausePattern.FindStringSubmatch(clause)
+		items := []string{}
+		for _, item := range strings.Split(match[2], ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		switch strings.ToLower(match[1]) {
+		case "conflict":
+			u.conflict = items
+		case "update":
+			u.update = items
+		case "returning":
+			u.returning = items
+		}
+		return ""
+	})
+	if strings.TrimSpace(rest) != "" || len(u.conflict) == 0 {
+		return upsert{}, fmt.Errorf("invalid upsert annotation %s", annotation)
+	}
+	return u, nil
+}
+
+// splitInsert splits the SQL code of an INSERT INTO table (columns) VALUES ... statement
+// into its table, its columns and the code that follows VALUES.
+func splitInsert(sql string, d Dialect) (string, []string, string, error) {
+	tokens := []token{}
+	for _, tok := range tokenize(sql, d) {
+		if !tok.isTrivia() {
+			tokens = append(tokens, tok)
+		}
+	}
+	if len(tokens) < 2 || !strings.EqualFold(tokens[0].text(sql), "INSERT") || !strings.EqualFold(tokens[1].text(sql), "INTO") {
+		return "", nil, "", fmt.Errorf("not an INSERT INTO statement")
+	}
+	i := 2
+	for i < len(tokens) && tokens[i].text(sql) != "(" && !strings.EqualFold(tokens[i].text(sql), "VALUES") {
+		i++
+	}
+	if i == 2 || i == len(tokens) || tokens[i].text(sql) != "(" {
+		return "", nil, "", fmt.Errorf("the INSERT statement has no column list")
+	}
+	table := strings.TrimSpace(sql[tokens[2].start:tokens[i-1].end])
+	columns := []string{}
+	column := []string{}
+	for i++; i < len(tokens) && tokens[i].text(sql) != ")"; i++ {
+		if tokens[i].text(sql) == "," {
+			columns = append(columns, strings.Join(column, ""))
+			column = nil
+			continue
+		}
+		column = append(column, tokens[i].text(sql))
+	}
+	columns = append(columns, strings.Join(column, ""))
+	i++
+	if i >= len(tokens) || !strings.EqualFold(tokens[i].text(sql), "VALUES") {
+		return "", nil, "", fmt.Errorf("the INSERT statement has no VALUES clause")
+	}
+	return table, columns, strings.TrimSpace(sql[tokens[i].end:]), nil
+}
+
+// ExpandUpsert turns the INSERT statement sql into an upsert written in dialect d, as
+// described by the value of an upsert annotation:
+//
+//	conflict(columns) [update(columns)] [returning(columns)]
+//
+// The conflict columns identify the row, the update columns are set to the inserted
+// values when the row exists (if there are none the existing row is left untouched), and
+// the returning columns are returned by the statement. It uses ON CONFLICT for
+// DialectPostgres and DialectSQLite, ON DUPLICATE KEY UPDATE for DialectMySQL, which
+// does not support returning columns, and MERGE for DialectTSQL. DialectGeneric is not
+// supported.
+//
+//	sqload.ExpandUpsert("INSERT INTO cat (id, name) VALUES (:id, :name);", sqload.DialectPostgres, "conflict(id) update(name)")
+//	// INSERT INTO cat (id, name) VALUES (:id, :name) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name;
+//
+// The Load functions expand the queries annotated with upsert using the dialect set with
+// WithDialect, so a single version of each upsert can be kept for all the databases:
+//
+//	-- query: SaveCat
+//	-- upsert: conflict(id) update(name, color)
+//	INSERT INTO cat (id, name, color) VALUES (:id, :name, :color);
+func ExpandUpsert(sql string, d Dialect, annotation string) (string, error) {
+	u, err := parseUpsert(annotation)
+	if err != nil {
+		return "", err
+	}
+	code, terminator := cutTerminator(sql, d)
+	switch d {
+	case DialectPostgres, DialectSQLite:
+		code += " ON CONFLICT (" + strings.Join(u.conflict, ", ") + ")"
+		if len(u.update) == 0 {
+			code += " DO NOTHING"
+		} else {
+			code += " DO UPDATE SET " + joinAssignments(u.update, "EXCLUDED.%s")
+		}
+		if len(u.returning) > 0 {
+			code += " RETURNING " + strings.Join(u.returning, ", ")
+		}
+		return code + terminator, nil
+	case DialectMySQL:
+		if len(u.returning) > 0 {
+			return "", fmt.Errorf("dialect %s does not support returning columns from an upsert", d)
+		}
+		if len(u.update) == 0 {
+			// Setting a conflict column to itself leaves the existing row untouched
+			return code + fmt.Sprintf(" ON DUPLICATE KEY UPDATE %s = %s", u.conflict[0], u.conflict[0]) + terminator, nil
+		}
+		return code + " ON DUPLICATE KEY UPDATE " + joinAssignments(u.update, "VALUES(%s)") + terminator, nil
+	case DialectTSQL:
+		table, columns, values, err := splitInsert(code, d)
+		if err != nil {
+			return "", err
+		}
+		on := make([]string, len(u.conflict))
+		for i, column := range u.conflict {
+			on[i] = fmt.Sprintf("target.%s = source.%s", column, column)
+		}
+		sourceColumns := make([]string, len(columns))
+		for i, column := range columns {
+			sourceColumns[i] = "source." + column
+		}
+		merge := fmt.Sprintf("MERGE INTO %s AS target USING (VALUES %s) AS source (%s) ON %s",
+			table, values, strings.Join(columns, ", "), strings.Join(on, " AND "))
+		if len(u.update) > 0 {
+			merge += " WHEN MATCHED THEN UPDATE SET " + joinAssignments(u.update, "source.%s")
+		}
+		merge += fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", strings.Join(columns, ", "), strings.Join(sourceColumns, ", "))
+		if len(u.returning) > 0 {
+			returning := make([]string, len(u.returning))
+			for i, column := range u.returning {
+				returning[i] = "inserted." + column
+			}
+			merge += " OUTPUT " + strings.Join(returning, ", ")
+		}
+		// MERGE must always be terminated by a semicolon
+		return merge + ";", nil
+	}
+	return "", fmt.Errorf("upserts need a dialect, see WithDialect")
+}
+
+// joinAssignments returns the assignments of the columns to the values built with the
+// format value, separated by commas.
+func joinAssignments(columns []string, value string) string {
+	assignments := make([]string, len(columns))
+	for i, column := range columns {
+		assignments[i] = column + " = " + fmt.Sprintf(value, column)
+	}
+	return strings.Join(assignments, ", ")
+}
+
+// expandUpserts returns a copy of queries with the queries annotated with upsert
+// expanded, see ExpandUpsert. The queries map is left untouched.
+func expandUpserts(queries map[string]string, headers map[string]header, cfg *config) (map[string]string, error) {
+	expanded := copyQueries(queries)
+	for _, name := range sortedKeys(headers) {
+		a := headers[name].annotations
+		sql, found := queries[name]
+		if !found || !a.Has("upsert") {
+			continue
+		}
+		upsert, err := ExpandUpsert(sql, cfg.dialect, a.Get("upsert"))
+		if err != nil {
+			return nil, errorf(CodeExpansionFailed, "%w: query %s: %s", ErrCannotLoadQueries, name, err)
+		}
+		expanded[name] = upsert
+	}
+	return expanded, nil
+}
@@ -0,0 +1,115 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExpandUpsert(t *testing.T) {
+	insert := "INSERT INTO cat (id, name, color) VALUES (:id, :name, :color);"
+	testCases := []struct {
+		sql        string
+		d          Dialect
+		annotation string
+		want       string
+		wantErr    string
+	}{
+		{
+			insert, DialectPostgres, "conflict(id) update(name, color)",
+			"INSERT INTO cat (id, name, color) VALUES (:id, :name, :color) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name, color = EXCLUDED.color;", "",
+		},
+		{
+			insert, DialectSQLite, "conflict(id) returning(id)",
+			"INSERT INTO cat (id, name, color) VALUES (:id, :name, :color) ON CONFLICT (id) DO NOTHING RETURNING id;", "",
+		},
+		{
+			insert, DialectMySQL, "conflict(id) update(name,color)",
+			"INSERT INTO cat (id, name, color) VALUES (:id, :name, :color) ON DUPLICATE KEY UPDATE name = VALUES(name), color = VALUES(color);", "",
+		},
+		{
+			"INSERT INTO cat (id, name) VALUES (:id, :name)", DialectMySQL, "conflict(id)",
+			"INSERT INTO cat (id, name) VALUES (:id, :name) ON DUPLICATE KEY UPDATE id = id", "",
+		},
+		{
+			insert, DialectTSQL, "conflict(id) update(name, color) returning(id)",
+			"MERGE INTO cat AS target USING (VALUES (:id, :name, :color)) AS source (id, name, color) ON target.id = source.id" +
+				" WHEN MATCHED THEN UPDATE SET name = source.name, color = source.color" +
+				" WHEN NOT MATCHED THEN INSERT (id, name, color) VALUES (source.id, source.name, source.color) OUTPUT inserted.id;", "",
+		},
+		{
+			"INSERT INTO dbo.cat ([id], name)\nVALUES (@id, @name)", DialectTSQL, "conflict([id])",
+			"MERGE INTO dbo.cat AS target USING (VALUES (@id, @name)) AS source ([id], name) ON target.[id] = source.[id]" +
+				" WHEN NOT MATCHED THEN INSERT ([id], name) VALUES (source.[id], source.name);", "",
+		},
+		{insert, DialectMySQL, "conflict(id) returning(id)", "", "dialect mysql does not support returning columns from an upsert"},
+		{insert, DialectGeneric, "conflict(id)", "", "upserts need a dialect, see WithDialect"},
+		{insert, DialectPostgres, "update(name)", "", "invalid upsert annotation update(name)"},
+		{insert, DialectPostgres, "conflict(id) replace(name)", "", "invalid upsert annotation conflict(id) replace(name)"},
+		{"UPDATE cat SET name = :name;", DialectTSQL, "conflict(id)", "", "not an INSERT INTO statement"},
+		{"INSERT INTO cat VALUES (1);", DialectTSQL, "conflict(id)", "", "the INSERT statement has no column list"},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			sql, err := ExpandUpsert(testCase.sql, testCase.d, testCase.annotation)
+			if testCase.wantErr != "" {
+				if fmt.Sprint(err) != testCase.wantErr {
+					t.Fatalf("got %v, want %s", err, testCase.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err must be nil, got %s", err)
+			}
+			if sql != testCase.want {
+				t.Errorf("got %s, want %s", sql, testCase.want)
+			}
+		})
+	}
+}
+
+func TestLoadUpserts(t *testing.T) {
+	sql := `
+-- query: SaveCat
+-- upsert: conflict(id) update(name)
+INSERT INTO cat (id, name) VALUES (:id, :name);
+
+-- query: FindCat
+SELECT * FROM cat WHERE id = :id;
+`
+	q, err := LoadFromString[struct {
+		SaveCat string `query:"SaveCat"`
+		FindCat string `query:"FindCat"`
+	}](sql, WithDialect(DialectPostgres))
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "INSERT INTO cat (id, name) VALUES (:id, :name) ON CONFLICT (id) DO UPDATE SET name = EXCLUDED.name;"
+	if q.SaveCat != want {
+		t.Errorf("got %s, want %s", q.SaveCat, want)
+	}
+	if q.FindCat != "SELECT * FROM cat WHERE id = :id;" {
+		t.Errorf("got %s, want %s", q.FindCat, "SELECT * FROM cat WHERE id = :id;")
+	}
+	_, err = LoadFromString[struct {
+		SaveCat string `query:"SaveCat"`
+	}](sql)
+	wantErr := "cannot load queries: query SaveCat: upserts need a dialect, see WithDialect"
+	if fmt.Sprint(err) != wantErr {
+		t.Errorf("got %v, want %s", err, wantErr)
+	}
+}
+
+func TestExpandUpsertsCopies(t *testing.T) {
+	queries := map[string]string{"SaveCat": "INSERT INTO cat (id, name) VALUES (:id, :name);"}
+	headers := map[string]header{"SaveCat": parseHeader("-- upsert: conflict(id)\nINSERT INTO cat (id, name) VALUES (:id, :name);")}
+	expanded, err := expandUpserts(queries, headers, &config{dialect: DialectSQLite})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if want := "INSERT INTO cat (id, name) VALUES (:id, :name) ON CONFLICT (id) DO NOTHING;"; expanded["SaveCat"] != want {
+		t.Errorf("got %s, want %s", expanded["SaveCat"], want)
+	}
+	if want := "INSERT INTO cat (id, name) VALUES (:id, :name);"; queries["SaveCat"] != want {
+		t.Errorf("got %s, want the queries untouched", queries["SaveCat"])
+	}
+}
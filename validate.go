@@ -0,0 +1,22 @@
+package sqload
+
+// Validator is a function run on every query at load time, see WithValidator. If it
+// returns an error, the load fails.
+//
+//	requireReview := func(q sqload.Query) error {
+//		if q.Kind == sqload.KindDML && strings.HasPrefix(strings.ToUpper(q.SQL), "UPDATE") && !q.Annotations.Has("reviewed-by") {
+//			return errors.New("UPDATE queries must be reviewed")
+//		}
+//		return nil
+//	}
+type Validator func(q Query) error
+
+// validate runs the validators configured in cfg on q, stopping at the first error.
+func validate(q Query, cfg *config) error {
+	for _, validator := range cfg.validators {
+		if err := validator(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
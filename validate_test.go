@@ -0,0 +1,45 @@
+package sqload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadWithValidator(t *testing.T) {
+	requireDoc := func(q Query) error {
+		if q.Doc == "" {
+			return errors.New("the query has no doc comment")
+		}
+		return nil
+	}
+	requireReview := func(q Query) error {
+		if strings.HasPrefix(q.SQL, "UPDATE") && !q.Annotations.Has("reviewed-by") {
+			return errors.New("UPDATE queries must be reviewed")
+		}
+		return nil
+	}
+	testCases := []struct {
+		sql  string
+		want error
+	}{
+		{"-- query: FindCat\n-- Finds a cat.\nSELECT 1;\n-- query: UpdateCat\n-- Updates a cat.\n-- reviewed-by: neto\nUPDATE cat SET name = :name;", nil},
+		{"-- query: FindCat\nSELECT 1;", fmt.Errorf("%w: query FindCat: the query has no doc comment", ErrCannotLoadQueries)},
+		{"-- query: UpdateCat\n-- Updates a cat.\nUPDATE cat SET name = :name;", fmt.Errorf("%w: query UpdateCat: UPDATE queries must be reviewed", ErrCannotLoadQueries)},
+		{"-- query: B\nSELECT 1;\n-- query: A\nSELECT 1;", fmt.Errorf("%w: query A: the query has no doc comment", ErrCannotLoadQueries)},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := LoadQuerySet(fstest.MapFS{"cat.sql": {Data: []byte(testCase.sql)}}, WithValidator(requireDoc), WithValidator(requireReview))
+			if fmt.Sprint(err) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %v", err, testCase.want)
+			}
+			_, err = LoadFromString[struct{}](testCase.sql, WithValidator(requireDoc, requireReview))
+			if fmt.Sprint(err) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %v", err, testCase.want)
+			}
+		})
+	}
+}
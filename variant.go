@@ -0,0 +1,85 @@
+package sqload
+
+import (
+	"sort"
+	"strings"
+)
+
+// variantSeparator separates the name of a query from the name of its variant in the
+// keys of the query maps, so the variant FindUser variant=fast is stored as
+// FindUser:fast. The separator is not valid in query names, so keys can not clash.
+const variantSeparator = ":"
+
+// variantKey returns the key of the variant variant of the query name.
+func variantKey(name, variant string) string {
+	return name + variantSeparator + variant
+}
+
+// withoutVariants returns a new map with the queries of queries that are not variants,
+// for the functions returning plain query maps.
+func withoutVariants(queries map[string]string) map[string]string {
+	if queries == nil {
+		return nil
+	}
+	filtered := make(map[string]string, len(queries))
+	for key, sql := range queries {
+		if !strings.Contains(key, variantSeparator) {
+			filtered[key] = sql
+		}
+	}
+	return filtered
+}
+
+// Flags selects, for each query name, the variant of the query that must be used. They
+// are usually computed per request by a feature flag system, so an alternative version
+// of a query can be rolled out to a part of the traffic and turned off at once.
+type Flags map[string]string
+
+// Select returns the SQL code of the variant of the query name chosen by flags. If flags
+// does not choose a variant for name, or the chosen variant does not exist, the default
+// version of the query is returned, so removing a variant from the SQL files or from
+// flags falls back to the default version.
+//
+// Variants are written next to the default version of a query with the variant
+// attribute:
+//
+//	-- query: FindUser
+//	SELECT * FROM user WHERE email = :email;
+//
+//	-- query: FindUser variant=fast
+//	SELECT * FROM user_by_email WHERE email = :email;
+//
+//	sql, found := qs.Select("FindUser", sqload.Flags{"FindUser": "fast"})
+//
+// The Load functions that bind queries into a struct can also get a variant directly,
+// with a query:"FindUser:fast" tag.
+func (qs *QuerySet) Select(name string, flags Flags) (string, bool) {
+	q, found := qs.SelectQuery(name, flags)
+	return q.SQL, found
+}
+
+// SelectQuery is like Select but it returns the selected query with its metadata.
+func (qs *QuerySet) SelectQuery(name string, flags Flags) (Query, bool) {
+	if variant, ok := flags[name]; ok {
+		if q, found := qs.variants[name][variant]; found {
+			return q, true
+		}
+	}
+	return qs.Query(name)
+}
+
+// Variants returns the names of the variants of the query name, sorted alphabetically.
+func (qs *QuerySet) Variants(name string) []string {
+	variants := make([]string, 0, len(qs.variants[name]))
+	for variant := range qs.variants[name] {
+		variants = append(variants, variant)
+	}
+	sort.Strings(variants)
+	return variants
+}
+
+// Select returns the SQL code of the variant of the query name chosen by flags from the
+// current QuerySet, see QuerySet.Select.
+func (s *QueryStore) Select(name string, flags Flags) (string, bool) {
+	return s.QuerySet().Select(name, flags)
+}
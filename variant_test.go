@@ -0,0 +1,129 @@
+package sqload
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+)
+
+var variantTestSql = `
+-- query: FindUser
+SELECT * FROM user WHERE email = :email;
+
+-- query: FindUser variant=fast
+-- owner: search-team
+SELECT * FROM user_by_email WHERE email = :email;
+
+-- query: FindUser   variant=cached
+SELECT * FROM user_cache WHERE email = :email;
+
+-- query: DeleteUser
+DELETE FROM user WHERE id = :id;
+`
+
+func TestQuerySetSelect(t *testing.T) {
+	qs, err := LoadQuerySet(fstest.MapFS{"user.sql": {Data: []byte(variantTestSql)}})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	testCases := []struct {
+		name  string
+		flags Flags
+		want  string
+	}{
+		{"FindUser", nil, "SELECT * FROM user WHERE email = :email;"},
+		{"FindUser", Flags{"FindUser": "fast"}, "SELECT * FROM user_by_email WHERE email = :email;"},
+		{"FindUser", Flags{"FindUser": "cached", "DeleteUser": "fast"}, "SELECT * FROM user_cache WHERE email = :email;"},
+		{"FindUser", Flags{"FindUser": "slow"}, "SELECT * FROM user WHERE email = :email;"},
+		{"DeleteUser", Flags{"DeleteUser": "fast"}, "DELETE FROM user WHERE id = :id;"},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			sql, found := qs.Select(testCase.name, testCase.flags)
+			if !found {
+				t.Fatalf("query %s not found", testCase.name)
+			}
+			if sql != testCase.want {
+				t.Errorf("got %s, want %s", sql, testCase.want)
+			}
+		})
+	}
+	if _, found := qs.Select("FindCat", Flags{"FindCat": "fast"}); found {
+		t.Error("found is true")
+	}
+	q, _ := qs.SelectQuery("FindUser", Flags{"FindUser": "fast"})
+	if q.Name != "FindUser" || q.Variant != "fast" || q.Annotations.Get("owner") != "search-team" {
+		t.Errorf("got %+v, want the fast variant of FindUser", q)
+	}
+	if fmt.Sprint(qs.Variants("FindUser")) != "[cached fast]" {
+		t.Errorf("got %v, want %v", qs.Variants("FindUser"), "[cached fast]")
+	}
+	if len(qs.Variants("DeleteUser")) != 0 {
+		t.Errorf("got %v, want []", qs.Variants("DeleteUser"))
+	}
+	if fmt.Sprint(qs.Names()) != "[DeleteUser FindUser]" {
+		t.Errorf("got %v, want %v", qs.Names(), "[DeleteUser FindUser]")
+	}
+}
+
+func TestQueryStoreSelect(t *testing.T) {
+	store := NewQueryStore()
+	if err := store.Reload(fstest.MapFS{"user.sql": {Data: []byte(variantTestSql)}}); err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	sql, _ := store.Select("FindUser", Flags{"FindUser": "fast"})
+	if sql != "SELECT * FROM user_by_email WHERE email = :email;" {
+		t.Errorf("got %s, want %s", sql, "SELECT * FROM user_by_email WHERE email = :email;")
+	}
+}
+
+func TestLoadVariants(t *testing.T) {
+	q, err := LoadFromString[struct {
+		FindUser     string `query:"FindUser"`
+		FindUserFast string `query:"FindUser:fast"`
+	}](variantTestSql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if q.FindUser != "SELECT * FROM user WHERE email = :email;" {
+		t.Errorf("got %s, want %s", q.FindUser, "SELECT * FROM user WHERE email = :email;")
+	}
+	if q.FindUserFast != "SELECT * FROM user_by_email WHERE email = :email;" {
+		t.Errorf("got %s, want %s", q.FindUserFast, "SELECT * FROM user_by_email WHERE email = :email;")
+	}
+	// The query maps only hold the default versions of the queries
+	queries, err := ExtractQueryMap(variantTestSql)
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	want := "map[DeleteUser:DELETE FROM user WHERE id = :id; FindUser:SELECT * FROM user WHERE email = :email;]"
+	if fmt.Sprint(queries) != want {
+		t.Errorf("got %v, want %s", queries, want)
+	}
+	queries, err = ExtractQueryMapFromFS(fstest.MapFS{"users.sql": {Data: []byte(variantTestSql)}})
+	if err != nil {
+		t.Fatalf("err must be nil, got %s", err)
+	}
+	if fmt.Sprint(queries) != want {
+		t.Errorf("got %v, want %s", queries, want)
+	}
+	testCases := []struct {
+		sql  string
+		want error
+	}{
+		{"-- query: FindUser variant=fast\nSELECT 1;", fmt.Errorf("%w: query FindUser has variants but no default version", ErrCannotLoadQueries)},
+		{"-- query: FindUser 1color=red\nSELECT 1;", fmt.Errorf("%w: invalid query attribute 1color=red of query FindUser", ErrCannotLoadQueries)},
+		{"-- query: FindUser color=red\nSELECT 1;", fmt.Errorf("%w: unknown query attribute color=red of query FindUser, see WithAttributes", ErrCannotLoadQueries)},
+		{"-- query: FindUser\nSELECT 1;\n-- query: FindUser varient=fast\nSELECT 2;", fmt.Errorf("%w: unknown query attribute varient=fast of query FindUser, see WithAttributes", ErrCannotLoadQueries)},
+		{"-- query: FindUser variant=\nSELECT 1;", fmt.Errorf("%w: invalid query attribute variant= of query FindUser", ErrCannotLoadQueries)},
+		{"-- query: Find User\nSELECT 1;", fmt.Errorf("%w: invalid query name Find User", ErrCannotLoadQueries)},
+	}
+	for i, testCase := range testCases {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := ExtractQueryMap(testCase.sql)
+			if fmt.Sprint(err) != fmt.Sprint(testCase.want) {
+				t.Errorf("got %v, want %s", err, testCase.want)
+			}
+		})
+	}
+}